@@ -0,0 +1,286 @@
+// Package admin serve a HTTP admin API and web UI for a zbolt DB
+package admin
+
+import (
+	"bytes"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dukangxu/zbolt"
+)
+
+//go:embed ui
+var ui embed.FS
+
+// Handler HTTP admin handler of a DB
+type Handler struct {
+	db            *zbolt.DB
+	mux           *http.ServeMux
+	backupHandler http.Handler
+}
+
+// BucketInfo name and size of a bucket
+type BucketInfo struct {
+	Name  zbolt.DumpValue `json:"name"`
+	Keys  int             `json:"keys"`
+	Bytes int             `json:"bytes"`
+}
+
+// Record key value of a bucket
+type Record struct {
+	Key   zbolt.DumpValue `json:"key"`
+	Value zbolt.DumpValue `json:"value"`
+}
+
+// BatchOp operation of a batch decoded into zbolt.Operation, Op is a zbolt.OpKind like get, put, delete, sortPut
+// or sortDelete. Bucket, Key, Value and SortKey are text, 0x prefix for hex
+type BatchOp struct {
+	Op      string `json:"op"`
+	Bucket  string `json:"bucket"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	SortKey string `json:"sortKey,omitempty"`
+}
+
+// BatchResult result of an operation of a batch, Value is set for get of existing key
+type BatchResult struct {
+	Value *zbolt.DumpValue `json:"value,omitempty"`
+}
+
+// Stats stats of the DB
+type Stats struct {
+	Buckets []BucketInfo  `json:"buckets"`
+	GC      zbolt.GCStats `json:"gc"`
+}
+
+// NewHandler create admin handler, serve web UI at / and JSON API at /api/
+func NewHandler(db *zbolt.DB) *Handler {
+	h := &Handler{db: db, mux: http.NewServeMux(), backupHandler: zbolt.BackupHandler(db)}
+	static, _ := fs.Sub(ui, "ui")
+	h.mux.Handle("/", http.FileServer(http.FS(static)))
+	h.mux.HandleFunc("/api/buckets", h.buckets)
+	h.mux.HandleFunc("/api/keys", h.keys)
+	h.mux.HandleFunc("/api/stats", h.stats)
+	h.mux.HandleFunc("/api/backup", h.backup)
+	h.mux.HandleFunc("/api/compact", h.compact)
+	h.mux.HandleFunc("/api/eval", h.eval)
+	h.mux.HandleFunc("/api/health", h.health)
+	h.mux.HandleFunc("/api/batch", h.batch)
+	return h
+}
+
+// ServeHTTP implement http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// parseName parse bucket name or key parameter, 0x prefix for hex
+func parseName(s string) ([]byte, error) {
+	if strings.HasPrefix(s, "0x") {
+		return hex.DecodeString(s[2:])
+	}
+	return []byte(s), nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// bucketInfos get name and size of all buckets, internal buckets are skipped
+func (h *Handler) bucketInfos() ([]BucketInfo, error) {
+	tx := h.db.NewTx(false)
+	defer tx.Rollback()
+	var names [][]byte
+	err := tx.ForEachBucket(func(name []byte) error {
+		if len(name) > 0 && name[0] >= ' ' {
+			names = append(names, append([]byte{}, name...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	infos := []BucketInfo{}
+	for _, name := range names {
+		info := BucketInfo{Name: zbolt.NewDumpValue(name)}
+		err := tx.ForEach(name, func(k, v []byte) error {
+			info.Keys++
+			info.Bytes += len(k) + len(v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (h *Handler) buckets(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.bucketInfos()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, infos)
+}
+
+// keys list records of bucket with prefix, after given key, at most limit
+func (h *Handler) keys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name, err := parseName(q.Get("bucket"))
+	if err != nil || len(name) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid bucket"))
+		return
+	}
+	prefix, err := parseName(q.Get("prefix"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := 100
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	tx := h.db.NewTx(false)
+	defer tx.Rollback()
+	records := []Record{}
+	it := tx.Cursor(name)
+	for ok := it.Seek(prefix); ok && bytes.HasPrefix(it.Key(), prefix); ok = it.Next() {
+		records = append(records, Record{Key: zbolt.NewDumpValue(it.Key()), Value: zbolt.NewDumpValue(it.Value())})
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, records)
+}
+
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.bucketInfos()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, Stats{Buckets: infos, GC: h.db.GCStats()})
+}
+
+// backup stream a snapshot of the db file by zbolt.BackupHandler, POST is served like GET
+func (h *Handler) backup(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		r = r.Clone(r.Context())
+		r.Method = http.MethodGet
+	}
+	h.backupHandler.ServeHTTP(w, r)
+}
+
+// compact apply gc policies of versioned buckets
+func (h *Handler) compact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if err := h.db.RunGC(0); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, h.db.GCStats())
+}
+
+// eval run query given by q parameter, see zbolt.DB.Eval
+func (h *Handler) eval(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Eval(r.FormValue("q"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		records[i] = Record{Key: zbolt.NewDumpValue(row.Key), Value: zbolt.NewDumpValue(row.Value)}
+	}
+	writeJSON(w, records)
+}
+
+// health report DB health, respond 503 when unhealthy
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	report := h.db.Health()
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// batch run operations posted as JSON array of BatchOp in order in one transaction, respond their results.
+// Nothing is written if an operation fails
+func (h *Handler) batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var ops []BatchOp
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	tops := make([]zbolt.Operation, len(ops))
+	for i, op := range ops {
+		top, err := op.operation()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("op %d %s: %w", i, op.Op, err))
+			return
+		}
+		tops[i] = top
+	}
+	var values [][]byte
+	err := h.db.Update(func(tx *zbolt.Tx) error {
+		var err error
+		values, err = tx.Apply(tops...)
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	results := make([]BatchResult, len(values))
+	for i, v := range values {
+		if v != nil {
+			dv := zbolt.NewDumpValue(v)
+			results[i].Value = &dv
+		}
+	}
+	writeJSON(w, results)
+}
+
+// operation decode text fields of op into operation run by zbolt.Tx.Apply
+func (op BatchOp) operation() (zbolt.Operation, error) {
+	var args [4][]byte
+	for i, s := range []string{op.Bucket, op.Key, op.Value, op.SortKey} {
+		b, err := parseName(s)
+		if err != nil {
+			return zbolt.Operation{}, err
+		}
+		args[i] = b
+	}
+	return zbolt.Operation{Kind: zbolt.OpKind(op.Op), Bucket: args[0], Key: args[1], Value: args[2], SortKey: args[3]}, nil
+}
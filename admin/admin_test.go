@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dukangxu/zbolt"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	db, err := zbolt.Open(filepath.Join(t.TempDir(), "admin.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	tx := db.NewTx(true)
+	tx.Put([]byte("users"), []byte("us_1"), []byte(`{"email":"a@b.c"}`), []byte("us_2"), []byte("bob"), []byte("x"), []byte("y"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(NewHandler(db))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestHandler_Keys(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := http.Get(s.URL + "/api/keys?bucket=users&prefix=us_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var records []Record
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Value.Type != zbolt.DumpJSON || records[1].Key.Value != "us_2" {
+		t.Fatal("unexpected records", records)
+	}
+}
+
+func TestHandler_Stats(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := http.Get(s.URL + "/api/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var stats Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Buckets) != 1 || stats.Buckets[0].Keys != 3 {
+		t.Fatal("unexpected stats", stats)
+	}
+}
+
+func TestHandler_Health(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := http.Get(s.URL + "/api/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report zbolt.HealthReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !report.Healthy || !report.Writable {
+		t.Fatal("unexpected health", resp.StatusCode, report)
+	}
+}
+
+func TestHandler_UI(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := http.Get(s.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatal("ui not served", resp.Status)
+	}
+	resp, err = http.Post(s.URL+"/api/backup", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength <= 0 {
+		t.Fatal("backup failed", resp.Status, resp.ContentLength)
+	}
+	if body, err := io.ReadAll(resp.Body); err != nil || int64(len(body)) != resp.ContentLength {
+		t.Fatal("backup truncated", len(body), err)
+	}
+}
+
+func TestHandler_Eval(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := http.Get(s.URL + "/api/eval?q=" + url.QueryEscape("scan bucket=users prefix=us_ | json .email"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var records []Record
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Value.Value != "a@b.c" {
+		t.Fatal("unexpected records", records)
+	}
+}
+
+func TestHandler_Batch(t *testing.T) {
+	s := newTestServer(t)
+	post := func(body string) (*http.Response, []BatchResult) {
+		resp, err := http.Post(s.URL+"/api/batch", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var results []BatchResult
+		json.NewDecoder(resp.Body).Decode(&results)
+		return resp, results
+	}
+	resp, results := post(`[
+		{"op": "put", "bucket": "users", "key": "us_3", "value": "carol"},
+		{"op": "delete", "bucket": "users", "key": "x"},
+		{"op": "get", "bucket": "users", "key": "us_3"},
+		{"op": "get", "bucket": "users", "key": "x"}
+	]`)
+	if resp.StatusCode != http.StatusOK || len(results) != 4 || results[2].Value.Value != "carol" || results[3].Value != nil {
+		t.Fatal("unexpected results", resp.Status, results)
+	}
+	resp, _ = post(`[
+		{"op": "put", "bucket": "users", "key": "us_4", "value": "dave"},
+		{"op": "rename", "bucket": "users", "key": "us_4"}
+	]`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expect bad request", resp.Status)
+	}
+	if _, results = post(`[{"op": "get", "bucket": "users", "key": "us_4"}]`); results[0].Value != nil {
+		t.Fatal("failed batch must not write", results)
+	}
+}
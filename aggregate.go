@@ -0,0 +1,136 @@
+package zbolt
+
+import (
+	"bytes"
+	"container/heap"
+	"sort"
+)
+
+// AggregateInts sum, min, max and count of values in key range [start, end) in one scan,
+// values are int64 encoded as 8 bytes big endian like Uint64ToBytes(uint64(v)), other values are skipped.
+// nil start begin with the first key, nil end stop after the last key
+func (tx *Tx) AggregateInts(name []byte, start, end []byte) (sum, min, max int64, count int) {
+	if tx.err != nil {
+		return
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return
+	}
+	r := tx.reader(name)
+	c := b.Cursor()
+	var k, v []byte
+	if start == nil {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(start)
+	}
+	for ; k != nil && (end == nil || bytes.Compare(k, end) < 0); k, v = c.Next() {
+		if tx.canceled() {
+			return 0, 0, 0, 0
+		}
+		v = r.value(k, v)
+		if len(v) != 8 {
+			continue
+		}
+		n := int64(BytesToUint64(v))
+		if count == 0 || n < min {
+			min = n
+		}
+		if count == 0 || n > max {
+			max = n
+		}
+		sum += n
+		count++
+	}
+	return
+}
+
+// GroupCount count keys of bucket grouped by their first prefixLen bytes in one scan,
+// shorter keys are grouped by the whole key
+func (tx *Tx) GroupCount(name []byte, prefixLen int) map[string]int {
+	counts := make(map[string]int)
+	tx.group(name, prefixLen, func(group string, v []byte) {
+		counts[group]++
+	})
+	return counts
+}
+
+// GroupSumInts sum int64 values of bucket grouped by first prefixLen bytes of keys in one scan,
+// values are encoded like AggregateInts, other values are skipped
+func (tx *Tx) GroupSumInts(name []byte, prefixLen int) map[string]int64 {
+	sums := make(map[string]int64)
+	tx.group(name, prefixLen, func(group string, v []byte) {
+		if len(v) == 8 {
+			sums[group] += int64(BytesToUint64(v))
+		}
+	})
+	return sums
+}
+
+// group call fn with group of every key value of bucket
+func (tx *Tx) group(name []byte, prefixLen int, fn func(group string, v []byte)) {
+	tx.ForEach(name, func(k, v []byte) error {
+		if len(k) > prefixLen {
+			k = k[:prefixLen]
+		}
+		fn(string(k), v)
+		return nil
+	})
+}
+
+// TopK get k key value of bucket with the highest score in one scan, like [key1,value1,key2,value2, ...] ordered by score descending,
+// ties keep the first scanned key
+func (tx *Tx) TopK(name []byte, k int, score func(k, v []byte) int64) [][]byte {
+	if k <= 0 {
+		return [][]byte{}
+	}
+	h := &scoreHeap{}
+	tx.ForEach(name, func(key, v []byte) error {
+		s := score(key, v)
+		if h.Len() < k {
+			heap.Push(h, scored{s, h.n, append([]byte{}, key...), append([]byte{}, v...)})
+		} else if s > h.items[0].score {
+			h.items[0] = scored{s, h.n, append([]byte{}, key...), append([]byte{}, v...)}
+			heap.Fix(h, 0)
+		}
+		h.n++
+		return nil
+	})
+	sort.Slice(h.items, func(i, j int) bool { return h.less(j, i) })
+	bs := make([][]byte, 0, 2*len(h.items))
+	for _, s := range h.items {
+		bs = append(bs, s.key, s.value)
+	}
+	return bs
+}
+
+// scored key value with score and scan order
+type scored struct {
+	score      int64
+	seq        int
+	key, value []byte
+}
+
+// scoreHeap min heap of scored, the lowest score and latest scanned on top
+type scoreHeap struct {
+	items []scored
+	n     int
+}
+
+func (h *scoreHeap) less(i, j int) bool {
+	if h.items[i].score != h.items[j].score {
+		return h.items[i].score < h.items[j].score
+	}
+	return h.items[i].seq > h.items[j].seq
+}
+
+func (h *scoreHeap) Len() int           { return len(h.items) }
+func (h *scoreHeap) Less(i, j int) bool { return h.less(i, j) }
+func (h *scoreHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *scoreHeap) Push(x interface{}) { h.items = append(h.items, x.(scored)) }
+func (h *scoreHeap) Pop() interface{} {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
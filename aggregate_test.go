@@ -0,0 +1,68 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_AggregateInts(t *testing.T) {
+	name := []byte("aggregate_ints")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.DeleteBucket(name)
+	tx.Error(ErrNil)
+	for i, n := range []int64{5, -3, 10, 7} {
+		tx.Put(name, Uint64ToBytes(uint64(i)), Uint64ToBytes(uint64(n)))
+	}
+	tx.Put(name, Uint64ToBytes(9), []byte("not a number"))
+	if sum, min, max, count := tx.AggregateInts(name, nil, nil); sum != 19 || min != -3 || max != 10 || count != 4 {
+		t.Fatal("unexpected aggregate", sum, min, max, count)
+	}
+	if sum, min, max, count := tx.AggregateInts(name, Uint64ToBytes(1), Uint64ToBytes(3)); sum != 7 || min != -3 || max != 10 || count != 2 {
+		t.Fatal("unexpected range aggregate", sum, min, max, count)
+	}
+	if _, _, _, count := tx.AggregateInts([]byte("aggregate_none"), nil, nil); count != 0 {
+		t.Fatal("unexpected count of missing bucket", count)
+	}
+}
+
+func TestTx_GroupCount(t *testing.T) {
+	name := []byte("aggregate_events")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.DeleteBucket(name)
+	tx.Error(ErrNil)
+	tx.Put(name,
+		[]byte("u1:e1"), Uint64ToBytes(2),
+		[]byte("u1:e2"), Uint64ToBytes(3),
+		[]byte("u2:e1"), Uint64ToBytes(4),
+		[]byte("u"), []byte("x"),
+	)
+	if counts := tx.GroupCount(name, 3); len(counts) != 3 || counts["u1:"] != 2 || counts["u2:"] != 1 || counts["u"] != 1 {
+		t.Fatal("unexpected counts", counts)
+	}
+	if sums := tx.GroupSumInts(name, 3); len(sums) != 2 || sums["u1:"] != 5 || sums["u2:"] != 4 {
+		t.Fatal("unexpected sums", sums)
+	}
+}
+
+func TestTx_TopK(t *testing.T) {
+	name := []byte("aggregate_scores")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.DeleteBucket(name)
+	tx.Error(ErrNil)
+	for _, kv := range []struct {
+		k string
+		s uint64
+	}{{"a", 5}, {"b", 9}, {"c", 1}, {"d", 9}, {"e", 7}} {
+		tx.Put(name, []byte(kv.k), Uint64ToBytes(kv.s))
+	}
+	score := func(k, v []byte) int64 { return int64(BytesToUint64(v)) }
+	top := tx.TopK(name, 3, score)
+	if len(top) != 6 || string(top[0]) != "b" || string(top[2]) != "d" || string(top[4]) != "e" {
+		t.Fatalf("unexpected top %q", top)
+	}
+	if top := tx.TopK(name, 10, score); len(top) != 10 || string(top[8]) != "c" {
+		t.Fatalf("unexpected top of all %q", top)
+	}
+}
@@ -0,0 +1,84 @@
+package zbolt
+
+import (
+	"bytes"
+)
+
+// SetAppendOnly declare keys of bucket are put in increasing order, like log entries.
+// Pages of the bucket are then filled completely before split instead of half, and the last key of bucket is read
+// once by a Put so old values of keys after it are not read. Putting keys out of order still works but splits
+// pages more often
+func (db *DB) SetAppendOnly(name []byte, enabled bool) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.appendOnly = enabled
+	})
+}
+
+// Append put value with key of next sequence of bucket filling pages completely, return the key.
+// Keys are 8 bytes big endian and increase as long as the bucket is only written by Append
+func (tx *Tx) Append(name []byte, value []byte) ([]byte, error) {
+	seq, err := tx.NextSequence(name)
+	if err != nil {
+		return nil, err
+	}
+	// bucket is cached by the transaction, Put find it with fill percent set
+	fillFull(tx.tx.Bucket(name))
+	key := Uint64ToBytes(seq)
+	return key, tx.Put(name, key, value)
+}
+
+// fillFull fill pages of bucket completely before split if storage engine allow it
+func fillFull(b backendBucket) {
+	if f, ok := unwrapBucket(b).(backendFiller); ok {
+		f.SetFillPercent(1.0)
+	}
+}
+
+// unwrapBucket get bucket of storage engine under key codec and expiry of b
+func unwrapBucket(b backendBucket) backendBucket {
+	for {
+		switch w := b.(type) {
+		case keyBucket:
+			b = w.backendBucket
+		case ttlBucket:
+			b = w.backendBucket
+		default:
+			return b
+		}
+	}
+}
+
+// appendTail cached last key of append-only bucket, keys put after it are not in bucket
+type appendTail struct {
+	b    backendBucket
+	key  []byte
+	read bool
+}
+
+// newAppendTail tail of bucket b with config c, nil if keys of b are encoded as their order may change
+func newAppendTail(b backendBucket, c bucketConfig) *appendTail {
+	if c.keyCodec() != nil {
+		return nil
+	}
+	return &appendTail{b: b}
+}
+
+// after check key is after last key of bucket, the last key is read by a cursor on first call
+func (t *appendTail) after(key []byte) bool {
+	if t == nil {
+		return false
+	}
+	if !t.read {
+		// expired keys are still stored, so the engine bucket is used
+		k, _ := unwrapBucket(t.b).Cursor().Last()
+		t.key, t.read = append([]byte{}, k...), true
+	}
+	return bytes.Compare(key, t.key) > 0
+}
+
+// advance move tail to key put after it
+func (t *appendTail) advance(key []byte) {
+	if t != nil && t.read && bytes.Compare(key, t.key) > 0 {
+		t.key = append(t.key[:0], key...)
+	}
+}
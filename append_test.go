@@ -0,0 +1,74 @@
+package zbolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTx_Append(t *testing.T) {
+	name := []byte("append_log")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	var last []byte
+	for _, v := range []string{"a", "b", "c"} {
+		key, err := tx.Append(name, []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(key) <= string(last) {
+			t.Fatal("keys must increase", key, last)
+		}
+		if got := tx.Get(name, key); len(got) != 2 || string(got[1]) != v {
+			t.Fatal("unexpected value", got)
+		}
+		last = key
+	}
+	b, ok := unwrapBucket(tx.tx.Bucket(name)).(boltBucket)
+	if !ok {
+		t.Fatal("expect bolt bucket", tx.tx.Bucket(name))
+	}
+	if b.FillPercent != 1.0 {
+		t.Fatal("pages of append bucket must be filled completely", b.FillPercent)
+	}
+}
+
+func TestTx_AppendWrapped(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "append.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("append_ttl")
+	d.SetAppendOnly(name, true)
+	if err := d.EnableFastCount(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.PutTTL(name, time.Hour, []byte("a"), []byte("1"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	if _, ok := tx.tx.Bucket(name).(ttlBucket); !ok {
+		t.Fatal("expect bucket wrapped by expiry")
+	}
+	// out of order and repeated keys are counted once, keys after the tail are new
+	if err := tx.Put(name, []byte("c"), []byte("3"), []byte("b"), []byte("2"), []byte("c"), []byte("4"), []byte("d"), []byte("5")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Append(name, []byte("6")); err != nil {
+		t.Fatal(err)
+	}
+	b, ok := unwrapBucket(tx.tx.Bucket(name)).(boltBucket)
+	if !ok || b.FillPercent != 1.0 {
+		t.Fatal("pages of wrapped append bucket must be filled completely", ok, b.FillPercent)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := d.FastCount(name); c.Keys != 5 {
+		t.Fatal("unexpected count", c)
+	}
+}
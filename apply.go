@@ -0,0 +1,65 @@
+package zbolt
+
+import (
+	"fmt"
+)
+
+// OpKind kind of Operation
+type OpKind string
+
+// kinds of operations run by Apply
+const (
+	OpGet        OpKind = "get"
+	OpPut        OpKind = "put"
+	OpDelete     OpKind = "delete"
+	OpSortPut    OpKind = "sortPut"
+	OpSortDelete OpKind = "sortDelete"
+)
+
+// Operation typed operation on a key of bucket run by Apply, SortKey is used by OpSortPut only
+type Operation struct {
+	Kind    OpKind
+	Bucket  []byte
+	Key     []byte
+	Value   []byte
+	SortKey []byte
+}
+
+// Apply run ops in order in tx, like a batch sent by a remote client, stop at the first error which is
+// accumulated in tx. Return a value per op, a copy valid after tx is closed set for OpGet of an existing key
+func (tx *Tx) Apply(ops ...Operation) ([][]byte, error) {
+	values := make([][]byte, len(ops))
+	if tx.err != nil {
+		return values, tx.err
+	}
+	for i, op := range ops {
+		if err := tx.apply(op, &values[i]); err != nil {
+			return values, tx.Error(fmt.Errorf("op %d %s: %w", i, op.Kind, err))
+		}
+	}
+	return values, nil
+}
+
+// apply run op in tx, set value of get
+func (tx *Tx) apply(op Operation, value *[]byte) error {
+	if len(op.Bucket) == 0 || len(op.Key) == 0 {
+		return fmt.Errorf("%w: bucket and key required", ErrInvalidOp)
+	}
+	switch op.Kind {
+	case OpGet:
+		if gets := tx.Get(op.Bucket, op.Key); len(gets) == 2 {
+			// copied, the value is read after tx is closed
+			*value = append([]byte{}, gets[1]...)
+		}
+		return tx.err
+	case OpPut:
+		return tx.Put(op.Bucket, op.Key, op.Value)
+	case OpDelete:
+		return tx.Delete(op.Bucket, op.Key)
+	case OpSortPut:
+		return tx.SortPut(op.Bucket, op.SortKey, op.Key, op.Value)
+	case OpSortDelete:
+		return tx.SortDelete(op.Bucket, op.Key)
+	}
+	return fmt.Errorf("%w: unknown kind %q", ErrInvalidOp, op.Kind)
+}
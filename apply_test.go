@@ -0,0 +1,30 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_Apply(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("apply")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	values, err := tx.Apply(
+		Operation{Kind: OpPut, Bucket: name, Key: []byte("a"), Value: []byte("1")},
+		Operation{Kind: OpPut, Bucket: name, Key: []byte("b"), Value: []byte("2")},
+		Operation{Kind: OpDelete, Bucket: name, Key: []byte("b")},
+		Operation{Kind: OpGet, Bucket: name, Key: []byte("a")},
+		Operation{Kind: OpGet, Bucket: name, Key: []byte("b")},
+	)
+	if err != nil || len(values) != 5 || string(values[3]) != "1" || values[4] != nil {
+		t.Fatal("unexpected values", values, err)
+	}
+	if _, err := tx.Apply(Operation{Kind: "rename", Bucket: name, Key: []byte("a")}); !errors.Is(err, ErrInvalidOp) || tx.Error() != err {
+		t.Fatal("expect ErrInvalidOp accumulated in tx, got", err)
+	}
+}
@@ -0,0 +1,99 @@
+package zbolt
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Backend storage engine of DB
+type Backend string
+
+// storage engines, files of both are compatible
+const (
+	BackendBolt  Backend = "bolt"  // github.com/boltdb/bolt, the default
+	BackendBbolt Backend = "bbolt" // go.etcd.io/bbolt, maintained fork of bolt
+)
+
+// backendOptions options passed to storage engine on open
+type backendOptions struct {
+	Mode            os.FileMode
+	Timeout         time.Duration
+	ReadOnly        bool
+	NoGrowSync      bool
+	NoSync          bool
+	InitialMmapSize int
+	MmapFlags       int
+}
+
+// backend storage engine with the API of bolt.DB used by zbolt
+type backend interface {
+	Begin(writable bool) (backendTx, error)
+	Batch(fn func(tx backendTx) error) error
+	SetBatch(maxSize int, maxDelay time.Duration)
+	Close() error
+	IsReadOnly() bool
+	Stats() Stats
+}
+
+// backendTx transaction of storage engine, Bucket return nil if bucket not exist
+type backendTx interface {
+	Bucket(name []byte) backendBucket
+	CreateBucketIfNotExists(name []byte) (backendBucket, error)
+	DeleteBucket(name []byte) error
+	ForEach(fn func(name []byte, b backendBucket) error) error
+	Writable() bool
+	ID() int
+	WriteTo(w io.Writer) (int64, error)
+	Commit() error
+	Rollback() error
+}
+
+// backendBucket bucket of storage engine
+type backendBucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	ForEach(fn func(k, v []byte) error) error
+	Cursor() backendCursor
+	Sequence() uint64
+	NextSequence() (uint64, error)
+	SetSequence(v uint64) error
+}
+
+// backendKeyCounter bucket counting its keys from page stats instead of walking them
+type backendKeyCounter interface {
+	KeyN() int
+}
+
+// backendFiller bucket with tunable fill percent of split pages
+type backendFiller interface {
+	SetFillPercent(f float64)
+}
+
+// backendFile storage engine keeping data in a bolt file at Path
+type backendFile interface {
+	Path() string
+}
+
+// backendSizer transaction knowing the size of the snapshot WriteTo writes
+type backendSizer interface {
+	Size() int64
+}
+
+// backendCursor cursor of storage engine bucket
+type backendCursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	Seek(seek []byte) (key, value []byte)
+	Delete() error
+}
+
+// backendOpeners open storage engine by name
+var backendOpeners = map[Backend]func(path string, o backendOptions) (backend, error){
+	BackendBolt:   openBolt,
+	BackendBbolt:  openBbolt,
+	BackendMemory: openMemory,
+}
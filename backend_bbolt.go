@@ -0,0 +1,176 @@
+package zbolt
+
+import (
+	"io"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// bboltDB backend of go.etcd.io/bbolt
+type bboltDB struct {
+	db *bbolt.DB
+}
+
+// bboltTx transaction of bboltDB
+type bboltTx struct {
+	tx *bbolt.Tx
+}
+
+// bboltBucket bucket of bboltDB
+type bboltBucket struct {
+	*bbolt.Bucket
+}
+
+func openBbolt(path string, o backendOptions) (backend, error) {
+	db, err := bbolt.Open(path, o.Mode, &bbolt.Options{
+		Timeout:         o.Timeout,
+		ReadOnly:        o.ReadOnly,
+		NoGrowSync:      o.NoGrowSync,
+		InitialMmapSize: o.InitialMmapSize,
+		MmapFlags:       o.MmapFlags,
+	})
+	if err == bbolt.ErrTimeout {
+		return nil, ErrTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.NoSync = o.NoSync
+	return bboltDB{db}, nil
+}
+
+func (db bboltDB) Begin(writable bool) (backendTx, error) {
+	tx, err := db.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+	return bboltTx{tx}, nil
+}
+
+func (db bboltDB) Batch(fn func(tx backendTx) error) error {
+	return db.db.Batch(func(tx *bbolt.Tx) error {
+		return fn(bboltTx{tx})
+	})
+}
+
+func (db bboltDB) SetBatch(maxSize int, maxDelay time.Duration) {
+	if maxSize > 0 {
+		db.db.MaxBatchSize = maxSize
+	}
+	if maxDelay > 0 {
+		db.db.MaxBatchDelay = maxDelay
+	}
+}
+
+func (db bboltDB) Close() error {
+	return db.db.Close()
+}
+
+func (db bboltDB) Path() string {
+	return db.db.Path()
+}
+
+func (db bboltDB) IsReadOnly() bool {
+	return db.db.IsReadOnly()
+}
+
+func (db bboltDB) Stats() Stats {
+	s := db.db.Stats()
+	t := s.TxStats
+	return Stats{
+		FreePageN:     s.FreePageN,
+		PendingPageN:  s.PendingPageN,
+		FreeAlloc:     s.FreeAlloc,
+		FreelistInuse: s.FreelistInuse,
+		TxN:           s.TxN,
+		OpenTxN:       s.OpenTxN,
+		PageCount:     t.PageCount,
+		PageAlloc:     t.PageAlloc,
+		Rebalances:    t.Rebalance,
+		Splits:        t.Split,
+		Spills:        t.Spill,
+		Writes:        t.Write,
+		WriteTime:     t.WriteTime,
+	}
+}
+
+func (tx bboltTx) Bucket(name []byte) backendBucket {
+	if b := tx.tx.Bucket(name); b != nil {
+		return bboltBucket{b}
+	}
+	return nil
+}
+
+func (tx bboltTx) CreateBucketIfNotExists(name []byte) (backendBucket, error) {
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return bboltBucket{b}, nil
+}
+
+func (tx bboltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+func (tx bboltTx) ForEach(fn func(name []byte, b backendBucket) error) error {
+	return tx.tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		return fn(name, bboltBucket{b})
+	})
+}
+
+func (tx bboltTx) Writable() bool {
+	return tx.tx.Writable()
+}
+
+func (tx bboltTx) ID() int {
+	return tx.tx.ID()
+}
+
+func (tx bboltTx) Size() int64 {
+	return tx.tx.Size()
+}
+
+func (tx bboltTx) WriteTo(w io.Writer) (int64, error) {
+	return tx.tx.WriteTo(w)
+}
+
+func (tx bboltTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx bboltTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+func (b bboltBucket) Cursor() backendCursor {
+	return b.Bucket.Cursor()
+}
+
+func (b bboltBucket) SetFillPercent(f float64) {
+	b.Bucket.FillPercent = f
+}
+
+func (b bboltBucket) KeyN() int {
+	return b.Bucket.Stats().KeyN
+}
+
+func (b bboltBucket) BucketStats() BucketStats {
+	s := b.Bucket.Stats()
+	return BucketStats{
+		KeyN:              s.KeyN,
+		Depth:             s.Depth,
+		BranchPageN:       s.BranchPageN,
+		BranchOverflowN:   s.BranchOverflowN,
+		LeafPageN:         s.LeafPageN,
+		LeafOverflowN:     s.LeafOverflowN,
+		BranchAlloc:       s.BranchAlloc,
+		BranchInuse:       s.BranchInuse,
+		LeafAlloc:         s.LeafAlloc,
+		LeafInuse:         s.LeafInuse,
+		BucketN:           s.BucketN,
+		InlineBucketN:     s.InlineBucketN,
+		InlineBucketInuse: s.InlineBucketInuse,
+	}
+}
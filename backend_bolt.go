@@ -0,0 +1,173 @@
+package zbolt
+
+import (
+	"io"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltDB backend of github.com/boltdb/bolt
+type boltDB struct {
+	db *bolt.DB
+}
+
+// boltTx transaction of boltDB
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// boltBucket bucket of boltDB
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func openBolt(path string, o backendOptions) (backend, error) {
+	db, err := bolt.Open(path, o.Mode, &bolt.Options{
+		Timeout:         o.Timeout,
+		ReadOnly:        o.ReadOnly,
+		NoGrowSync:      o.NoGrowSync,
+		InitialMmapSize: o.InitialMmapSize,
+		MmapFlags:       o.MmapFlags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.NoSync = o.NoSync
+	return boltDB{db}, nil
+}
+
+func (db boltDB) Begin(writable bool) (backendTx, error) {
+	tx, err := db.db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+	return boltTx{tx}, nil
+}
+
+func (db boltDB) Batch(fn func(tx backendTx) error) error {
+	return db.db.Batch(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (db boltDB) SetBatch(maxSize int, maxDelay time.Duration) {
+	if maxSize > 0 {
+		db.db.MaxBatchSize = maxSize
+	}
+	if maxDelay > 0 {
+		db.db.MaxBatchDelay = maxDelay
+	}
+}
+
+func (db boltDB) Close() error {
+	return db.db.Close()
+}
+
+func (db boltDB) Path() string {
+	return db.db.Path()
+}
+
+func (db boltDB) IsReadOnly() bool {
+	return db.db.IsReadOnly()
+}
+
+func (db boltDB) Stats() Stats {
+	s := db.db.Stats()
+	t := s.TxStats
+	return Stats{
+		FreePageN:     s.FreePageN,
+		PendingPageN:  s.PendingPageN,
+		FreeAlloc:     s.FreeAlloc,
+		FreelistInuse: s.FreelistInuse,
+		TxN:           s.TxN,
+		OpenTxN:       s.OpenTxN,
+		PageCount:     t.PageCount,
+		PageAlloc:     t.PageAlloc,
+		Rebalances:    t.Rebalance,
+		Splits:        t.Split,
+		Spills:        t.Spill,
+		Writes:        t.Write,
+		WriteTime:     t.WriteTime,
+	}
+}
+
+func (tx boltTx) Bucket(name []byte) backendBucket {
+	if b := tx.tx.Bucket(name); b != nil {
+		return boltBucket{b}
+	}
+	return nil
+}
+
+func (tx boltTx) CreateBucketIfNotExists(name []byte) (backendBucket, error) {
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{b}, nil
+}
+
+func (tx boltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+func (tx boltTx) ForEach(fn func(name []byte, b backendBucket) error) error {
+	return tx.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		return fn(name, boltBucket{b})
+	})
+}
+
+func (tx boltTx) Writable() bool {
+	return tx.tx.Writable()
+}
+
+func (tx boltTx) ID() int {
+	return tx.tx.ID()
+}
+
+func (tx boltTx) Size() int64 {
+	return tx.tx.Size()
+}
+
+func (tx boltTx) WriteTo(w io.Writer) (int64, error) {
+	return tx.tx.WriteTo(w)
+}
+
+func (tx boltTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx boltTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+func (b boltBucket) Cursor() backendCursor {
+	return b.Bucket.Cursor()
+}
+
+func (b boltBucket) SetFillPercent(f float64) {
+	b.Bucket.FillPercent = f
+}
+
+func (b boltBucket) KeyN() int {
+	return b.Bucket.Stats().KeyN
+}
+
+func (b boltBucket) BucketStats() BucketStats {
+	s := b.Bucket.Stats()
+	return BucketStats{
+		KeyN:              s.KeyN,
+		Depth:             s.Depth,
+		BranchPageN:       s.BranchPageN,
+		BranchOverflowN:   s.BranchOverflowN,
+		LeafPageN:         s.LeafPageN,
+		LeafOverflowN:     s.LeafOverflowN,
+		BranchAlloc:       s.BranchAlloc,
+		BranchInuse:       s.BranchInuse,
+		LeafAlloc:         s.LeafAlloc,
+		LeafInuse:         s.LeafInuse,
+		BucketN:           s.BucketN,
+		InlineBucketN:     s.InlineBucketN,
+		InlineBucketInuse: s.InlineBucketInuse,
+	}
+}
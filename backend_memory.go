@@ -0,0 +1,417 @@
+package zbolt
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// BackendMemory in memory storage engine, path is ignored and data is lost on Close
+const BackendMemory Backend = "memory"
+
+// memDB in memory backend, write transactions copy buckets they access and replace them on commit,
+// so read transactions keep a consistent snapshot like bolt
+type memDB struct {
+	writer sync.Mutex // held by the open write transaction
+	mu     sync.RWMutex
+	root   map[string]*memBucket
+	closed bool
+	writes int
+	txid   int // id of the last committed write transaction
+}
+
+// memTx transaction of memDB
+type memTx struct {
+	db       *memDB
+	root     map[string]*memBucket
+	owned    map[string]bool // buckets copied by write transaction
+	id       int
+	writable bool
+	closed   bool
+}
+
+// memBucket sorted keys and values of a bucket
+type memBucket struct {
+	tx     *memTx
+	keys   [][]byte
+	values [][]byte
+	seq    uint64
+}
+
+// memCursor cursor of memBucket
+type memCursor struct {
+	b *memBucket
+	i int
+}
+
+// OpenMemory open DB stored in memory, for fast parallel tests without files
+func OpenMemory() (*DB, error) {
+	return OpenWithOptions("", &Options{Backend: BackendMemory})
+}
+
+func openMemory(path string, o backendOptions) (backend, error) {
+	return &memDB{root: make(map[string]*memBucket)}, nil
+}
+
+func (db *memDB) Begin(writable bool) (backendTx, error) {
+	if writable {
+		db.writer.Lock()
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed {
+		if writable {
+			db.writer.Unlock()
+		}
+		return nil, bolt.ErrDatabaseNotOpen
+	}
+	tx := &memTx{db: db, root: db.root, id: db.txid, writable: writable}
+	if writable {
+		tx.id++
+		tx.root = make(map[string]*memBucket, len(db.root))
+		for name, b := range db.root {
+			tx.root[name] = b
+		}
+		tx.owned = make(map[string]bool)
+	}
+	return tx, nil
+}
+
+func (db *memDB) Batch(fn func(tx backendTx) error) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *memDB) SetBatch(maxSize int, maxDelay time.Duration) {}
+
+func (db *memDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.closed, db.root = true, nil
+	return nil
+}
+
+func (db *memDB) IsReadOnly() bool {
+	return false
+}
+
+func (db *memDB) Stats() Stats {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return Stats{Writes: db.writes}
+}
+
+// bucket get bucket of tx, copy it first if tx is writable
+func (tx *memTx) bucket(name []byte) *memBucket {
+	b := tx.root[string(name)]
+	if b == nil || !tx.writable || tx.owned[string(name)] {
+		return b
+	}
+	c := &memBucket{tx: tx, seq: b.seq}
+	c.keys = append([][]byte{}, b.keys...)
+	c.values = append([][]byte{}, b.values...)
+	tx.root[string(name)], tx.owned[string(name)] = c, true
+	return c
+}
+
+func (tx *memTx) Bucket(name []byte) backendBucket {
+	if b := tx.bucket(name); b != nil {
+		return b
+	}
+	return nil
+}
+
+func (tx *memTx) CreateBucketIfNotExists(name []byte) (backendBucket, error) {
+	switch {
+	case tx.closed:
+		return nil, bolt.ErrTxClosed
+	case !tx.writable:
+		return nil, bolt.ErrTxNotWritable
+	case len(name) == 0:
+		return nil, bolt.ErrBucketNameRequired
+	}
+	if b := tx.bucket(name); b != nil {
+		return b, nil
+	}
+	b := &memBucket{tx: tx}
+	tx.root[string(name)], tx.owned[string(name)] = b, true
+	return b, nil
+}
+
+func (tx *memTx) DeleteBucket(name []byte) error {
+	switch {
+	case tx.closed:
+		return bolt.ErrTxClosed
+	case !tx.writable:
+		return bolt.ErrTxNotWritable
+	case tx.root[string(name)] == nil:
+		return bolt.ErrBucketNotFound
+	}
+	delete(tx.root, string(name))
+	delete(tx.owned, string(name))
+	return nil
+}
+
+func (tx *memTx) ForEach(fn func(name []byte, b backendBucket) error) error {
+	names := make([]string, 0, len(tx.root))
+	for name := range tx.root {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn([]byte(name), tx.bucket([]byte(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *memTx) ID() int {
+	return tx.id
+}
+
+func (tx *memTx) Writable() bool {
+	return tx.writable
+}
+
+// WriteTo write snapshot as a bolt file
+func (tx *memTx) WriteTo(w io.Writer) (int64, error) {
+	f, err := ioutil.TempFile("", "zbolt-memory-*.db")
+	if err != nil {
+		return 0, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = db.Update(func(btx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, mb backendBucket) error {
+			b, err := btx.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+			m := mb.(*memBucket)
+			for i, k := range m.keys {
+				if err := b.Put(k, m.values[i]); err != nil {
+					return err
+				}
+			}
+			return b.SetSequence(m.seq)
+		})
+	})
+	if err == nil {
+		err = db.View(func(btx *bolt.Tx) error {
+			_, err := btx.WriteTo(ioutil.Discard)
+			return err
+		})
+	}
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if f, err = os.Open(path); err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+func (tx *memTx) Commit() error {
+	switch {
+	case tx.closed:
+		return bolt.ErrTxClosed
+	case !tx.writable:
+		return bolt.ErrTxNotWritable
+	}
+	tx.closed = true
+	tx.db.mu.Lock()
+	if !tx.db.closed {
+		tx.db.root = tx.root
+		tx.db.writes += len(tx.owned)
+		tx.db.txid = tx.id
+	}
+	tx.db.mu.Unlock()
+	tx.db.writer.Unlock()
+	return nil
+}
+
+func (tx *memTx) Rollback() error {
+	if tx.closed {
+		return bolt.ErrTxClosed
+	}
+	tx.closed = true
+	if tx.writable {
+		tx.db.writer.Unlock()
+	}
+	return nil
+}
+
+// search index of first key >= key
+func (b *memBucket) search(key []byte) int {
+	return sort.Search(len(b.keys), func(i int) bool {
+		return bytes.Compare(b.keys[i], key) >= 0
+	})
+}
+
+// check error of writing to bucket
+func (b *memBucket) check(key []byte) error {
+	switch {
+	case b.tx.closed:
+		return bolt.ErrTxClosed
+	case !b.tx.writable:
+		return bolt.ErrTxNotWritable
+	case len(key) == 0:
+		return bolt.ErrKeyRequired
+	}
+	return nil
+}
+
+func (b *memBucket) Get(key []byte) []byte {
+	if i := b.search(key); i < len(b.keys) && bytes.Equal(b.keys[i], key) {
+		return b.values[i]
+	}
+	return nil
+}
+
+func (b *memBucket) Put(key, value []byte) error {
+	if err := b.check(key); err != nil {
+		return err
+	}
+	value = append([]byte{}, value...)
+	i := b.search(key)
+	if i < len(b.keys) && bytes.Equal(b.keys[i], key) {
+		b.values[i] = value
+		return nil
+	}
+	b.keys = append(b.keys, nil)
+	b.values = append(b.values, nil)
+	copy(b.keys[i+1:], b.keys[i:])
+	copy(b.values[i+1:], b.values[i:])
+	b.keys[i], b.values[i] = append([]byte{}, key...), value
+	return nil
+}
+
+func (b *memBucket) Delete(key []byte) error {
+	if err := b.check(key); err != nil {
+		return err
+	}
+	if i := b.search(key); i < len(b.keys) && bytes.Equal(b.keys[i], key) {
+		b.remove(i)
+	}
+	return nil
+}
+
+// remove key at index i
+func (b *memBucket) remove(i int) {
+	b.keys = append(b.keys[:i], b.keys[i+1:]...)
+	b.values = append(b.values[:i], b.values[i+1:]...)
+}
+
+func (b *memBucket) ForEach(fn func(k, v []byte) error) error {
+	keys, values := b.keys, b.values
+	for i, k := range keys {
+		if err := fn(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memBucket) Cursor() backendCursor {
+	return &memCursor{b: b, i: -1}
+}
+
+func (b *memBucket) KeyN() int {
+	return len(b.keys)
+}
+
+func (b *memBucket) BucketStats() BucketStats {
+	s := BucketStats{KeyN: len(b.keys), Depth: 1, BucketN: 1}
+	for i, k := range b.keys {
+		s.LeafInuse += len(k) + len(b.values[i])
+	}
+	return s
+}
+
+func (b *memBucket) Sequence() uint64 {
+	return b.seq
+}
+
+func (b *memBucket) NextSequence() (uint64, error) {
+	if err := b.check([]byte{0}); err != nil {
+		return 0, err
+	}
+	b.seq++
+	return b.seq, nil
+}
+
+func (b *memBucket) SetSequence(v uint64) error {
+	if err := b.check([]byte{0}); err != nil {
+		return err
+	}
+	b.seq = v
+	return nil
+}
+
+// at key value at index i, nil if out of range
+func (c *memCursor) at(i int) ([]byte, []byte) {
+	switch {
+	case i < 0:
+		c.i = -1
+		return nil, nil
+	case i >= len(c.b.keys):
+		c.i = len(c.b.keys)
+		return nil, nil
+	}
+	c.i = i
+	return c.b.keys[i], c.b.values[i]
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+func (c *memCursor) Last() ([]byte, []byte) {
+	return c.at(len(c.b.keys) - 1)
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	return c.at(c.i + 1)
+}
+
+func (c *memCursor) Prev() ([]byte, []byte) {
+	return c.at(c.i - 1)
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.at(c.b.search(seek))
+}
+
+func (c *memCursor) Delete() error {
+	if err := c.b.check([]byte{0}); err != nil {
+		return err
+	}
+	if c.i >= 0 && c.i < len(c.b.keys) {
+		c.b.remove(c.i)
+		c.i--
+	}
+	return nil
+}
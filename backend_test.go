@@ -0,0 +1,211 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// compatSteps Tx operations run against every backend, each return a fingerprint of its results
+var compatSteps = []struct {
+	name string
+	run  func(tx *Tx) string
+}{
+	{"put", func(tx *Tx) string {
+		return fmt.Sprint(tx.Put([]byte("b"), []byte("k1"), []byte("v1"), []byte("k2"), []byte("v2"), []byte("k3"), []byte("v3")))
+	}},
+	{"get", func(tx *Tx) string {
+		return fmt.Sprintf("%q", tx.Get([]byte("b"), []byte("k1"), []byte("k9"), []byte("k3")))
+	}},
+	{"upsert", func(tx *Tx) string {
+		err := tx.Upsert([]byte("b"), [][]byte{[]byte("k2"), []byte("x"), []byte("k4"), []byte("v4")}, func(k, old, new []byte) []byte {
+			return BytesConcat(old, new)
+		})
+		return fmt.Sprintf("%v %q", err, tx.Next([]byte("b"), nil, 0))
+	}},
+	{"next prev", func(tx *Tx) string {
+		return fmt.Sprintf("%q %q %q", tx.Next([]byte("b"), []byte("k1"), 2), tx.Prev([]byte("b"), []byte("k3"), 0), tx.Prev([]byte("b"), []byte("k9"), 1))
+	}},
+	{"foreach", func(tx *Tx) string {
+		var buf bytes.Buffer
+		tx.ForEach([]byte("b"), func(k, v []byte) error {
+			fmt.Fprintf(&buf, "%s=%s,", k, v)
+			return nil
+		})
+		return buf.String()
+	}},
+	{"delete", func(tx *Tx) string {
+		return fmt.Sprintf("%v %q", tx.Delete([]byte("b"), []byte("k1"), []byte("k9")), tx.Next([]byte("b"), nil, 0))
+	}},
+	{"sequence", func(tx *Tx) string {
+		seq, err := tx.NextSequence([]byte("seq"))
+		return fmt.Sprint(seq, err, tx.Sequence([]byte("seq")))
+	}},
+	{"sort", func(tx *Tx) string {
+		tx.SortPut([]byte("s"), Uint64ToBytes(2), []byte("a"), []byte("1"))
+		tx.SortPut([]byte("s"), Uint64ToBytes(1), []byte("b"), []byte("2"), []byte("c"), []byte("3"))
+		tx.SortPut([]byte("s"), Uint64ToBytes(3), []byte("b"), []byte("4"))
+		tx.SortDelete([]byte("s"), []byte("c"))
+		return fmt.Sprintf("%q %q", tx.SortNext([]byte("s"), nil, 0), tx.SortPrev([]byte("s"), nil, 1))
+	}},
+	{"delta", func(tx *Tx) string {
+		tx.db.EnableDelta([]byte("d"), 2)
+		defer tx.db.EnableDelta([]byte("d"), 0)
+		for _, v := range []string{"hello world", "hello there", "hello there!", "bye"} {
+			tx.Put([]byte("d"), []byte("k"), []byte(v))
+		}
+		return fmt.Sprintf("%q", tx.Get([]byte("d"), []byte("k")))
+	}},
+	{"version", func(tx *Tx) string {
+		tx.db.EnableVersioning([]byte("v"), true)
+		defer tx.db.EnableVersioning([]byte("v"), false)
+		tx.Put([]byte("v"), []byte("k"), []byte("1"))
+		tx.Put([]byte("v"), []byte("k"), []byte("2"))
+		tx.Delete([]byte("v"), []byte("k"))
+		return fmt.Sprintf("%q", tx.ScanAsOf([]byte("v"), time.Now().Add(time.Hour), 0))
+	}},
+	{"envelope", func(tx *Tx) string {
+		tx.db.SetEnvelope([]byte("e"), &EnvelopeOptions{Flags: EnvelopeCompressed})
+		defer tx.db.SetEnvelope([]byte("e"), nil)
+		tx.Put([]byte("e"), []byte("k"), bytes.Repeat([]byte("z"), 100))
+		return fmt.Sprintf("%d", len(tx.Get([]byte("e"), []byte("k"))[1]))
+	}},
+	{"buckets", func(tx *Tx) string {
+		tx.DeleteBucket([]byte("seq"))
+		var names [][]byte
+		tx.ForEachBucket(func(name []byte) error {
+			names = append(names, append([]byte{}, name...))
+			return nil
+		})
+		return fmt.Sprintf("%q", names)
+	}},
+	{"writeto", func(tx *Tx) string {
+		n, err := tx.WriteTo(&bytes.Buffer{})
+		return fmt.Sprint(n > 0, err)
+	}},
+}
+
+// backends names of all backends
+func backends() []Backend {
+	var names []Backend
+	for name := range backendOpeners {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func TestBackend_Compatibility(t *testing.T) {
+	results := make(map[Backend][]string)
+	paths := make(map[Backend]string)
+	for _, backend := range backends() {
+		path := filepath.Join(t.TempDir(), string(backend)+".db")
+		d, err := OpenWithOptions(path, &Options{Backend: backend})
+		if err != nil {
+			t.Fatal(backend, err)
+		}
+		tx := d.NewTx(true)
+		for _, step := range compatSteps {
+			results[backend] = append(results[backend], step.run(tx))
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(backend, err)
+		}
+		d.Close()
+		if backend != BackendMemory {
+			paths[backend] = path
+		}
+	}
+	first := backends()[0]
+	for backend, res := range results {
+		for i, step := range compatSteps {
+			if res[i] != results[first][i] {
+				t.Errorf("%s: %s got %s, %s got %s", step.name, backend, res[i], first, results[first][i])
+			}
+		}
+	}
+	// files written by one backend are read by the others
+	for written, path := range paths {
+		for backend := range paths {
+			d, err := OpenWithOptions(path, &Options{Backend: backend, ReadOnly: true})
+			if err != nil {
+				t.Fatal(written, backend, err)
+			}
+			d.View(func(tx *Tx) error {
+				if next := fmt.Sprintf("%q", tx.Next([]byte("b"), nil, 0)); next != `["k2" "v2x" "k3" "v3" "k4" "v4"]` {
+					t.Error(written, "read by", backend, next)
+				}
+				return nil
+			})
+			d.Close()
+		}
+	}
+}
+
+func TestOpenMemory(t *testing.T) {
+	t.Parallel()
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("memory")
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k1"), []byte("v1"), []byte("k2"), []byte("v2"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// read transaction keep its snapshot while a later write commit
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Delete(name, []byte("k1"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if next := fmt.Sprintf("%q", tx.Next(name, nil, 0)); next != `["k1" "v1" "k2" "v2"]` {
+		t.Fatal("snapshot changed", next)
+	}
+	// rolled back writes are discarded
+	wtx := d.NewTx(true)
+	wtx.Put(name, []byte("k3"), []byte("v3"))
+	wtx.Rollback()
+	d.View(func(tx *Tx) error {
+		if next := fmt.Sprintf("%q", tx.Next(name, nil, 0)); next != `["k2" "v2"]` {
+			t.Error("unexpected", next)
+		}
+		return nil
+	})
+	// backup of memory DB is a regular file
+	path := filepath.Join(t.TempDir(), "backup.db")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.View(func(tx *Tx) error {
+		_, err := tx.WriteTo(f)
+		return err
+	})
+	f.Close()
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	b.View(func(tx *Tx) error {
+		if next := fmt.Sprintf("%q", tx.Next(name, nil, 0)); next != `["k2" "v2"]` {
+			t.Error("backup", next)
+		}
+		return nil
+	})
+}
+
+func TestOpenWithOptions_UnknownBackend(t *testing.T) {
+	if _, err := OpenWithOptions(filepath.Join(t.TempDir(), "x.db"), &Options{Backend: "nope"}); err == nil {
+		t.Fatal("expect unknown backend error")
+	}
+}
@@ -0,0 +1,63 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Backup stream a consistent snapshot of the whole db file to w in a read transaction, writers are not blocked.
+// Return count of bytes written
+func (db *DB) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := db.View(func(tx *Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// BackupHandler http handler streaming a snapshot of db file as attachment with its length, for GET and HEAD,
+// like: curl -o backup.db http://host/backup
+func BackupHandler(db *DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		tx := db.NewTx(false)
+		defer tx.Rollback()
+		if err := tx.Error(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		var body io.WriterTo = tx
+		var size int64
+		if s, ok := tx.tx.(backendSizer); ok {
+			size = s.Size()
+		} else {
+			// size of snapshot is only known once written
+			var buf bytes.Buffer
+			if _, err := tx.WriteTo(&buf); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			body, size = &buf, int64(buf.Len())
+		}
+		h := w.Header()
+		h.Set("Content-Type", "application/octet-stream")
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="backup-%s.db"`, time.Now().Format("20060102150405")))
+		h.Set("Content-Length", fmt.Sprint(size))
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := body.WriteTo(w); err != nil {
+			// headers are sent, abort so client sees a truncated response
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDB_Backup(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "backup.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("backup")
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, []byte("k"), []byte("v")) }); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := d.Backup(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatal("unexpected backup", n, err)
+	}
+	path := filepath.Join(t.TempDir(), "copy.db")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tx := c.NewTx(false)
+	defer tx.Rollback()
+	if gets := tx.Get(name, []byte("k")); len(gets) != 2 || string(gets[1]) != "v" {
+		t.Fatal("unexpected restored value", gets)
+	}
+}
+
+func TestBackupHandler(t *testing.T) {
+	for _, open := range []func() (*DB, error){
+		func() (*DB, error) { return Open(filepath.Join(t.TempDir(), "backup.db")) },
+		OpenMemory,
+	} {
+		d, err := open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Update(func(tx *Tx) error { return tx.Put([]byte("backup"), []byte("k"), []byte("v")) }); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		BackupHandler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/backup", nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Fatal("unexpected response", rec.Code, rec.Body.Len())
+		}
+		if got := rec.Header().Get("Content-Length"); got != fmt.Sprint(rec.Body.Len()) {
+			t.Fatal("unexpected content length", got, rec.Body.Len())
+		}
+		if rec.Header().Get("Content-Disposition") == "" {
+			t.Fatal("expect attachment")
+		}
+		rec = httptest.NewRecorder()
+		BackupHandler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/backup", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatal("expect post refused", rec.Code)
+		}
+		d.Close()
+	}
+}
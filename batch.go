@@ -0,0 +1,50 @@
+package zbolt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetBatch set max count of calls coalesced into one Batch transaction and max delay before it is committed,
+// values <= 0 keep bolt defaults. Must be called before Batch is used
+func (db *DB) SetBatch(maxSize int, maxDelay time.Duration) {
+	db.engine().SetBatch(maxSize, maxDelay)
+}
+
+// Batch run fn in a writable transaction shared with concurrent Batch calls, like bolt.DB.Batch.
+// fn may be called more than once if other calls in the batch fail, so it must be idempotent.
+// Return error of fn or error accumulated in tx, a failed call does not fail the others
+func (db *DB) Batch(fn func(tx *Tx) error) error {
+	if db.isClosing() {
+		return ErrShuttingDown
+	}
+	// counted as an open transaction until committed, so Shutdown wait pending batches
+	atomic.AddInt32(&db.openTxs, 1)
+	defer atomic.AddInt32(&db.openTxs, -1)
+	var tx *Tx // tx of the last call of fn, fn may be retried
+	var locked bool
+	defer func() {
+		if locked {
+			db.watch.commit.RUnlock()
+		}
+	}()
+	if err := db.engine().Batch(func(btx backendTx) error {
+		if !locked {
+			// held until published, like Commit
+			db.watch.commit.RLock()
+			locked = true
+		}
+		tx = &Tx{tx: db.wrapTx(btx), db: db, done: true, seq: uint64(btx.ID())}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if tx.err == nil {
+			tx.Error(db.preCommit(tx))
+		}
+		return tx.err
+	}); err != nil {
+		return err
+	}
+	tx.committed()
+	return nil
+}
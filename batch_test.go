@@ -0,0 +1,42 @@
+package zbolt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Batch(t *testing.T) {
+	name := []byte("batch")
+	db.SetBatch(0, 5*time.Millisecond)
+	errBad := errors.New("bad")
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = db.Batch(func(tx *Tx) error {
+				tx.Put(name, []byte(fmt.Sprintf("k%d", i)), []byte("v"))
+				if i == 3 {
+					tx.Error(errBad)
+				}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if (i == 3) != (err == errBad) {
+			t.Fatal("unexpected error", i, err)
+		}
+	}
+	db.View(func(tx *Tx) error {
+		if next := tx.Next(name, nil, 0); len(next) != 18 {
+			t.Fatal("unexpected values", len(next))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+package zbolt
+
+import (
+	"bytes"
+)
+
+// SetMaxResultBytes cap bytes of keys and values a single Get, Next, Prev or Range of tx may return.
+// A call exceeding it return an empty result and fail tx with ErrResultTooLarge, n <= 0 means no cap
+func (tx *Tx) SetMaxResultBytes(n int) *Tx {
+	tx.max = n
+	return tx
+}
+
+// overBudget add size of b to size of result, fail tx with ErrResultTooLarge when it exceeds the cap
+func (tx *Tx) overBudget(size *int, b ...[]byte) bool {
+	if tx.max <= 0 {
+		return false
+	}
+	for _, p := range b {
+		*size += len(p)
+	}
+	if *size > tx.max {
+		tx.Error(ErrResultTooLarge)
+		return true
+	}
+	return false
+}
+
+// ScanRange call fn with each key value with key in [start, end) in bucket in order without building a result,
+// empty start begin with first one and empty end stop after last one. Stop and return error of fn
+func (tx *Tx) ScanRange(name []byte, start, end []byte, fn func(k, v []byte) error) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	r := tx.reader(name)
+	c := b.Cursor()
+	k, v := c.First()
+	if len(start) > 0 {
+		k, v = c.Seek(start)
+	}
+	for ; k != nil && (len(end) == 0 || bytes.Compare(k, end) < 0); k, v = c.Next() {
+		if tx.canceled() {
+			return tx.err
+		}
+		if err := fn(k, r.value(k, v)); err != nil {
+			return err
+		}
+	}
+	return tx.err
+}
@@ -0,0 +1,33 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_SetMaxResultBytes(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("big")
+	tx := d.NewTx(true).SetMaxResultBytes(10)
+	defer tx.Rollback()
+	tx.Put(name, []byte("a"), []byte("1234"), []byte("b"), []byte("1234"), []byte("c"), []byte("1234"))
+	if next := tx.Next(name, nil, 2); len(next) != 4 || tx.Error() != nil {
+		t.Fatal("result within cap must be returned", next, tx.Error())
+	}
+	if next := tx.Next(name, nil, 0); len(next) != 0 || !errors.Is(tx.Error(), ErrResultTooLarge) {
+		t.Fatal("expect ErrResultTooLarge", next, tx.Error())
+	}
+	tx.Error(ErrNil)
+	var n int
+	err = tx.ScanRange(name, []byte("b"), nil, func(k, v []byte) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 2 {
+		t.Fatal("unexpected scan", n, err)
+	}
+}
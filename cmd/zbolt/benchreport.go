@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// benchResult result of a benchmark of BenchmarkCompare
+type benchResult struct {
+	impl   string
+	ns     float64
+	bytes  float64
+	allocs float64
+}
+
+func runBenchReport(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: go test -run NONE -bench Compare -benchmem | zbolt benchreport")
+	}
+	return benchReport(os.Stdin, os.Stdout)
+}
+
+// benchReport read go test -bench output of BenchmarkCompare from r, write overhead of each layer over raw bolt to w
+func benchReport(r io.Reader, w io.Writer) error {
+	var ops []string
+	results := make(map[string][]benchResult)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		op, res, ok := parseBenchLine(s.Text())
+		if !ok {
+			continue
+		}
+		if _, seen := results[op]; !seen {
+			ops = append(ops, op)
+		}
+		results[op] = append(results[op], res)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return errors.New("no BenchmarkCompare results in input")
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\timpl\tns/op\tB/op\tallocs/op\tx bolt time\t+allocs\t")
+	for _, op := range ops {
+		var base *benchResult
+		for i, res := range results[op] {
+			if res.impl == "bolt" {
+				base = &results[op][i]
+			}
+		}
+		for _, res := range results[op] {
+			ratio, extra := "-", "-"
+			if base != nil && base.ns > 0 {
+				ratio = fmt.Sprintf("%.2f", res.ns/base.ns)
+				extra = fmt.Sprintf("%+.0f", res.allocs-base.allocs)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.0f\t%.0f\t%s\t%s\t\n", op, res.impl, res.ns, res.bytes, res.allocs, ratio, extra)
+		}
+	}
+	return tw.Flush()
+}
+
+// parseBenchLine parse line like "BenchmarkCompare/Get/zbolt-8  200  982.7 ns/op  240 B/op  7 allocs/op"
+func parseBenchLine(line string) (op string, res benchResult, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || !strings.HasPrefix(fields[0], "BenchmarkCompare/") {
+		return "", res, false
+	}
+	name := strings.TrimPrefix(fields[0], "BenchmarkCompare/")
+	if i := strings.LastIndexByte(name, '-'); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			name = name[:i]
+		}
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return "", res, false
+	}
+	res.impl = parts[1]
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return "", res, false
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			res.ns = v
+		case "B/op":
+			res.bytes = v
+		case "allocs/op":
+			res.allocs = v
+		}
+	}
+	return parts[0], res, true
+}
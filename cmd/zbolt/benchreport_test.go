@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBenchReport(t *testing.T) {
+	in := `goos: linux
+BenchmarkCompare/Get/bolt-8         	     200	       400.0 ns/op	      72 B/op	       2 allocs/op
+BenchmarkCompare/Get/zbolt-8        	     200	       1000 ns/op	     240 B/op	       7 allocs/op
+BenchmarkCompare/Put/zbolt          	     200	    180065 ns/op	   11897 B/op	      52 allocs/op
+PASS
+`
+	out := &bytes.Buffer{}
+	if err := benchReport(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatal("unexpected report", out.String())
+	}
+	if f := strings.Fields(lines[2]); f[0] != "Get" || f[1] != "zbolt" || f[5] != "2.50" || f[6] != "+5" {
+		t.Fatal("unexpected overhead", lines[2])
+	}
+	if f := strings.Fields(lines[3]); f[5] != "-" {
+		t.Fatal("op without bolt baseline must have no ratio", lines[3])
+	}
+	if err := benchReport(strings.NewReader("PASS\n"), out); err == nil {
+		t.Fatal("expect error without results")
+	}
+}
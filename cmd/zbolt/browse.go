@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dukangxu/zbolt"
+	"golang.org/x/term"
+)
+
+const browseHelp = "j/k move  enter open  h back  n/p page  e edit  w write  q quit"
+
+// browser key codes besides printable characters
+const (
+	keyUp = -1 - iota
+	keyDown
+	keyEnter
+	keyBack
+)
+
+// browser terminal ui state
+type browser struct {
+	db       *zbolt.DB
+	bucket   []byte   // opened bucket, nil when listing buckets
+	items    [][]byte // bucket names or keys of current page
+	values   [][]byte // values of current page
+	pages    [][]byte // last key of previous pages
+	cursor   int
+	pageSize int
+	pending  [][]byte // edited key values not written yet, like [bucket1,key1,value1, ...]
+	confirm  bool
+	status   string
+	edit     func(value []byte) ([]byte, error)
+}
+
+func runBrowse(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: zbolt browse path.db")
+	}
+	db, err := zbolt.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("browse need a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+	_, height, err := term.GetSize(fd)
+	if err != nil {
+		return err
+	}
+	br := &browser{db: db, pageSize: height - 4}
+	br.edit = func(value []byte) ([]byte, error) {
+		term.Restore(fd, state)
+		defer term.MakeRaw(fd)
+		return editValue(value)
+	}
+	if err := br.load(nil); err != nil {
+		return err
+	}
+	buf := make([]byte, 8)
+	for {
+		width, height, _ := term.GetSize(fd)
+		br.pageSize = height - 4
+		br.render(os.Stdout, width)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		if br.handle(parseKey(buf[:n])) {
+			fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J")
+			return nil
+		}
+	}
+}
+
+// parseKey convert terminal input to key code
+func parseKey(b []byte) int {
+	switch {
+	case bytes.Equal(b, []byte("\x1b[A")):
+		return keyUp
+	case bytes.Equal(b, []byte("\x1b[B")):
+		return keyDown
+	case len(b) == 1 && (b[0] == '\r' || b[0] == '\n'):
+		return keyEnter
+	case len(b) == 1 && (b[0] == 127 || b[0] == 8):
+		return keyBack
+	case len(b) == 1:
+		return int(b[0])
+	}
+	return 0
+}
+
+// load load page after key of current bucket, or bucket names if no bucket opened
+func (br *browser) load(after []byte) error {
+	tx := br.db.NewTx(false)
+	defer tx.Rollback()
+	br.items, br.values, br.cursor = nil, nil, 0
+	if br.bucket == nil {
+		return tx.ForEachBucket(func(name []byte) error {
+			br.items = append(br.items, append([]byte{}, name...))
+			return nil
+		})
+	}
+	// copied, items are rendered after tx is closed
+	bs := tx.Next(br.bucket, after, br.pageSize)
+	for i := 0; i+1 < len(bs); i += 2 {
+		br.items = append(br.items, append([]byte{}, bs[i]...))
+		br.values = append(br.values, append([]byte{}, bs[i+1]...))
+	}
+	return tx.Error()
+}
+
+// handle handle a key, return true to quit
+func (br *browser) handle(key int) bool {
+	br.status = ""
+	if br.confirm {
+		br.confirm = false
+		if key == 'y' {
+			if err := br.write(); err != nil {
+				br.status = "write failed: " + err.Error()
+			} else {
+				br.status = "written"
+				br.reload()
+			}
+		} else {
+			br.status = "write cancelled"
+		}
+		return false
+	}
+	switch key {
+	case 'q':
+		return true
+	case 'j', keyDown:
+		if br.cursor < len(br.items)-1 {
+			br.cursor++
+		}
+	case 'k', keyUp:
+		if br.cursor > 0 {
+			br.cursor--
+		}
+	case 'l', keyEnter:
+		if br.bucket == nil && len(br.items) > 0 {
+			br.bucket = br.items[br.cursor]
+			br.pages = nil
+			br.setErr(br.load(nil))
+		}
+	case 'h', keyBack:
+		if br.bucket != nil {
+			br.bucket = nil
+			br.setErr(br.load(nil))
+		}
+	case 'n':
+		if br.bucket != nil && len(br.items) > 0 && len(br.items) >= br.pageSize {
+			var after []byte
+			if len(br.pages) > 0 {
+				after = br.pages[len(br.pages)-1]
+			}
+			last := br.items[len(br.items)-1]
+			br.pages = append(br.pages, last)
+			br.setErr(br.load(last))
+			if len(br.items) == 0 {
+				br.pages = br.pages[:len(br.pages)-1]
+				br.setErr(br.load(after))
+			}
+		}
+	case 'p':
+		if br.bucket != nil && len(br.pages) > 0 {
+			br.pages = br.pages[:len(br.pages)-1]
+			br.reload()
+		}
+	case 'e':
+		if br.bucket != nil && len(br.items) > 0 {
+			value, err := br.edit(br.values[br.cursor])
+			if err != nil {
+				br.status = "edit failed: " + err.Error()
+			} else if !bytes.Equal(value, br.values[br.cursor]) {
+				br.pending = append(br.pending, br.bucket, br.items[br.cursor], value)
+				br.values[br.cursor] = value
+			}
+		}
+	case 'w':
+		if len(br.pending) > 0 {
+			br.confirm = true
+			br.status = fmt.Sprintf("write %d changes? y/n", len(br.pending)/3)
+		}
+	}
+	return false
+}
+
+// reload load the current page again
+func (br *browser) reload() {
+	var after []byte
+	if len(br.pages) > 0 {
+		after = br.pages[len(br.pages)-1]
+	}
+	br.setErr(br.load(after))
+}
+
+func (br *browser) setErr(err error) {
+	if err != nil {
+		br.status = err.Error()
+	}
+}
+
+// write write all pending edits in one transaction
+func (br *browser) write() error {
+	tx := br.db.NewTx(true)
+	defer tx.Rollback()
+	for i := 0; i+2 < len(br.pending); i += 3 {
+		if err := tx.Put(br.pending[i], br.pending[i+1], br.pending[i+2]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	br.pending = nil
+	return nil
+}
+
+// render draw the screen
+func (br *browser) render(w io.Writer, width int) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	title := "buckets"
+	if br.bucket != nil {
+		title = fmt.Sprintf("bucket %s  page %d", renderValue(br.bucket), len(br.pages)+1)
+	}
+	if len(br.pending) > 0 {
+		title += fmt.Sprintf("  [%d unwritten]", len(br.pending)/3)
+	}
+	b.WriteString(clip(title, width) + "\r\n")
+	for i, item := range br.items {
+		mark := "  "
+		if i == br.cursor {
+			mark = "> "
+		}
+		line := mark + renderValue(item)
+		if br.values != nil {
+			line += "\t" + renderValue(br.values[i])
+		}
+		b.WriteString(clip(line, width) + "\r\n")
+	}
+	if br.values != nil && len(br.values) > 0 {
+		b.WriteString("\r\n" + strings.Replace(renderPreview(br.values[br.cursor]), "\n", "\r\n", -1) + "\r\n")
+	}
+	status := br.status
+	if status == "" {
+		status = browseHelp
+	}
+	b.WriteString(clip(status, width) + "\r\n")
+	io.WriteString(w, b.String())
+}
+
+// renderValue render bytes on one line by detected type
+func renderValue(b []byte) string {
+	d := zbolt.NewDumpValue(b)
+	switch d.Type {
+	case zbolt.DumpUTF8, zbolt.DumpJSON:
+		return strings.Replace(d.Value, "\n", " ", -1)
+	case zbolt.DumpUint64:
+		return "u64:" + d.Value
+	}
+	return "0x" + d.Value
+}
+
+// renderPreview render value with codec-aware formatting
+func renderPreview(b []byte) string {
+	d := zbolt.NewDumpValue(b)
+	switch d.Type {
+	case zbolt.DumpJSON:
+		var buf bytes.Buffer
+		json.Indent(&buf, b, "", "  ")
+		return buf.String()
+	case zbolt.DumpBinary:
+		return strings.TrimRight(hex.Dump(b), "\n")
+	}
+	return renderValue(b)
+}
+
+func clip(s string, width int) string {
+	if width > 0 && len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+// editValue edit value in $EDITOR through a temp file
+func editValue(value []byte) ([]byte, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	f, err := ioutil.TempFile("", "zbolt-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dukangxu/zbolt"
+)
+
+func TestBrowser_Handle(t *testing.T) {
+	db, err := zbolt.Open(filepath.Join(t.TempDir(), "browse.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx := db.NewTx(true)
+	tx.Put([]byte("users"), []byte("u1"), []byte("alice"), []byte("u2"), []byte("bob"), []byte("u3"), []byte("carol"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	br := &browser{db: db, pageSize: 2, edit: func(value []byte) ([]byte, error) {
+		return append(value, '!'), nil
+	}}
+	if err := br.load(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []int{'j', keyEnter, 'n'} { // skip meta bucket
+		br.handle(key)
+	}
+	if len(br.items) != 1 || string(br.items[0]) != "u3" {
+		t.Fatal("unexpected second page", br.items)
+	}
+	br.handle('p')
+	br.handle('j')
+	br.handle('e')
+	br.handle('w')
+	if !br.confirm {
+		t.Fatal("write should ask confirmation")
+	}
+	br.handle('y')
+	if br.status != "written" {
+		t.Fatal("unexpected status", br.status)
+	}
+
+	tx = db.NewTx(false)
+	defer tx.Rollback()
+	if gets := tx.Get([]byte("users"), []byte("u2")); string(gets[1]) != "bob!" {
+		t.Fatal("edit not written", gets)
+	}
+	var out bytes.Buffer
+	br.render(&out, 80)
+	if !strings.Contains(out.String(), "u2\tbob!") {
+		t.Fatal("unexpected render", out.String())
+	}
+	if !br.handle('q') {
+		t.Fatal("q should quit")
+	}
+}
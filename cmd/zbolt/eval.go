@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dukangxu/zbolt"
+)
+
+func runEval(args []string) error {
+	if len(args) < 2 {
+		return errors.New(`usage: zbolt eval path.db "query"`)
+	}
+	db, err := zbolt.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	rows, err := db.Eval(strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+	sh := &shell{format: "string"}
+	for _, row := range rows {
+		if row.Value == nil {
+			fmt.Fprintln(os.Stdout, sh.formatBytes(row.Key))
+		} else {
+			fmt.Fprintf(os.Stdout, "%s\t%s\n", sh.formatBytes(row.Key), sh.formatBytes(row.Value))
+		}
+	}
+	return nil
+}
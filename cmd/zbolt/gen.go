@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// genField key or index field of a model
+type genField struct {
+	Name string // Go field name
+	Type string // Go type expression
+	Kind string // encoding kind: string, bytes, uint, int, time
+}
+
+// genModel struct with zbolt tags
+type genModel struct {
+	Name    string
+	Bucket  string
+	Key     genField
+	Indexes []genField
+}
+
+func runGen(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: zbolt gen file.go...")
+	}
+	for _, path := range args {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := generate(path, src)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			continue
+		}
+		if err := ioutil.WriteFile(strings.TrimSuffix(path, ".go")+"_zbolt.go", out, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generate generate typed store code for structs with zbolt tags in src, nil if no such struct
+func generate(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	var models []genModel
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			m, err := parseModel(ts.Name.Name, st)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				models = append(models, *m)
+			}
+		}
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	err = genTemplate.Execute(&buf, struct {
+		Package string
+		Time    bool
+		Models  []genModel
+	}{f.Name.Name, usesTime(models), models})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// parseModel read zbolt tags of struct fields, nil if struct has no zbolt tag
+func parseModel(name string, st *ast.StructType) (*genModel, error) {
+	m := &genModel{Name: name, Bucket: strings.ToLower(name)}
+	tagged := false
+	for _, field := range st.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("zbolt")
+		if tag == "" {
+			continue
+		}
+		tagged = true
+		typ := exprString(field.Type)
+		f := genField{Name: field.Names[0].Name, Type: typ, Kind: fieldKind(typ)}
+		if f.Kind == "" {
+			return nil, fmt.Errorf("%s.%s: unsupported type %s", name, f.Name, typ)
+		}
+		for _, opt := range strings.Split(tag, ",") {
+			switch {
+			case opt == "key":
+				if f.Kind == "time" {
+					return nil, fmt.Errorf("%s.%s: time can not be key", name, f.Name)
+				}
+				m.Key = f
+			case opt == "index":
+				m.Indexes = append(m.Indexes, f)
+			case strings.HasPrefix(opt, "bucket:"):
+				m.Bucket = opt[len("bucket:"):]
+			default:
+				return nil, fmt.Errorf("%s.%s: unknown zbolt tag %q", name, f.Name, opt)
+			}
+		}
+	}
+	if !tagged {
+		return nil, nil
+	}
+	if m.Key.Name == "" {
+		return nil, fmt.Errorf("%s: no field tagged zbolt:\"key\"", name)
+	}
+	return m, nil
+}
+
+func exprString(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + exprString(t.Elt)
+		}
+	}
+	return ""
+}
+
+func fieldKind(typ string) string {
+	switch typ {
+	case "string":
+		return "string"
+	case "[]byte":
+		return "bytes"
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "uint"
+	case "int", "int8", "int16", "int32", "int64":
+		return "int"
+	case "time.Time":
+		return "time"
+	}
+	return ""
+}
+
+// usesTime check generated code need import time
+func usesTime(models []genModel) bool {
+	for _, m := range models {
+		for _, f := range m.Indexes {
+			if f.Kind == "time" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var genTemplate = template.Must(template.New("gen").Funcs(template.FuncMap{
+	"lower": func(s string) string { return strings.ToLower(s[:1]) + s[1:] },
+	"encode": func(f genField, v string) string {
+		switch f.Kind {
+		case "string":
+			return "[]byte(" + v + ")"
+		case "uint":
+			return "zbolt.Uint64ToBytes(uint64(" + v + "))"
+		case "int":
+			return "zbolt.Uint64ToBytes(uint64(" + v + ") ^ 1<<63)"
+		case "time":
+			return "zbolt.TimeToBytes(" + v + ")"
+		}
+		return v
+	},
+}).Parse(`// Code generated by zbolt gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+{{- if .Time}}
+	"time"
+{{- end}}
+
+	"github.com/dukangxu/zbolt"
+)
+
+{{range $m := .Models}}{{$s := printf "%sStore" $m.Name}}{{$b := printf "%sBucket" (lower $m.Name)}}
+var {{$b}} = []byte({{printf "%q" $m.Bucket}})
+{{range $m.Indexes}}
+var {{lower $m.Name}}{{.Name}}Index = zbolt.BucketNameConcat({{$b}}, []byte("idx"), []byte({{printf "%q" .Name}}))
+{{end}}
+// {{lower $m.Name}}IndexPrefix prefix of index entry keys of value, like [len(value), value]
+func {{lower $m.Name}}IndexPrefix(value []byte) []byte {
+	n := make([]byte, 2)
+	binary.BigEndian.PutUint16(n, uint16(len(value)))
+	return zbolt.BytesConcat(n, value)
+}
+
+// {{lower $m.Name}}IndexScan get record keys of index entries with value
+func {{lower $m.Name}}IndexScan(tx *zbolt.Tx, index, value []byte) ([][]byte, error) {
+	prefix := {{lower $m.Name}}IndexPrefix(value)
+	var keys [][]byte
+	start := prefix
+	for {
+		bs := tx.Range(index, start, nil, 100)
+		if err := tx.Error(); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(bs); i += 2 {
+			if !bytes.HasPrefix(bs[i], prefix) {
+				return keys, nil
+			}
+			keys = append(keys, bs[i+1])
+		}
+		if len(bs) < 200 {
+			return keys, nil
+		}
+		start = zbolt.BytesConcat(bs[len(bs)-2], []byte{0})
+	}
+}
+
+// {{$s}} typed store of {{$m.Name}} records in bucket {{printf "%q" $m.Bucket}}
+type {{$s}} struct {
+	tx *zbolt.Tx
+}
+
+// New{{$s}} create {{$m.Name}} store on transaction
+func New{{$s}}(tx *zbolt.Tx) {{$s}} {
+	return {{$s}}{tx: tx}
+}
+
+// get get {{$m.Name}} by encoded key, nil if not found
+func (s {{$s}}) get(key []byte) (*{{$m.Name}}, error) {
+	gets := s.tx.Get({{$b}}, key)
+	if err := s.tx.Error(); err != nil {
+		return nil, err
+	}
+	if len(gets) == 0 {
+		return nil, nil
+	}
+	v := new({{$m.Name}})
+	if err := json.Unmarshal(gets[1], v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Get get {{$m.Name}} by key, nil if not found
+func (s {{$s}}) Get(key {{$m.Key.Type}}) (*{{$m.Name}}, error) {
+	return s.get({{encode $m.Key "key"}})
+}
+
+// Put put {{$m.Name}} and update its indexes
+func (s {{$s}}) Put(v *{{$m.Name}}) error {
+	key := {{encode $m.Key (printf "v.%s" $m.Key.Name)}}
+{{- if $m.Indexes}}
+	old, err := s.get(key)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if err := s.deleteIndexes(old, key); err != nil {
+			return err
+		}
+	}
+{{- end}}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := s.tx.Put({{$b}}, key, data); err != nil {
+		return err
+	}
+{{- range $m.Indexes}}
+	if err := s.tx.Put({{lower $m.Name}}{{.Name}}Index, zbolt.BytesConcat({{lower $m.Name}}IndexPrefix({{encode . (printf "v.%s" .Name)}}), key), key); err != nil {
+		return err
+	}
+{{- end}}
+	return nil
+}
+
+// Delete delete {{$m.Name}} by key and its index entries
+func (s {{$s}}) Delete(key {{$m.Key.Type}}) error {
+	k := {{encode $m.Key "key"}}
+{{- if $m.Indexes}}
+	old, err := s.get(k)
+	if err != nil || old == nil {
+		return err
+	}
+	if err := s.deleteIndexes(old, k); err != nil {
+		return err
+	}
+{{- end}}
+	return s.tx.Delete({{$b}}, k)
+}
+{{if $m.Indexes}}
+// deleteIndexes delete index entries of v
+func (s {{$s}}) deleteIndexes(v *{{$m.Name}}, key []byte) error {
+{{- range $m.Indexes}}
+	if err := s.tx.Delete({{lower $m.Name}}{{.Name}}Index, zbolt.BytesConcat({{lower $m.Name}}IndexPrefix({{encode . (printf "v.%s" .Name)}}), key)); err != nil {
+		return err
+	}
+{{- end}}
+	return nil
+}
+{{end}}
+// Next get limit count {{$m.Name}} after key, empty key start with first one, limit = 0 representative of all
+func (s {{$s}}) Next(after []byte, limit int) ([]*{{$m.Name}}, error) {
+	bs := s.tx.Next({{$b}}, after, limit)
+	if err := s.tx.Error(); err != nil {
+		return nil, err
+	}
+	vs := make([]*{{$m.Name}}, 0, len(bs)/2)
+	for i := 0; i < len(bs); i += 2 {
+		v := new({{$m.Name}})
+		if err := json.Unmarshal(bs[i+1], v); err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+{{range $m.Indexes}}
+// FindBy{{.Name}} get {{$m.Name}} records which {{.Name}} equal value
+func (s {{$s}}) FindBy{{.Name}}(value {{.Type}}) ([]*{{$m.Name}}, error) {
+	keys, err := {{lower $m.Name}}IndexScan(s.tx, {{lower $m.Name}}{{.Name}}Index, {{encode . "value"}})
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]*{{$m.Name}}, 0, len(keys))
+	for _, key := range keys {
+		v, err := s.get(key)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			vs = append(vs, v)
+		}
+	}
+	return vs, nil
+}
+{{end}}{{end}}`))
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	src := []byte(`package models
+
+import "time"
+
+type User struct {
+	ID        string    ` + "`zbolt:\"key\"`" + `
+	Email     string    ` + "`zbolt:\"index\"`" + `
+	CreatedAt time.Time ` + "`zbolt:\"index\"`" + `
+}
+
+type Plain struct {
+	Name string
+}
+`)
+	out, err := generate("models.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(out)
+	for _, want := range []string{
+		"package models",
+		"func NewUserStore(tx *zbolt.Tx) UserStore",
+		"func (s UserStore) FindByEmail(value string) ([]*User, error)",
+		"func (s UserStore) FindByCreatedAt(value time.Time) ([]*User, error)",
+		`var userBucket = []byte("user")`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatal("generated code miss", want)
+		}
+	}
+	if strings.Contains(code, "PlainStore") {
+		t.Fatal("struct without tags should be skipped")
+	}
+
+	_, err = generate("bad.go", []byte("package m\ntype A struct {\n\tX string `zbolt:\"index\"`\n}\n"))
+	if err == nil || !strings.Contains(err.Error(), "no field tagged") {
+		t.Fatal("expect missing key error, got", err)
+	}
+}
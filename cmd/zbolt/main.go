@@ -0,0 +1,51 @@
+// Command zbolt inspect and edit zbolt database files
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command zbolt subcommand
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"benchreport": {"benchreport < bench.txt", runBenchReport},
+	"browse":      {"browse path.db", runBrowse},
+	"eval":        {`eval path.db "query"`, runEval},
+	"gen":         {"gen file.go...", runGen},
+	"pages":       {"pages path.db", runPages},
+	"shell":       {"shell path.db", runShell},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  zbolt", commands[name].usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "zbolt:", err)
+		os.Exit(1)
+	}
+}
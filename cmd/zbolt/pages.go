@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/dukangxu/zbolt"
+)
+
+func runPages(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: zbolt pages path.db")
+	}
+	r, err := zbolt.InspectPages(args[0])
+	if err != nil {
+		return err
+	}
+	_, err = r.WriteTo(os.Stdout)
+	return err
+}
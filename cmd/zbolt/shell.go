@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dukangxu/zbolt"
+	"golang.org/x/term"
+)
+
+const shellHelp = `commands:
+  buckets                          list buckets
+  get <bucket> <key>...            get values
+  put <bucket> <key> <value>...    put key values
+  del <bucket> <key>...            delete keys
+  scan <bucket> [prefix] [limit]   list key values with prefix, default limit 20
+  stats [bucket]                   count keys and bytes
+  begin [ro]                       begin transaction, ro for read only
+  commit                           commit transaction
+  rollback                         rollback transaction
+  format hex|string|uint64|json    set output format
+  exit                             leave shell
+arguments: 0x prefix for hex bytes, u64: prefix for big-endian uint64, quote with "" for spaces`
+
+var shellCommands = []string{"begin", "buckets", "commit", "del", "exit", "format", "get", "help", "put", "rollback", "scan", "stats"}
+
+// shell interactive session on a db
+type shell struct {
+	db     *zbolt.DB
+	tx     *zbolt.Tx
+	format string
+	out    io.Writer
+}
+
+func runShell(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: zbolt shell path.db")
+	}
+	db, err := zbolt.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	sh := &shell{db: db, format: "string", out: os.Stdout}
+	defer func() {
+		if sh.tx != nil {
+			sh.tx.Rollback()
+		}
+	}()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		s := bufio.NewScanner(os.Stdin)
+		for s.Scan() {
+			if sh.exec(s.Text()) {
+				break
+			}
+		}
+		return s.Err()
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "zbolt> ")
+	t.AutoCompleteCallback = sh.complete
+	sh.out = t
+	for {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sh.exec(line) {
+			return nil
+		}
+	}
+}
+
+// complete complete command or bucket name under cursor when tab pressed
+func (sh *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || pos != len(line) {
+		return "", 0, false
+	}
+	fields := strings.Fields(line)
+	if strings.HasSuffix(line, " ") {
+		fields = append(fields, "")
+	}
+	var candidates []string
+	switch len(fields) {
+	case 0:
+		return "", 0, false
+	case 1:
+		candidates = shellCommands
+	case 2:
+		switch fields[0] {
+		case "get", "put", "del", "scan", "stats":
+			candidates = sh.buckets()
+		case "format":
+			candidates = []string{"hex", "json", "string", "uint64"}
+		}
+	}
+	word := fields[len(fields)-1]
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(matches) == 1 {
+		prefix += " "
+	}
+	newLine := line[:len(line)-len(word)] + prefix
+	return newLine, len(newLine), true
+}
+
+// buckets get printable bucket names
+func (sh *shell) buckets() []string {
+	var names []string
+	sh.view(func(tx *zbolt.Tx) error {
+		return tx.ForEachBucket(func(name []byte) error {
+			if utf8.Valid(name) && len(name) > 0 && unicode.IsPrint(rune(name[0])) {
+				names = append(names, string(name))
+			}
+			return nil
+		})
+	})
+	sort.Strings(names)
+	return names
+}
+
+// view run fn in the current transaction or a new read only one
+func (sh *shell) view(fn func(tx *zbolt.Tx) error) error {
+	if sh.tx != nil {
+		return fn(sh.tx)
+	}
+	tx := sh.db.NewTx(false)
+	defer tx.Rollback()
+	if err := tx.Error(); err != nil {
+		return err
+	}
+	return fn(tx)
+}
+
+// update run fn in the current transaction or a new writable one committed at once
+func (sh *shell) update(fn func(tx *zbolt.Tx) error) error {
+	if sh.tx != nil {
+		return fn(sh.tx)
+	}
+	tx := sh.db.NewTx(true)
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec run one command line, return true to leave shell
+func (sh *shell) exec(line string) bool {
+	args, err := splitArgs(line)
+	if err != nil {
+		fmt.Fprintln(sh.out, "error:", err)
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+	cmd, args := args[0], args[1:]
+	if cmd == "exit" || cmd == "quit" {
+		return true
+	}
+	if err := sh.run(cmd, args); err != nil {
+		fmt.Fprintln(sh.out, "error:", err)
+	}
+	return false
+}
+
+func (sh *shell) run(cmd string, args []string) error {
+	switch cmd {
+	case "help":
+		fmt.Fprintln(sh.out, shellHelp)
+	case "buckets":
+		for _, name := range sh.buckets() {
+			fmt.Fprintln(sh.out, name)
+		}
+	case "get":
+		if len(args) < 2 {
+			return errors.New("usage: get <bucket> <key>...")
+		}
+		keys, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return sh.view(func(tx *zbolt.Tx) error {
+			sh.printPairs(tx.Get([]byte(args[0]), keys...))
+			return tx.Error()
+		})
+	case "put":
+		if len(args) < 3 || len(args)%2 != 1 {
+			return errors.New("usage: put <bucket> <key> <value>...")
+		}
+		kvs, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return sh.update(func(tx *zbolt.Tx) error {
+			return tx.Put([]byte(args[0]), kvs...)
+		})
+	case "del":
+		if len(args) < 2 {
+			return errors.New("usage: del <bucket> <key>...")
+		}
+		keys, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return sh.update(func(tx *zbolt.Tx) error {
+			return tx.Delete([]byte(args[0]), keys...)
+		})
+	case "scan":
+		return sh.scan(args)
+	case "stats":
+		return sh.stats(args)
+	case "begin":
+		if sh.tx != nil {
+			return errors.New("transaction already begun")
+		}
+		tx := sh.db.NewTx(len(args) == 0 || args[0] != "ro")
+		if err := tx.Error(); err != nil {
+			return err
+		}
+		sh.tx = tx
+	case "commit", "rollback":
+		if sh.tx == nil {
+			return errors.New("no transaction")
+		}
+		tx := sh.tx
+		sh.tx = nil
+		if cmd == "rollback" {
+			return tx.Rollback()
+		}
+		if err := tx.Commit(); err != nil {
+			tx.Rollback()
+			return err
+		}
+	case "format":
+		if len(args) != 1 {
+			return errors.New("usage: format hex|string|uint64|json")
+		}
+		switch args[0] {
+		case "hex", "string", "uint64", "json":
+			sh.format = args[0]
+		default:
+			return fmt.Errorf("unknown format %q", args[0])
+		}
+	default:
+		return fmt.Errorf("unknown command %q, type help", cmd)
+	}
+	return nil
+}
+
+func (sh *shell) scan(args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New("usage: scan <bucket> [prefix] [limit]")
+	}
+	var prefix []byte
+	limit := 20
+	if len(args) > 1 {
+		b, err := parseArg(args[1])
+		if err != nil {
+			return err
+		}
+		prefix = b
+	}
+	if len(args) > 2 {
+		n, err := strconv.Atoi(args[2])
+		if err != nil {
+			return err
+		}
+		limit = n
+	}
+	return sh.view(func(tx *zbolt.Tx) error {
+		var bs [][]byte
+		it := tx.Cursor([]byte(args[0]))
+		for ok := it.Seek(prefix); ok && bytes.HasPrefix(it.Key(), prefix); ok = it.Next() {
+			bs = append(bs, it.Key(), it.Value())
+			if limit > 0 && len(bs)/2 >= limit {
+				break
+			}
+		}
+		sh.printPairs(bs)
+		return it.Err()
+	})
+}
+
+func (sh *shell) stats(args []string) error {
+	names := args
+	if len(names) == 0 {
+		names = sh.buckets()
+	}
+	return sh.view(func(tx *zbolt.Tx) error {
+		for _, name := range names {
+			var keys, size int
+			err := tx.ForEach([]byte(name), func(k, v []byte) error {
+				keys++
+				size += len(k) + len(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(sh.out, "%s\tkeys=%d\tbytes=%d\n", name, keys, size)
+		}
+		return nil
+	})
+}
+
+// printPairs print key values like [key1,value1,key2,value2, ...]
+func (sh *shell) printPairs(bs [][]byte) {
+	for i := 0; i+1 < len(bs); i += 2 {
+		fmt.Fprintf(sh.out, "%s\t%s\n", sh.formatBytes(bs[i]), sh.formatBytes(bs[i+1]))
+	}
+}
+
+// formatBytes format bytes in the current output format
+func (sh *shell) formatBytes(b []byte) string {
+	switch sh.format {
+	case "hex":
+		return hex.EncodeToString(b)
+	case "uint64":
+		if len(b) == 8 {
+			return strconv.FormatUint(zbolt.BytesToUint64(b), 10)
+		}
+		return hex.EncodeToString(b)
+	case "json":
+		var buf bytes.Buffer
+		if json.Indent(&buf, b, "", "  ") == nil {
+			return buf.String()
+		}
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return strconv.Quote(string(b))
+}
+
+// parseArg parse argument to bytes, 0x prefix for hex and u64: prefix for uint64
+func parseArg(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		return hex.DecodeString(s[2:])
+	case strings.HasPrefix(s, "u64:"):
+		v, err := strconv.ParseUint(s[4:], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return zbolt.Uint64ToBytes(v), nil
+	}
+	return []byte(s), nil
+}
+
+func parseArgs(args []string) ([][]byte, error) {
+	bs := make([][]byte, len(args))
+	for i, arg := range args {
+		b, err := parseArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		bs[i] = b
+	}
+	return bs, nil
+}
+
+// splitArgs split line by spaces, double quoted text is kept as one argument
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inQuote, hasArg := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && inQuote && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+		case c == '"':
+			inQuote = !inQuote
+			hasArg = true
+		case c == ' ' && !inQuote:
+			if hasArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				hasArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			hasArg = true
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unterminated quote")
+	}
+	if hasArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
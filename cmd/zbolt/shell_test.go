@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dukangxu/zbolt"
+)
+
+func newTestShell(t *testing.T) (*shell, *bytes.Buffer) {
+	db, err := zbolt.Open(filepath.Join(t.TempDir(), "shell.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	out := &bytes.Buffer{}
+	return &shell{db: db, format: "string", out: out}, out
+}
+
+func TestShell_Exec(t *testing.T) {
+	sh, out := newTestShell(t)
+	for _, line := range []string{
+		`put users u1 alice u2 "bob smith"`,
+		`put nums u64:7 0x0102`,
+		`begin`,
+		`put users u3 carol`,
+		`rollback`,
+	} {
+		sh.exec(line)
+	}
+	if out.Len() != 0 {
+		t.Fatal("unexpected output", out.String())
+	}
+	sh.exec("scan users u")
+	if out.String() != "u1\talice\nu2\tbob smith\n" {
+		t.Fatal("unexpected scan output", out.String())
+	}
+	out.Reset()
+	sh.exec("format hex")
+	sh.exec("get nums u64:7")
+	if out.String() != "0000000000000007\t0102\n" {
+		t.Fatal("unexpected get output", out.String())
+	}
+	out.Reset()
+	sh.exec("stats users")
+	if !strings.HasPrefix(out.String(), "users\tkeys=2\t") {
+		t.Fatal("unexpected stats output", out.String())
+	}
+	if !sh.exec("exit") {
+		t.Fatal("exit should leave shell")
+	}
+}
+
+func TestShell_Complete(t *testing.T) {
+	sh, _ := newTestShell(t)
+	sh.exec("put users u1 alice")
+	if line, _, ok := sh.complete("sc", 2, '\t'); !ok || line != "scan " {
+		t.Fatal("unexpected command completion", line)
+	}
+	if line, _, ok := sh.complete("get us", 6, '\t'); !ok || line != "get users " {
+		t.Fatal("unexpected bucket completion", line)
+	}
+}
+
+func TestShell_ScanInTx(t *testing.T) {
+	sh, out := newTestShell(t)
+	sh.exec(`put users a0 x u1 alice u2 bob v1 y`)
+	sh.exec("begin")
+	defer sh.exec("rollback")
+	sh.exec("scan users u 1")
+	if out.String() != "u1\talice\n" || sh.tx.Error() != nil {
+		t.Fatal("unexpected scan output", out.String(), sh.tx.Error())
+	}
+	out.Reset()
+	failed := errors.New("failed")
+	sh.tx.Error(failed)
+	sh.exec("scan users u 1")
+	if sh.tx.Error() != failed {
+		t.Fatal("scan must keep error of tx", sh.tx.Error(), out.String())
+	}
+}
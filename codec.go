@@ -0,0 +1,212 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serialization of values used by GetObject, GetObjects and PutObject
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// built-in codecs
+var (
+	CodecJSON    Codec = jsonCodec{}
+	CodecGob     Codec = gobCodec{}
+	CodecMsgpack Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+
+// codec ids of built-in codecs recorded in envelope, 0 is unknown codec
+const (
+	CodecIDJSON byte = iota + 1
+	CodecIDGob
+	CodecIDMsgpack
+)
+
+// codecs registry of codecs by id recorded in envelope
+var codecs = struct {
+	mu   sync.RWMutex
+	byID map[byte]Codec
+}{byID: map[byte]Codec{CodecIDJSON: CodecJSON, CodecIDGob: CodecGob, CodecIDMsgpack: CodecMsgpack}}
+
+// RegisterCodec register codec c with id, values PutObject store in envelope record the id of their codec
+// and GetObject decode them with it whatever codec the bucket use now. Id 0 can't be registered
+func RegisterCodec(id byte, c Codec) {
+	if id == 0 {
+		return
+	}
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+	if c == nil {
+		delete(codecs.byID, id)
+		return
+	}
+	codecs.byID[id] = c
+}
+
+// codecByID registered codec of id, nil if none
+func codecByID(id byte) Codec {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+	return codecs.byID[id]
+}
+
+// codecID id of registered codec c, 0 if not registered
+func codecID(c Codec) byte {
+	if c == nil || !reflect.TypeOf(c).Comparable() {
+		return 0
+	}
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+	for id, v := range codecs.byID {
+		if reflect.TypeOf(v).Comparable() && v == c {
+			return id
+		}
+	}
+	return 0
+}
+
+// valueCodec get codec of values of bucket by key: the codec recorded in their envelope, c if none is recorded
+func (tx *Tx) valueCodec(name []byte, c Codec) func(key []byte) Codec {
+	b := tx.tx.Bucket(name)
+	if b == nil || !tx.hasEnvelope(name) {
+		return func([]byte) Codec { return c }
+	}
+	return func(key []byte) Codec {
+		if v := b.Get(key); len(v) >= envelopeHeaderLen && bytes.HasPrefix(v, _envelopeMagic) {
+			if vc := codecByID(v[3]); vc != nil {
+				return vc
+			}
+		}
+		return c
+	}
+}
+
+// SetCodec set default codec of DB, CodecJSON if not set
+func (db *DB) SetCodec(c Codec) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.codec = c
+}
+
+// SetBucketCodec set codec of bucket, nil use default codec of DB
+func (db *DB) SetBucketCodec(name []byte, c Codec) {
+	db.setConfig(name, func(cfg *bucketConfig) {
+		cfg.codec = c
+	})
+}
+
+// Codec get codec of bucket
+func (db *DB) Codec(name []byte) Codec {
+	if c := db.config(name).codec; c != nil {
+		return c
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.codec != nil {
+		return db.codec
+	}
+	return CodecJSON
+}
+
+// PutObject put v encoded with codec of bucket as value of key
+func (tx *Tx) PutObject(name, key []byte, v interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	return tx.putObject(name, key, v, tx.db.Codec(name))
+}
+
+// putObject put v encoded with codec c as value of key
+func (tx *Tx) putObject(name, key []byte, v interface{}, c Codec) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	b, err := c.Marshal(v)
+	if err != nil {
+		return tx.Error(err)
+	}
+	tx.codec = codecID(c)
+	defer func() { tx.codec = 0 }()
+	return tx.Put(name, key, b)
+}
+
+// GetObject decode value of key into out with codec of bucket, return ErrRecordNotFound if key not exist
+func (tx *Tx) GetObject(name, key []byte, out interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	return tx.getObject(name, key, out, tx.db.Codec(name))
+}
+
+// getObject decode value of key into out with codec c
+func (tx *Tx) getObject(name, key []byte, out interface{}, c Codec) error {
+	gets := tx.Get(name, key)
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(gets) != 2 {
+		return ErrRecordNotFound
+	}
+	return tx.Error(tx.valueCodec(name, c)(key).Unmarshal(gets[1], out))
+}
+
+// GetObjects decode values of keys into slice pointed by out, like *[]User, with codec of bucket, keys not exist are skipped
+func (tx *Tx) GetObjects(name []byte, keys [][]byte, out interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	return tx.getObjects(name, keys, out, tx.db.Codec(name))
+}
+
+// getObjects decode values of keys into slice pointed by out with codec c
+func (tx *Tx) getObjects(name []byte, keys [][]byte, out interface{}, c Codec) error {
+	s := reflect.ValueOf(out)
+	if s.Kind() != reflect.Ptr || s.Elem().Kind() != reflect.Slice {
+		return tx.Error(errors.New("zbolt: GetObjects out must be a pointer to slice"))
+	}
+	gets := tx.Get(name, keys...)
+	if tx.err != nil {
+		return tx.err
+	}
+	s = s.Elem()
+	codec := tx.valueCodec(name, c)
+	for i := 1; i < len(gets); i += 2 {
+		e := reflect.New(s.Type().Elem())
+		if err := codec(gets[i-1]).Unmarshal(gets[i], e.Interface()); err != nil {
+			return tx.Error(err)
+		}
+		s.Set(reflect.Append(s, e.Elem()))
+	}
+	return nil
+}
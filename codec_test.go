@@ -0,0 +1,48 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_PutObject(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.SetCodec(CodecMsgpack)
+	d.SetBucketCodec([]byte("gob_users"), CodecGob)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for _, name := range []string{"gob_users", "msgpack_users"} {
+		if err := tx.PutObject([]byte(name), []byte("u1"), user{"alice", 30}); err != nil {
+			t.Fatal(name, err)
+		}
+		var u user
+		if err := tx.GetObject([]byte(name), []byte("u1"), &u); err != nil || u.Name != "alice" || u.Age != 30 {
+			t.Fatal(name, u, err)
+		}
+		if err := tx.GetObject([]byte(name), []byte("u9"), &u); err != ErrRecordNotFound {
+			t.Fatal(name, "expect ErrRecordNotFound, got", err)
+		}
+		var us []user
+		if err := tx.GetObjects([]byte(name), [][]byte{[]byte("u9"), []byte("u1")}, &us); err != nil || len(us) != 1 || us[0].Age != 30 {
+			t.Fatal(name, "unexpected multi get", us, err)
+		}
+	}
+	// values are stored with the codec of their bucket
+	var u user
+	if err := CodecGob.Unmarshal(tx.Get([]byte("gob_users"), []byte("u1"))[1], &u); err != nil || u.Name != "alice" {
+		t.Fatal("not gob", err)
+	}
+	if err := CodecMsgpack.Unmarshal(tx.Get([]byte("msgpack_users"), []byte("u1"))[1], &u); err != nil {
+		t.Fatal("not msgpack", err)
+	}
+	if d.Codec([]byte("other")) != CodecMsgpack {
+		t.Fatal("default codec not used")
+	}
+}
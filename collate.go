@@ -0,0 +1,110 @@
+package zbolt
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Collation transform of string keys deciding their order in a bucket
+type Collation uint8
+
+// collation flags, combined with |
+const (
+	CollateNFKD            Collation = 1 << iota // unicode compatibility decomposition, accented letters sort next to the base letter
+	CollateCaseInsensitive                       // fold case
+	CollateNumeric                               // digit runs compare by numeric value, like file2 < file10
+)
+
+// SetCollation order keys of bucket by collation c, 0 restores byte order.
+// Keys are stored as collated key followed by the original key, so keys equal after collation stay distinct
+// and every API still take and return original keys. Keys of entries of SortPut and of secondary indexes are ordered
+// by collation too, sort keys and index values keep byte order. Collation must be set before the bucket is written
+func (db *DB) SetCollation(name []byte, c Collation) {
+	db.setConfig(name, func(cfg *bucketConfig) {
+		cfg.collation = c
+	})
+	if c != 0 {
+		atomic.StoreInt32(&db.wrapped, 1)
+	}
+}
+
+// Key collated form of key
+func (c Collation) Key(key []byte) []byte {
+	if c&CollateNFKD != 0 {
+		key = norm.NFKD.Bytes(key)
+	}
+	if c&CollateCaseInsensitive != 0 {
+		key = bytes.ToLower(key)
+	}
+	if c&CollateNumeric != 0 {
+		key = numericKey(key)
+	}
+	return key
+}
+
+// numericKey replace digit runs by count of significant digits followed by the digits
+func numericKey(key []byte) []byte {
+	b := make([]byte, 0, len(key)+4)
+	for i := 0; i < len(key); {
+		if key[i] < '0' || key[i] > '9' {
+			b = append(b, key[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(key) && key[j] == '0' {
+			j++
+		}
+		k := j
+		for k < len(key) && key[k] >= '0' && key[k] <= '9' {
+			k++
+		}
+		n := k - j
+		if n > 255 {
+			n = 255
+		}
+		b = append(b, byte(n))
+		b = append(b, key[j:k]...)
+		i = k
+	}
+	return b
+}
+
+// encode stored key of original key
+func (c Collation) encode(key []byte) []byte {
+	return BytesConcat(escapeKey(c.Key(key)), key)
+}
+
+// decode original key of stored key
+func (c Collation) decode(k []byte) []byte {
+	if _, key, ok := unescapeKey(k); ok {
+		return key
+	}
+	return k
+}
+
+// member stored form of key following an escaped prefix in sort and index entries, keep the order of keys in bucket
+func (c Collation) member(key []byte) []byte {
+	if c == 0 {
+		return key
+	}
+	return c.encode(key)
+}
+
+// memberKey original key of stored member
+func (c Collation) memberKey(k []byte) []byte {
+	if c == 0 {
+		return k
+	}
+	return c.decode(k)
+}
+
+// collation get collation of bucket
+func (tx *Tx) collation(name []byte) Collation {
+	if tx.db == nil {
+		return 0
+	}
+	return tx.db.config(name).collation
+}
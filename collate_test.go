@@ -0,0 +1,81 @@
+package zbolt
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDB_SetCollation(t *testing.T) {
+	name := []byte("collate_files")
+	db.SetCollation(name, CollateNFKD|CollateCaseInsensitive|CollateNumeric)
+	defer db.SetCollation(name, 0)
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.DeleteBucket(name)
+	tx.Error(ErrNil)
+	for _, k := range []string{"file10", "File2", "éclair", "eclair", "apple", "Zebra", "file02"} {
+		tx.Put(name, []byte(k), []byte("v"+k))
+	}
+	var keys []string
+	tx.ForEach(name, func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	if fmt.Sprint(keys) != "[apple eclair éclair File2 file02 file10 Zebra]" {
+		t.Fatal("unexpected order", keys)
+	}
+	if gets := tx.Get(name, []byte("File2"), []byte("file2")); len(gets) != 2 || string(gets[1]) != "vFile2" {
+		t.Fatalf("unexpected get %q", gets)
+	}
+	if next := tx.Next(name, []byte("File2"), 2); len(next) != 4 || string(next[0]) != "file02" || string(next[2]) != "file10" {
+		t.Fatalf("unexpected next %q", next)
+	}
+	if prev := tx.Prev(name, []byte("f"), 1); len(prev) != 2 || string(prev[0]) != "éclair" {
+		t.Fatalf("unexpected prev %q", prev)
+	}
+	tx.Delete(name, []byte("file10"))
+	if next := tx.Next(name, []byte("file02"), 0); len(next) != 2 || string(next[0]) != "Zebra" {
+		t.Fatalf("unexpected next after delete %q", next)
+	}
+}
+
+func TestDB_CollationAcrossPaths(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("collate_paths")
+	d.SetCollation(name, CollateCaseInsensitive|CollateNumeric)
+	if err := d.AddIndex(name, "all", func(k, v []byte) [][]byte { return [][]byte{[]byte("x")} }); err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"file10", "File2", "apple", "Zebra", "file02"}
+	for _, desc := range []bool{false, true} {
+		d.EnableSortDescending(name, desc)
+		tx := d.NewTx(true)
+		for _, k := range keys {
+			tx.Put(name, []byte(k), []byte("v"))
+			tx.SortPut(name, []byte("same"), []byte(k), []byte("v"))
+		}
+		var plain, sorted, indexed []string
+		tx.ForEach(name, func(k, v []byte) error {
+			plain = append(plain, string(k))
+			return nil
+		})
+		for _, e := range tx.SortNextEntries(name, nil, 0) {
+			sorted = append(sorted, string(e.Key))
+		}
+		for _, k := range tx.indexKeys(name, "all", []byte("x")) {
+			indexed = append(indexed, string(k))
+		}
+		if fmt.Sprint(plain) != "[apple File2 file02 file10 Zebra]" || fmt.Sprint(sorted) != fmt.Sprint(plain) ||
+			fmt.Sprint(indexed) != fmt.Sprint(plain) {
+			t.Fatal("orders differ across paths", desc, plain, sorted, indexed)
+		}
+		if gets := tx.SortGet(name, []byte("File2")); len(gets) != 3 || string(gets[1]) != "same" {
+			t.Fatalf("unexpected sort get %q", gets)
+		}
+		tx.Rollback()
+	}
+}
@@ -0,0 +1,48 @@
+package zbolt
+
+import (
+	"fmt"
+	"time"
+)
+
+// OnPreCommit register hook called before every writable transaction commit, including each call of Batch.
+// Error returned by hook is accumulated in tx and the commit is vetoed
+func (db *DB) OnPreCommit(hook func(tx *Tx) error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.preCommits = append(db.preCommits, hook)
+}
+
+// preCommit run pre-commit hooks, return the first error
+func (db *DB) preCommit(tx *Tx) error {
+	if db == nil {
+		return nil
+	}
+	db.mu.RLock()
+	hooks := db.preCommits
+	db.mu.RUnlock()
+	for _, hook := range hooks {
+		if err := hook(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommitWithin commit like Commit if tx has been open less than d when commit start, including pre-commit hooks,
+// otherwise accumulate ErrTxDeadline and not commit
+func (tx *Tx) CommitWithin(d time.Duration) error {
+	if tx.err == nil && tx.tx.Writable() {
+		tx.Error(tx.db.preCommit(tx))
+	}
+	if tx.err == nil {
+		if elapsed := time.Since(tx.start); elapsed > d {
+			tx.Error(fmt.Errorf("%w: open %s, budget %s", ErrTxDeadline, elapsed, d))
+		}
+	}
+	if tx.err != nil {
+		return tx.err
+	}
+	tx.finish()
+	return tx.commit()
+}
@@ -0,0 +1,61 @@
+package zbolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDB_OnPreCommit(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "commit.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("accounts")
+	errNegative := errors.New("negative balance")
+	d.OnPreCommit(func(tx *Tx) error {
+		for _, v := range tx.Get(name, []byte("alice")) {
+			if v[0] == '-' {
+				return errNegative
+			}
+		}
+		return nil
+	})
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("alice"), []byte("10"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("alice"), []byte("-5"))
+	}); err != errNegative {
+		t.Fatal("expect commit vetoed, got", err)
+	}
+	if err := d.Batch(func(tx *Tx) error {
+		return tx.Put(name, []byte("alice"), []byte("-5"))
+	}); err != errNegative {
+		t.Fatal("expect batch vetoed, got", err)
+	}
+
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(name, []byte("alice"), []byte("20"))
+	time.Sleep(5 * time.Millisecond)
+	if err := tx.CommitWithin(time.Millisecond); !errors.Is(err, ErrTxDeadline) {
+		t.Fatal("expect ErrTxDeadline, got", err)
+	}
+	tx.Rollback()
+	tx = d.NewTx(true)
+	tx.Put(name, []byte("alice"), []byte("30"))
+	if err := tx.CommitWithin(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	d.View(func(tx *Tx) error {
+		if gets := tx.Get(name, []byte("alice")); string(gets[1]) != "30" {
+			t.Fatal("unexpected value", gets)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package zbolt
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+)
+
+// commitPollInterval interval of polling commit sequence in WaitForCommit
+const commitPollInterval = 10 * time.Millisecond
+
+// Seq get commit sequence of tx. For writable tx it is the sequence its changes are visible at once committed,
+// for read only tx the sequence of the last commit it sees. A writer hand it to readers as token for WaitForCommit
+func (tx *Tx) Seq() uint64 {
+	return tx.seq
+}
+
+// CommitSeq get sequence of the last commit of file, read from meta pages of bolt files so that
+// commits of other processes are seen
+func (db *DB) CommitSeq() (uint64, error) {
+	if f, ok := db.engine().(backendFile); ok {
+		return fileCommitSeq(f.Path())
+	}
+	tx := db.NewTx(false)
+	defer tx.Rollback()
+	return tx.seq, tx.err
+}
+
+// WaitForCommit wait until commit of sequence seq is visible in file, polling its meta pages.
+// A read only handle then see the changes in transactions began after return, unless ctx is done before
+func (db *DB) WaitForCommit(ctx context.Context, seq uint64) error {
+	t := time.NewTicker(commitPollInterval)
+	defer t.Stop()
+	for {
+		cur, err := db.CommitSeq()
+		if err != nil || cur >= seq {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// fileCommitSeq read txid of the latest valid meta page of bolt file at path
+func fileCommitSeq(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	m, err := latestMeta(f)
+	return m.txid, err
+}
+
+// latestMeta read the valid meta page with the highest txid of bolt file f
+func latestMeta(f io.ReaderAt) (rawMeta, error) {
+	// page size of the first meta page locate the second one
+	b := make([]byte, pageHeaderSize+metaChecksumOffset+8)
+	if _, err := f.ReadAt(b, 0); err != nil {
+		return rawMeta{}, err
+	}
+	m0 := parseMeta(b[pageHeaderSize:])
+	pageSize := int64(binary.LittleEndian.Uint32(b[pageHeaderSize+8:]))
+	if m0.err == nil {
+		pageSize = int64(m0.pageSize)
+	}
+	if _, err := f.ReadAt(b, pageSize); err == nil {
+		if m1 := parseMeta(b[pageHeaderSize:]); m1.err == nil && (m0.err != nil || m1.txid > m0.txid) {
+			return m1, nil
+		}
+	}
+	if m0.err != nil {
+		return rawMeta{}, m0.err
+	}
+	return m0, nil
+}
@@ -0,0 +1,65 @@
+package zbolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDB_WaitForCommit(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "seq.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx := d.NewTx(true)
+	tx.Put([]byte("users"), []byte("u1"), []byte("alice"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	seq := tx.Seq()
+	if cur, err := d.CommitSeq(); err != nil || cur != seq {
+		t.Fatal("unexpected commit seq", cur, seq, err)
+	}
+	if err := d.WaitForCommit(context.Background(), seq); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.WaitForCommit(ctx, seq+1); err != context.DeadlineExceeded {
+		t.Fatal("expect deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		tx := d.NewTx(true)
+		tx.Put([]byte("users"), []byte("u2"), []byte("bob"))
+		tx.Commit()
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := d.WaitForCommit(ctx, seq+1); err != nil {
+		t.Fatal(err)
+	}
+	rtx := d.NewTx(false)
+	defer rtx.Rollback()
+	if rtx.Seq() != seq+1 || len(rtx.Get([]byte("users"), []byte("u2"))) != 2 {
+		t.Fatal("write not visible", rtx.Seq())
+	}
+}
+
+func TestDB_CommitSeqMemory(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	before, _ := d.CommitSeq()
+	tx := d.NewTx(true)
+	tx.Put([]byte("users"), []byte("u1"), []byte("alice"))
+	tx.Commit()
+	if after, _ := d.CommitSeq(); after != tx.Seq() || after <= before {
+		t.Fatal("unexpected commit seq", before, after, tx.Seq())
+	}
+}
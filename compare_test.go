@@ -0,0 +1,172 @@
+package zbolt
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+// BenchmarkCompare compare zbolt layers against raw bolt, report overhead with
+// go test -run NONE -bench Compare -benchmem | zbolt benchreport
+func BenchmarkCompare(b *testing.B) {
+	const n = 1000
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key%06d", i))
+	}
+	value := make([]byte, 100)
+	name := []byte("bench")
+
+	open := func(b *testing.B, setup func(d *DB)) *DB {
+		d, err := Open(filepath.Join(b.TempDir(), "bench.db"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { d.Close() })
+		if setup != nil {
+			setup(d)
+		}
+		tx := d.NewTx(true)
+		for _, k := range keys {
+			tx.Put(name, k, value)
+		}
+		if err := tx.Commit(); err != nil {
+			b.Fatal(err)
+		}
+		return d
+	}
+	openBolt := func(b *testing.B) *bolt.DB {
+		bdb, err := bolt.Open(filepath.Join(b.TempDir(), "bolt.db"), 0600, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { bdb.Close() })
+		err = bdb.Update(func(tx *bolt.Tx) error {
+			bk, err := tx.CreateBucketIfNotExists(name)
+			for _, k := range keys {
+				if err == nil {
+					err = bk.Put(k, value)
+				}
+			}
+			return err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		return bdb
+	}
+	layers := []struct {
+		name  string
+		setup func(d *DB)
+	}{
+		{"zbolt", nil},
+		{"envelope", func(d *DB) { d.SetEnvelope(name, &EnvelopeOptions{}) }},
+		{"hooks", func(d *DB) { d.OnPreCommit(func(tx *Tx) error { return nil }) }},
+	}
+
+	b.Run("Put/bolt", func(b *testing.B) {
+		bdb := openBolt(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bdb.Update(func(tx *bolt.Tx) error {
+				return tx.Bucket(name).Put(keys[i%n], value)
+			})
+		}
+	})
+	for _, l := range layers {
+		b.Run("Put/"+l.name, func(b *testing.B) {
+			d := open(b, l.setup)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d.Update(func(tx *Tx) error {
+					return tx.Put(name, keys[i%n], value)
+				})
+			}
+		})
+	}
+
+	b.Run("Get/bolt", func(b *testing.B) {
+		bdb := openBolt(b)
+		tx, _ := bdb.Begin(false)
+		defer tx.Rollback()
+		bk := tx.Bucket(name)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bk.Get(keys[i%n])
+		}
+	})
+	for _, l := range layers {
+		b.Run("Get/"+l.name, func(b *testing.B) {
+			tx := open(b, l.setup).NewTx(false)
+			defer tx.Rollback()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tx.Get(name, keys[i%n])
+			}
+		})
+	}
+
+	b.Run("Scan/bolt", func(b *testing.B) {
+		bdb := openBolt(b)
+		tx, _ := bdb.Begin(false)
+		defer tx.Rollback()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c := tx.Bucket(name).Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			}
+		}
+	})
+	for _, l := range layers {
+		b.Run("Scan/"+l.name, func(b *testing.B) {
+			tx := open(b, l.setup).NewTx(false)
+			defer tx.Rollback()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tx.Next(name, nil, 0)
+			}
+		})
+	}
+
+	// raw equivalent of SortPut, an entry in the key bucket and the member in the value bucket
+	b.Run("SortPut/bolt", func(b *testing.B) {
+		bdb := openBolt(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bdb.Update(func(tx *bolt.Tx) error {
+				kb, err := tx.CreateBucketIfNotExists([]byte("sort_keys"))
+				if err != nil {
+					return err
+				}
+				vb, err := tx.CreateBucketIfNotExists([]byte("sort_values"))
+				if err != nil {
+					return err
+				}
+				sortKey := BytesConcat(Uint64ToBytes(uint64(i)), keys[i%n])
+				if err := kb.Put(sortKey, value); err != nil {
+					return err
+				}
+				return vb.Put(keys[i%n], sortKey)
+			})
+		}
+	})
+	b.Run("SortPut/zbolt", func(b *testing.B) {
+		d := open(b, nil)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			d.Update(func(tx *Tx) error {
+				return tx.SortPut(name, Uint64ToBytes(uint64(i)), keys[i%n], value)
+			})
+		}
+	})
+}
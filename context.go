@@ -0,0 +1,83 @@
+package zbolt
+
+import "context"
+
+// NewTxContext create transaction bound to ctx, when ctx is done operations of tx stop,
+// ctx error is accumulated in tx and tx is rolled back. Tags attached by ContextWithTag are copied to tx
+func (db *DB) NewTxContext(ctx context.Context, writable bool) *Tx {
+	if err := ctx.Err(); err != nil {
+		return &Tx{db: db, err: err}
+	}
+	tx := db.NewTx(writable)
+	tx.ctx = ctx
+	tx.contextTags(ctx)
+	return tx
+}
+
+// canceled check context of tx, accumulate its error and roll back tx when it is done
+func (tx *Tx) canceled() bool {
+	if tx.ctx == nil {
+		return false
+	}
+	err := tx.ctx.Err()
+	if err == nil {
+		return false
+	}
+	tx.Error(err)
+	if !tx.done && tx.tx != nil {
+		tx.finish()
+		tx.tx.Rollback()
+	}
+	return true
+}
+
+// withContext bind ctx to tx until the returned func is called
+func (tx *Tx) withContext(ctx context.Context) func() {
+	old := tx.ctx
+	tx.ctx = ctx
+	return func() {
+		tx.ctx = old
+	}
+}
+
+// GetContext Get stopping when ctx is done
+func (tx *Tx) GetContext(ctx context.Context, name []byte, keys ...[]byte) [][]byte {
+	defer tx.withContext(ctx)()
+	return tx.Get(name, keys...)
+}
+
+// PutContext Put stopping when ctx is done
+func (tx *Tx) PutContext(ctx context.Context, name []byte, kvs ...[]byte) error {
+	defer tx.withContext(ctx)()
+	return tx.Put(name, kvs...)
+}
+
+// ForEachContext ForEach stopping when ctx is done
+func (tx *Tx) ForEachContext(ctx context.Context, name []byte, fn func(k, v []byte) error) error {
+	defer tx.withContext(ctx)()
+	return tx.ForEach(name, fn)
+}
+
+// NextContext Next stopping when ctx is done
+func (tx *Tx) NextContext(ctx context.Context, name []byte, key []byte, limit int) [][]byte {
+	defer tx.withContext(ctx)()
+	return tx.Next(name, key, limit)
+}
+
+// PrevContext Prev stopping when ctx is done
+func (tx *Tx) PrevContext(ctx context.Context, name []byte, key []byte, limit int) [][]byte {
+	defer tx.withContext(ctx)()
+	return tx.Prev(name, key, limit)
+}
+
+// SortNextContext SortNext stopping when ctx is done
+func (tx *Tx) SortNextContext(ctx context.Context, name []byte, key []byte, limit int) [][]byte {
+	defer tx.withContext(ctx)()
+	return tx.SortNext(name, key, limit)
+}
+
+// SortPrevContext SortPrev stopping when ctx is done
+func (tx *Tx) SortPrevContext(ctx context.Context, name []byte, key []byte, limit int) [][]byte {
+	defer tx.withContext(ctx)()
+	return tx.SortPrev(name, key, limit)
+}
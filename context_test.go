@@ -0,0 +1,49 @@
+package zbolt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestDB_NewTxContext(t *testing.T) {
+	name := []byte("context")
+	db.Update(func(tx *Tx) error {
+		for i := 0; i < 100; i++ {
+			tx.Put(name, []byte(fmt.Sprintf("k%03d", i)), []byte("v"))
+		}
+		return nil
+	})
+	open := db.openTxCount()
+	ctx, cancel := context.WithCancel(context.Background())
+	tx := db.NewTxContext(ctx, false)
+	defer tx.Rollback()
+	if next := tx.Next(name, nil, 0); len(next) != 200 {
+		t.Fatal("unexpected values", len(next))
+	}
+	n := 0
+	err := tx.ForEach(name, func(k, v []byte) error {
+		if n++; n == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled || n != 10 {
+		t.Fatal("expect scan canceled, got", err, n)
+	}
+	if db.openTxCount() != open {
+		t.Fatal("canceled tx not rolled back")
+	}
+	if next := tx.Next(name, nil, 0); len(next) != 0 {
+		t.Fatal("canceled tx should return nothing", len(next))
+	}
+
+	tx2 := db.NewTx(false)
+	defer tx2.Rollback()
+	if next := tx2.NextContext(ctx, name, nil, 0); len(next) != 0 || tx2.Error() != context.Canceled {
+		t.Fatal("expect NextContext canceled", tx2.Error())
+	}
+	if db.NewTxContext(ctx, true).Error() != context.Canceled {
+		t.Fatal("expect canceled tx")
+	}
+}
@@ -0,0 +1,99 @@
+package zbolt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CopyBucket copy keys of bucket src with its sort, delta, version, reverse and expiry buckets to new bucket dst.
+// Secondary indexes are not copied. Return ErrRecordNotFound if src does not exist and ErrBucketExists if dst does
+func (tx *Tx) CopyBucket(src, dst []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if tx.Error(checkBucketName(dst)) != nil {
+		return tx.err
+	}
+	ok, err := copyBuckets(tx.tx, tx.tx, src, dst)
+	if errors.Is(err, ErrBucketExists) {
+		// nothing copied, tx is still usable
+		return err
+	}
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	if !ok {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// CopyBucketTo copy bucket name with its sort, delta, version, reverse and expiry buckets to new bucket of other
+// in one transaction of each DB. Keys and values are copied as stored, so key codecs of name must be configured
+// alike on both DBs. Return ErrRecordNotFound if name does not exist and ErrBucketExists
+// if other has it
+func (db *DB) CopyBucketTo(other *DB, name []byte) error {
+	return db.View(func(src *Tx) error {
+		return other.Update(func(dst *Tx) error {
+			ok, err := copyBuckets(src.tx, dst.tx, name, name)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrRecordNotFound
+			}
+			return nil
+		})
+	})
+}
+
+// copyBuckets copy bucket from of src with its shadow buckets to to of dst, return false if none exist.
+// Check every target first so that nothing is copied if one exists
+func copyBuckets(src, dst backendTx, from, to []byte) (bool, error) {
+	var found bool
+	for _, prefix := range _bucketPrefixes {
+		if dst.Bucket(shadowName(prefix, to)) != nil {
+			return false, fmt.Errorf("%w: %q", ErrBucketExists, to)
+		}
+		found = found || src.Bucket(shadowName(prefix, from)) != nil
+	}
+	if !found {
+		return false, nil
+	}
+	for _, prefix := range _bucketPrefixes {
+		if _, err := copyBucket(src, dst, shadowName(prefix, from), shadowName(prefix, to)); err != nil {
+			return false, err
+		}
+	}
+	// mark of values in envelope
+	if meta := src.Bucket(_metaBucket); meta != nil && meta.Get(BytesConcat(_envelopeMetaKey, from)) != nil {
+		b, err := dst.CreateBucketIfNotExists(_metaBucket)
+		if err != nil {
+			return false, err
+		}
+		return true, b.Put(BytesConcat(_envelopeMetaKey, to), []byte{1})
+	}
+	return true, nil
+}
+
+// copyBucket copy keys and sequence of bucket from of src to new bucket to of dst, return false if from does not exist
+// and ErrBucketExists if to exists
+func copyBucket(src, dst backendTx, from, to []byte) (bool, error) {
+	b := src.Bucket(from)
+	if b == nil {
+		return false, nil
+	}
+	if dst.Bucket(to) != nil {
+		return false, fmt.Errorf("%w: %q", ErrBucketExists, to)
+	}
+	nb, err := dst.CreateBucketIfNotExists(to)
+	if err != nil {
+		return false, err
+	}
+	if err := b.ForEach(func(k, v []byte) error {
+		return nb.Put(append([]byte{}, k...), append([]byte{}, v...))
+	}); err != nil {
+		return false, err
+	}
+	return true, nb.SetSequence(b.Sequence())
+}
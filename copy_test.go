@@ -0,0 +1,53 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_CopyBucket(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	src, dst := []byte("src"), []byte("dst")
+	err = d.Update(func(tx *Tx) error {
+		tx.Put(src, []byte("k"), []byte("v"))
+		tx.SortPut(src, []byte("1"), []byte("sk"), []byte("sv"))
+		if err := tx.CopyBucket(src, dst); err != nil {
+			return err
+		}
+		if err := tx.CopyBucket(src, dst); !errors.Is(err, ErrBucketExists) {
+			t.Fatal("expect ErrBucketExists", err)
+		}
+		if err := tx.CopyBucket([]byte("missing"), []byte("other")); !errors.Is(err, ErrRecordNotFound) {
+			t.Fatal("expect ErrRecordNotFound", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := d.CopyBucketTo(other, src); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		db   *DB
+		name []byte
+	}{{d, src}, {d, dst}, {other, src}} {
+		tx := c.db.NewTx(false)
+		if gets := tx.Get(c.name, []byte("k")); len(gets) != 2 || string(gets[1]) != "v" {
+			t.Fatal("unexpected value", string(c.name), gets)
+		}
+		if kvs := tx.SortNext(c.name, nil, 0); len(kvs) != 2 || string(kvs[1]) != "sv" {
+			t.Fatal("unexpected sort entries", string(c.name), kvs)
+		}
+		tx.Rollback()
+	}
+}
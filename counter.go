@@ -0,0 +1,40 @@
+package zbolt
+
+import (
+	"fmt"
+)
+
+// IncrBy add delta to counter of key in bucket stored as 8 bytes big endian int64, missing counter start at 0.
+// Return the new value, ErrNotCounter if stored value is not 8 bytes
+func (tx *Tx) IncrBy(name, key []byte, delta int64) (int64, error) {
+	n, err := tx.counter(name, key)
+	if err != nil {
+		return 0, tx.Error(err)
+	}
+	n += delta
+	if err := tx.Put(name, key, Uint64ToBytes(uint64(n))); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Counter get counter of key in bucket written by IncrBy, 0 if missing or not a counter
+func (tx *Tx) Counter(name, key []byte) int64 {
+	n, _ := tx.counter(name, key)
+	return n
+}
+
+// counter decode counter of key in bucket
+func (tx *Tx) counter(name, key []byte) (int64, error) {
+	if tx.err != nil {
+		return 0, tx.err
+	}
+	gets := tx.Get(name, key)
+	if len(gets) == 0 {
+		return 0, tx.err
+	}
+	if len(gets[1]) != 8 {
+		return 0, fmt.Errorf("%w: %q has %d bytes", ErrNotCounter, key, len(gets[1]))
+	}
+	return int64(BytesToUint64(gets[1])), nil
+}
@@ -0,0 +1,33 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_IncrBy(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("counters")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	if n, err := tx.IncrBy(name, []byte("views"), 5); err != nil || n != 5 {
+		t.Fatal("unexpected counter", n, err)
+	}
+	if n, err := tx.IncrBy(name, []byte("views"), -7); err != nil || n != -2 {
+		t.Fatal("unexpected counter", n, err)
+	}
+	if n := tx.Counter(name, []byte("views")); n != -2 {
+		t.Fatal("unexpected counter", n)
+	}
+	if n := tx.Counter(name, []byte("missing")); n != 0 {
+		t.Fatal("missing counter must be 0", n)
+	}
+	tx.Put(name, []byte("text"), []byte("abc"))
+	if _, err := tx.IncrBy(name, []byte("text"), 1); !errors.Is(err, ErrNotCounter) {
+		t.Fatal("expect ErrNotCounter", err)
+	}
+}
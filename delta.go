@@ -0,0 +1,148 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var _deltaPrefix = []byte{22}
+
+// EnableDelta store updates of keys in bucket as deltas against the base value,
+// after maxDeltas deltas the value is consolidated into a new base, maxDeltas <= 0 disable delta mode
+func (db *DB) EnableDelta(name []byte, maxDeltas int) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.maxDeltas = maxDeltas
+	})
+}
+
+// deltaKey key of delta in delta bucket, like [len(key), key, seq]
+func deltaKey(key []byte, seq uint32) []byte {
+	k := make([]byte, 2+len(key)+4)
+	binary.BigEndian.PutUint16(k, uint16(len(key)))
+	copy(k[2:], key)
+	binary.BigEndian.PutUint32(k[2+len(key):], seq)
+	return k
+}
+
+// deltaKeyPrefix prefix of all delta keys of key
+func deltaKeyPrefix(key []byte) []byte {
+	k := deltaKey(key, 0)
+	return k[:len(k)-4]
+}
+
+// isDeltaKey check k is a delta key with prefix
+func isDeltaKey(k, prefix []byte) bool {
+	return len(k) == len(prefix)+4 && bytes.HasPrefix(k, prefix)
+}
+
+// makeDelta encode new value as common prefix length, common suffix length and changed middle of old value
+func makeDelta(old, new []byte) []byte {
+	var p, s int
+	for p < len(old) && p < len(new) && old[p] == new[p] {
+		p++
+	}
+	for s < len(old)-p && s < len(new)-p && old[len(old)-1-s] == new[len(new)-1-s] {
+		s++
+	}
+	d := make([]byte, 2*binary.MaxVarintLen64+len(new)-p-s)
+	n := binary.PutUvarint(d, uint64(p))
+	n += binary.PutUvarint(d[n:], uint64(s))
+	n += copy(d[n:], new[p:len(new)-s])
+	return d[:n]
+}
+
+// applyDelta rebuild value from old value and delta
+func applyDelta(old, delta []byte) ([]byte, error) {
+	p, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, errors.New("invalid delta")
+	}
+	s, m := binary.Uvarint(delta[n:])
+	if m <= 0 || p+s > uint64(len(old)) {
+		return nil, errors.New("invalid delta")
+	}
+	return BytesConcat(old[:p], delta[n+m:], old[uint64(len(old))-s:]), nil
+}
+
+// deltaBucket get delta bucket of bucket, nil if bucket has no delta
+func (tx *Tx) deltaBucket(name []byte) backendBucket {
+	return tx.tx.Bucket(shadowName(_deltaPrefix, name))
+}
+
+// resolve apply deltas of key to base value v
+func (tx *Tx) resolve(d backendBucket, key, v []byte) []byte {
+	if d == nil || v == nil {
+		return v
+	}
+	prefix := deltaKeyPrefix(key)
+	c := d.Cursor()
+	for k, delta := c.Seek(prefix); isDeltaKey(k, prefix); k, delta = c.Next() {
+		nv, err := applyDelta(v, delta)
+		if err != nil {
+			tx.Error(err)
+			return v
+		}
+		v = nv
+	}
+	return v
+}
+
+// putDelta put value of key as delta, consolidate when delta count reach maxDeltas
+func (tx *Tx) putDelta(name []byte, b backendBucket, key, value []byte, maxDeltas int) error {
+	raw := b.Get(key)
+	if raw == nil {
+		if err := tx.deleteDeltas(name, key); err != nil {
+			return err
+		}
+		return tx.store(name, b, key, value)
+	}
+	old := raw
+	if tx.hasEnvelope(name) {
+		e, err := DecodeEnvelope(raw)
+		if err != nil {
+			return err
+		}
+		old = e.Payload
+	}
+	d, err := tx.tx.CreateBucketIfNotExists(shadowName(_deltaPrefix, name))
+	if err != nil {
+		return err
+	}
+	prefix := deltaKeyPrefix(key)
+	var seq uint32
+	c := d.Cursor()
+	for k, delta := c.Seek(prefix); isDeltaKey(k, prefix); k, delta = c.Next() {
+		if old, err = applyDelta(old, delta); err != nil {
+			return err
+		}
+		seq = binary.BigEndian.Uint32(k[len(prefix):])
+	}
+	if int(seq) >= maxDeltas {
+		if err := tx.deleteDeltas(name, key); err != nil {
+			return err
+		}
+		return tx.store(name, b, key, value)
+	}
+	return d.Put(deltaKey(key, seq+1), makeDelta(old, value))
+}
+
+// deleteDeltas delete all deltas of key
+func (tx *Tx) deleteDeltas(name []byte, key []byte) error {
+	d := tx.deltaBucket(name)
+	if d == nil {
+		return nil
+	}
+	prefix := deltaKeyPrefix(key)
+	var ks [][]byte
+	c := d.Cursor()
+	for k, _ := c.Seek(prefix); isDeltaKey(k, prefix); k, _ = c.Next() {
+		ks = append(ks, append([]byte{}, k...))
+	}
+	for _, k := range ks {
+		if err := d.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package zbolt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDB_EnableDelta(t *testing.T) {
+	name := []byte("delta")
+	db.EnableDelta(name, 3)
+	defer db.EnableDelta(name, 0)
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	base := bytes.Repeat([]byte("0123456789"), 100)
+	tx.Put(name, []byte("key"), base)
+	for i := 0; i < 5; i++ {
+		value := append([]byte{}, base...)
+		value[500] = byte('a' + i)
+		tx.Put(name, []byte("key"), value)
+		gets := tx.Get(name, []byte("key"))
+		if len(gets) != 2 || !bytes.Equal(gets[1], value) {
+			t.Fatal("unexpected value after delta put", i)
+		}
+	}
+	if tx.Error() != nil {
+		t.Fatal(tx.Error())
+	}
+	if next := tx.Next(name, nil, 0); len(next) != 2 || next[1][500] != 'e' {
+		t.Fatal("next not resolve deltas")
+	}
+	tx.Delete(name, []byte("key"))
+	tx.Put(name, []byte("key"), []byte("new"))
+	if gets := tx.Get(name, []byte("key")); string(gets[1]) != "new" {
+		t.Fatal("deltas not deleted with key", string(gets[1]))
+	}
+}
+
+func TestMakeDelta(t *testing.T) {
+	cases := [][2]string{{"", "abc"}, {"abc", ""}, {"hello world", "hello there world"}, {"aaa", "aaaa"}, {"abc", "abc"}}
+	for _, c := range cases {
+		v, err := applyDelta([]byte(c[0]), makeDelta([]byte(c[0]), []byte(c[1])))
+		if err != nil || string(v) != c[1] {
+			t.Fatal("delta roundtrip failed", c, string(v), err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package zbolt
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// dump value types
+const (
+	DumpUint64 = "u64"  // 8 bytes big-endian uint64, value is decimal
+	DumpUTF8   = "utf8" // printable UTF-8 text
+	DumpJSON   = "json" // JSON object or array text
+	DumpBinary = "hex"  // other bytes, value is hex
+)
+
+// DumpValue bytes annotated with detected type
+type DumpValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// DumpRecord key value line of dump
+type DumpRecord struct {
+	Key   DumpValue `json:"k"`
+	Value DumpValue `json:"v"`
+	Delta bool      `json:"d,omitempty"` // delta of a value stored by EnableDelta, Key is the key in the delta bucket
+}
+
+// NewDumpValue detect type of bytes and annotate it
+func NewDumpValue(b []byte) DumpValue {
+	printable := utf8.Valid(b)
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\t' && r != '\r' {
+			printable = false
+			break
+		}
+	}
+	switch {
+	case len(b) == 8 && !printable:
+		return DumpValue{Type: DumpUint64, Value: strconv.FormatUint(BytesToUint64(b), 10)}
+	case printable && len(b) > 0 && (b[0] == '{' || b[0] == '[') && json.Valid(b):
+		return DumpValue{Type: DumpJSON, Value: string(b)}
+	case printable:
+		return DumpValue{Type: DumpUTF8, Value: string(b)}
+	}
+	return DumpValue{Type: DumpBinary, Value: hex.EncodeToString(b)}
+}
+
+// Bytes reconstruct the exact bytes of dump value
+func (d DumpValue) Bytes() ([]byte, error) {
+	switch d.Type {
+	case DumpUint64:
+		v, err := strconv.ParseUint(d.Value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return Uint64ToBytes(v), nil
+	case DumpUTF8, DumpJSON:
+		return []byte(d.Value), nil
+	case DumpBinary:
+		return hex.DecodeString(d.Value)
+	}
+	return nil, fmt.Errorf("unknown dump type %q", d.Type)
+}
+
+// Dump write the stored key values of bucket to w, one JSON DumpRecord per line,
+// followed by the deltas of values stored by EnableDelta
+func (tx *Tx) Dump(name []byte, w io.Writer) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	if tx.Error(b.ForEach(func(k, v []byte) error {
+		return enc.Encode(DumpRecord{Key: NewDumpValue(k), Value: NewDumpValue(v)})
+	})) != nil {
+		return tx.err
+	}
+	d := tx.deltaBucket(name)
+	if d == nil {
+		return nil
+	}
+	return tx.Error(d.ForEach(func(k, v []byte) error {
+		return enc.Encode(DumpRecord{Key: NewDumpValue(k), Value: NewDumpValue(v), Delta: true})
+	}))
+}
+
+// Load put the key values written by Dump from r to bucket byte-exact,
+// deltas already in bucket of the keys loaded are replaced by the loaded ones
+func (tx *Tx) Load(name []byte, r io.Reader) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if tx.Error(checkBucketName(name)) != nil {
+		return tx.err
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	var d backendBucket
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1<<30)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var rec DumpRecord
+		if tx.Error(json.Unmarshal(s.Bytes(), &rec)) != nil {
+			return tx.err
+		}
+		k, err := rec.Key.Bytes()
+		if tx.Error(err) != nil {
+			return tx.err
+		}
+		v, err := rec.Value.Bytes()
+		if tx.Error(err) != nil {
+			return tx.err
+		}
+		if rec.Delta {
+			if d == nil {
+				if d, err = tx.tx.CreateBucketIfNotExists(shadowName(_deltaPrefix, name)); tx.Error(err) != nil {
+					return tx.err
+				}
+			}
+			if tx.Error(d.Put(k, v)) != nil {
+				return tx.err
+			}
+			continue
+		}
+		if tx.Error(tx.deleteDeltas(name, k)) != nil || tx.Error(b.Put(k, v)) != nil {
+			return tx.err
+		}
+	}
+	return tx.Error(s.Err())
+}
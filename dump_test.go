@@ -0,0 +1,81 @@
+package zbolt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewDumpValue(t *testing.T) {
+	cases := map[string][]byte{
+		DumpUint64: Uint64ToBytes(42),
+		DumpUTF8:   []byte("hello"),
+		DumpJSON:   []byte(`{"a":1}`),
+		DumpBinary: {0, 1, 2},
+	}
+	for typ, b := range cases {
+		d := NewDumpValue(b)
+		if d.Type != typ {
+			t.Fatal("unexpected type", typ, d)
+		}
+		if rb, err := d.Bytes(); err != nil || !bytes.Equal(rb, b) {
+			t.Fatal("bytes not reconstructed", typ, rb, err)
+		}
+	}
+}
+
+func TestTx_Dump(t *testing.T) {
+	src, dst := []byte("dump_src"), []byte("dump_dst")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(src, Uint64ToBytes(1), []byte(`{"a":1}`), []byte("key"), []byte{0xff, 0})
+	var buf bytes.Buffer
+	if err := tx.Dump(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Load(dst, &buf); err != nil {
+		t.Fatal(err)
+	}
+	a, b := tx.Next(src, nil, 0), tx.Next(dst, nil, 0)
+	if len(a) != 4 || len(a) != len(b) {
+		t.Fatal("unexpected load result", a, b)
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			t.Fatal("loaded bytes differ", a[i], b[i])
+		}
+	}
+}
+
+func TestTx_DumpDelta(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	src, dst := []byte("delta_src"), []byte("delta_dst")
+	d.EnableDelta(src, 10)
+	value := bytes.Repeat([]byte("v"), 100)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(src, []byte("k"), value)
+	tx.Put(src, []byte("k"), append(append([]byte{}, value...), '1'))
+	tx.Put(src, []byte("k"), append(append([]byte{}, value...), '2'))
+	// stale deltas of a key in dst are replaced
+	d.EnableDelta(dst, 10)
+	tx.Put(dst, []byte("k"), []byte("old"))
+	tx.Put(dst, []byte("k"), []byte("older"))
+	var buf bytes.Buffer
+	if err := tx.Dump(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"d":true`)) {
+		t.Fatal("deltas not dumped", buf.String())
+	}
+	if err := tx.Load(dst, &buf); err != nil {
+		t.Fatal(err)
+	}
+	a, b := tx.Get(src, []byte("k")), tx.Get(dst, []byte("k"))
+	if len(a) != 2 || len(b) != 2 || !bytes.Equal(a[1], b[1]) || a[1][len(a[1])-1] != '2' {
+		t.Fatalf("unexpected load result %q %q", a, b)
+	}
+}
@@ -0,0 +1,124 @@
+package zbolt
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+)
+
+// envelope layout: magic(3) codec(1) flags(1) crc32 of payload(4) payload
+var _envelopeMagic = []byte{0xfe, 'z', 'e'}
+
+const envelopeHeaderLen = 9
+
+// envelope flags
+const (
+	EnvelopeCompressed byte = 1 << iota // payload compressed with deflate
+)
+
+// Envelope self describing value format, values without envelope are read as raw payload with codec 0
+type Envelope struct {
+	Codec   byte
+	Flags   byte
+	Payload []byte
+}
+
+// EnvelopeOptions envelope used to store values of bucket
+type EnvelopeOptions struct {
+	Codec byte // id of codec of values, see RegisterCodec. PutObject record the id of the codec it used
+	Flags byte
+}
+
+// _envelopeMetaKey prefix of meta keys of buckets holding values in envelope, followed by bucket name
+var _envelopeMetaKey = []byte("envelope:")
+
+// SetEnvelope store values of bucket in envelope, nil store raw values, values already stored stay readable either way.
+// Values are only opened in buckets with envelope set or holding values stored in envelope
+func (db *DB) SetEnvelope(name []byte, opts *EnvelopeOptions) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.envelope = opts
+	})
+}
+
+// EncodeEnvelope encode envelope to bytes, compress payload if EnvelopeCompressed flag set
+func EncodeEnvelope(e Envelope) ([]byte, error) {
+	payload := e.Payload
+	if e.Flags&EnvelopeCompressed != 0 {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		payload = buf.Bytes()
+	}
+	b := make([]byte, envelopeHeaderLen+len(payload))
+	copy(b, _envelopeMagic)
+	b[3] = e.Codec
+	b[4] = e.Flags
+	binary.BigEndian.PutUint32(b[5:], crc32.ChecksumIEEE(payload))
+	copy(b[envelopeHeaderLen:], payload)
+	return b, nil
+}
+
+// DecodeEnvelope decode bytes to envelope, decompress payload and verify checksum,
+// bytes without envelope magic are returned as raw payload
+func DecodeEnvelope(b []byte) (Envelope, error) {
+	if len(b) < envelopeHeaderLen || !bytes.HasPrefix(b, _envelopeMagic) {
+		return Envelope{Payload: b}, nil
+	}
+	e := Envelope{Codec: b[3], Flags: b[4], Payload: b[envelopeHeaderLen:]}
+	if crc32.ChecksumIEEE(e.Payload) != binary.BigEndian.Uint32(b[5:]) {
+		return Envelope{}, ErrChecksum
+	}
+	if e.Flags&EnvelopeCompressed != 0 {
+		r := flate.NewReader(bytes.NewReader(e.Payload))
+		defer r.Close()
+		payload, err := ioutil.ReadAll(r)
+		if err != nil {
+			return Envelope{}, err
+		}
+		e.Payload = payload
+	}
+	return e, nil
+}
+
+// markEnvelope record that bucket holds values in envelope, so they are opened even after SetEnvelope(name, nil).
+// Raw values already in bucket starting with the envelope magic are wrapped in a plain envelope first,
+// so no raw value of a bucket holding envelopes is ever taken for one
+func (tx *Tx) markEnvelope(name []byte, b backendBucket) error {
+	if meta := tx.tx.Bucket(_metaBucket); meta != nil && meta.Get(BytesConcat(_envelopeMetaKey, name)) != nil {
+		return nil
+	}
+	var keys [][]byte
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil && bytes.HasPrefix(v, _envelopeMagic) {
+			keys = append(keys, append([]byte{}, k...))
+		}
+	}
+	for _, k := range keys {
+		v, _ := EncodeEnvelope(Envelope{Payload: b.Get(k)})
+		if err := b.Put(k, v); err != nil {
+			return err
+		}
+	}
+	return tx.putMeta(BytesConcat(_envelopeMetaKey, name), []byte{1})
+}
+
+// hasEnvelope check values of bucket may be in envelope, raw values of other buckets are never decoded
+// even if they start with the envelope magic
+func (tx *Tx) hasEnvelope(name []byte) bool {
+	if tx.db != nil && tx.db.config(name).envelope != nil {
+		return true
+	}
+	meta := tx.tx.Bucket(_metaBucket)
+	return meta != nil && meta.Get(BytesConcat(_envelopeMetaKey, name)) != nil
+}
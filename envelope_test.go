@@ -0,0 +1,133 @@
+package zbolt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeEnvelope(t *testing.T) {
+	payload := bytes.Repeat([]byte("payload"), 100)
+	for _, flags := range []byte{0, EnvelopeCompressed} {
+		b, err := EncodeEnvelope(Envelope{Codec: 2, Flags: flags, Payload: payload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := DecodeEnvelope(b)
+		if err != nil || e.Codec != 2 || e.Flags != flags || !bytes.Equal(e.Payload, payload) {
+			t.Fatal("envelope roundtrip failed", flags, err)
+		}
+	}
+	if e, err := DecodeEnvelope([]byte("raw")); err != nil || string(e.Payload) != "raw" {
+		t.Fatal("raw value should decode as payload", err)
+	}
+	b, _ := EncodeEnvelope(Envelope{Payload: payload})
+	b[len(b)-1] ^= 1
+	if _, err := DecodeEnvelope(b); err != ErrChecksum {
+		t.Fatal("expect ErrChecksum, got", err)
+	}
+}
+
+func TestDB_SetEnvelope(t *testing.T) {
+	name := []byte("envelope")
+	payload := bytes.Repeat([]byte("payload"), 100)
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(name, []byte("raw"), payload)
+	db.SetEnvelope(name, &EnvelopeOptions{Flags: EnvelopeCompressed})
+	tx.Put(name, []byte("sealed"), payload)
+	db.SetEnvelope(name, nil)
+
+	if stored := tx.tx.Bucket(name).Get([]byte("sealed")); len(stored) >= len(payload) {
+		t.Fatal("value not compressed", len(stored))
+	}
+	gets := tx.Get(name, []byte("raw"), []byte("sealed"))
+	if len(gets) != 4 || !bytes.Equal(gets[1], payload) || !bytes.Equal(gets[3], payload) {
+		t.Fatal("values not readable after toggling envelope")
+	}
+}
+
+func TestDB_RawValueWithEnvelopeMagic(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("plain")
+	sealed, _ := EncodeEnvelope(Envelope{Flags: EnvelopeCompressed, Payload: []byte("payload")})
+	broken := BytesConcat(_envelopeMagic, []byte("not a checksum"))
+	// another bucket with envelope stamp the file feature
+	d.SetEnvelope([]byte("sealed"), &EnvelopeOptions{})
+	err = d.Update(func(tx *Tx) error {
+		tx.Put([]byte("sealed"), []byte("k"), []byte("v"))
+		return tx.Put(name, []byte("sealed"), sealed, []byte("broken"), broken)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	gets := tx.Get(name, []byte("sealed"), []byte("broken"))
+	if tx.Error() != nil || len(gets) != 4 || !bytes.Equal(gets[1], sealed) || !bytes.Equal(gets[3], broken) {
+		t.Fatal("raw values must round-trip unchanged", tx.Error(), gets)
+	}
+}
+
+func TestDB_EnvelopeCodec(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	type user struct{ Name string }
+	name := []byte("coded")
+	d.SetBucketCodec(name, CodecGob)
+	d.SetEnvelope(name, &EnvelopeOptions{})
+	err = d.Update(func(tx *Tx) error {
+		tx.PutObject(name, []byte("gob"), user{Name: "g"})
+		return tx.PutJSON(name, []byte("json"), user{Name: "j"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// values keep decoding with the codec recorded in their envelope
+	d.SetBucketCodec(name, CodecMsgpack)
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	var us []user
+	if err := tx.GetObjects(name, [][]byte{[]byte("gob"), []byte("json")}, &us); err != nil {
+		t.Fatal(err)
+	}
+	if len(us) != 2 || us[0].Name != "g" || us[1].Name != "j" {
+		t.Fatal("unexpected objects", us)
+	}
+	if stored := tx.tx.Bucket(name).Get([]byte("json")); stored[3] != CodecIDJSON {
+		t.Fatal("expect json codec id in envelope", stored[3])
+	}
+}
+
+func TestDB_EnvelopeMagicCollision(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("collide")
+	raw := BytesConcat(_envelopeMagic, []byte("raw value"))
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, []byte("old"), raw) }); err != nil {
+		t.Fatal(err)
+	}
+	d.SetEnvelope(name, &EnvelopeOptions{})
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, []byte("new"), []byte("v")) }); err != nil {
+		t.Fatal(err)
+	}
+	d.SetEnvelope(name, nil)
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, []byte("late"), raw) }); err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	gets := tx.Get(name, []byte("old"), []byte("new"), []byte("late"))
+	if tx.Error() != nil || len(gets) != 6 || !bytes.Equal(gets[1], raw) || string(gets[3]) != "v" || !bytes.Equal(gets[5], raw) {
+		t.Fatal("raw values with envelope magic must round-trip unchanged", tx.Error(), gets)
+	}
+}
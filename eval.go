@@ -0,0 +1,326 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Row key value produced by Eval
+type Row struct {
+	Key   []byte
+	Value []byte
+}
+
+// Eval run a read only query, stages are separated by | and the first stage produce rows.
+//
+// sources:
+//
+//	scan bucket=NAME [prefix=P] [after=K] [limit=N]   key values of bucket
+//	get bucket=NAME key=K [key=K ...]                 values of keys
+//	sort bucket=NAME [after=SORTKEY] [limit=N]        key values of Sort* bucket
+//	buckets                                           bucket names
+//
+// filters:
+//
+//	json .field.sub      replace value by JSON field, drop rows without it
+//	grep TEXT            keep rows which value contains TEXT
+//	keys                 drop values
+//	limit N              keep first N rows
+//	count                replace rows by one row with the count as value
+//
+// Arguments accept 0x prefix for hex bytes, u64: prefix for big-endian uint64, and "" quotes
+func (db *DB) Eval(query string) ([]Row, error) {
+	stages, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("empty query")
+	}
+	tx := db.NewTx(false)
+	defer tx.Rollback()
+	rows, err := tx.evalSource(stages[0])
+	if err != nil {
+		return nil, err
+	}
+	// rows point into pages of tx, they are read after it is closed
+	for i, row := range rows {
+		rows[i] = Row{Key: append([]byte(nil), row.Key...), Value: append([]byte(nil), row.Value...)}
+	}
+	for _, stage := range stages[1:] {
+		if rows, err = evalFilter(stage, rows); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// stage command and arguments of a query stage
+type stage struct {
+	cmd  string
+	args []string
+	opts map[string][]string
+}
+
+func (s stage) opt(name string) string {
+	if vs := s.opts[name]; len(vs) > 0 {
+		return vs[len(vs)-1]
+	}
+	return ""
+}
+
+func (s stage) limit() (int, error) {
+	if v := s.opt("limit"); v != "" {
+		return parseLimit(v)
+	}
+	return 0, nil
+}
+
+// parseLimit parse limit N, N must be >= 0
+func parseLimit(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid limit %d, must be >= 0", n)
+	}
+	return n, nil
+}
+
+// parseQuery split query to stages
+func parseQuery(query string) ([]stage, error) {
+	var stages []stage
+	var words []string
+	var cur strings.Builder
+	inQuote, hasWord := false, false
+	flushWord := func() {
+		if hasWord {
+			words = append(words, cur.String())
+			cur.Reset()
+			hasWord = false
+		}
+	}
+	flushStage := func() error {
+		flushWord()
+		if len(words) == 0 {
+			return errors.New("empty stage")
+		}
+		s := stage{cmd: words[0], opts: make(map[string][]string)}
+		for _, w := range words[1:] {
+			if i := strings.IndexByte(w, '='); i > 0 {
+				s.opts[w[:i]] = append(s.opts[w[:i]], w[i+1:])
+			} else {
+				s.args = append(s.args, w)
+			}
+		}
+		stages = append(stages, s)
+		words = nil
+		return nil
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\\' && inQuote && i+1 < len(query):
+			i++
+			cur.WriteByte(query[i])
+		case c == '"':
+			inQuote = !inQuote
+			hasWord = true
+		case (c == ' ' || c == '\t') && !inQuote:
+			flushWord()
+		case c == '|' && !inQuote:
+			if err := flushStage(); err != nil {
+				return nil, err
+			}
+		default:
+			cur.WriteByte(c)
+			hasWord = true
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unterminated quote")
+	}
+	if err := flushStage(); err != nil && len(stages) > 0 {
+		return nil, err
+	}
+	return stages, nil
+}
+
+// parseQueryArg parse argument to bytes, 0x prefix for hex and u64: prefix for uint64
+func parseQueryArg(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		return hex.DecodeString(s[2:])
+	case strings.HasPrefix(s, "u64:"):
+		v, err := strconv.ParseUint(s[4:], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return Uint64ToBytes(v), nil
+	}
+	return []byte(s), nil
+}
+
+// evalSource produce rows of source stage
+func (tx *Tx) evalSource(s stage) ([]Row, error) {
+	if s.cmd == "buckets" {
+		var rows []Row
+		err := tx.ForEachBucket(func(name []byte) error {
+			rows = append(rows, Row{Key: append([]byte{}, name...)})
+			return nil
+		})
+		return rows, err
+	}
+	name, err := parseQueryArg(s.opt("bucket"))
+	if err != nil {
+		return nil, err
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("%s: bucket required", s.cmd)
+	}
+	limit, err := s.limit()
+	if err != nil {
+		return nil, err
+	}
+	after, err := parseQueryArg(s.opt("after"))
+	if err != nil {
+		return nil, err
+	}
+	var bs [][]byte
+	switch s.cmd {
+	case "scan":
+		prefix, err := parseQueryArg(s.opt("prefix"))
+		if err != nil {
+			return nil, err
+		}
+		b := tx.tx.Bucket(name)
+		if b == nil {
+			break
+		}
+		r := tx.reader(name)
+		c := b.Cursor()
+		k, v := c.Seek(prefix)
+		if bytes.Compare(after, prefix) >= 0 {
+			if k, v = c.Seek(after); bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			bs = append(bs, k, r.value(k, v))
+			if limit > 0 && len(bs)/2 >= limit {
+				break
+			}
+		}
+	case "get":
+		var keys [][]byte
+		for _, k := range s.opts["key"] {
+			key, err := parseQueryArg(k)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		bs = tx.Get(name, keys...)
+	case "sort":
+		bs = tx.SortNext(name, after, limit)
+	default:
+		return nil, fmt.Errorf("unknown source %q", s.cmd)
+	}
+	if err := tx.Error(); err != nil {
+		return nil, err
+	}
+	rows := make([]Row, 0, len(bs)/2)
+	for i := 0; i+1 < len(bs); i += 2 {
+		rows = append(rows, Row{Key: bs[i], Value: bs[i+1]})
+	}
+	return rows, nil
+}
+
+// evalFilter apply filter stage to rows
+func evalFilter(s stage, rows []Row) ([]Row, error) {
+	switch s.cmd {
+	case "json":
+		if len(s.args) != 1 || !strings.HasPrefix(s.args[0], ".") {
+			return nil, errors.New("usage: json .field")
+		}
+		var path []string
+		if s.args[0] != "." {
+			path = strings.Split(s.args[0][1:], ".")
+		}
+		out := rows[:0]
+		for _, row := range rows {
+			if v, ok := jsonField(row.Value, path); ok {
+				out = append(out, Row{Key: row.Key, Value: v})
+			}
+		}
+		return out, nil
+	case "grep":
+		if len(s.args) != 1 {
+			return nil, errors.New("usage: grep TEXT")
+		}
+		out := rows[:0]
+		for _, row := range rows {
+			if bytes.Contains(row.Value, []byte(s.args[0])) {
+				out = append(out, row)
+			}
+		}
+		return out, nil
+	case "keys":
+		for i := range rows {
+			rows[i].Value = nil
+		}
+		return rows, nil
+	case "limit":
+		if len(s.args) != 1 {
+			return nil, errors.New("usage: limit N")
+		}
+		n, err := parseLimit(s.args[0])
+		if err != nil {
+			return nil, err
+		}
+		if n < len(rows) {
+			rows = rows[:n]
+		}
+		return rows, nil
+	case "count":
+		return []Row{{Key: []byte("count"), Value: []byte(strconv.Itoa(len(rows)))}}, nil
+	}
+	return nil, fmt.Errorf("unknown filter %q", s.cmd)
+}
+
+// jsonField get field by path of JSON value, array elements are addressed by index
+func jsonField(b []byte, path []string) ([]byte, bool) {
+	var v interface{}
+	if json.Unmarshal(b, &v) != nil {
+		return nil, false
+	}
+	for _, p := range path {
+		switch o := v.(type) {
+		case map[string]interface{}:
+			f, ok := o[p]
+			if !ok {
+				return nil, false
+			}
+			v = f
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(o) {
+				return nil, false
+			}
+			v = o[i]
+		default:
+			return nil, false
+		}
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), true
+	}
+	out, err := json.Marshal(v)
+	return out, err == nil
+}
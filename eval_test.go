@@ -0,0 +1,80 @@
+package zbolt
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDB_Eval(t *testing.T) {
+	name := []byte("eval_users")
+	tx := db.NewTx(true)
+	tx.Put(name,
+		[]byte("ab_1"), []byte(`{"email":"x@y.z"}`),
+		[]byte("us_1"), []byte(`{"email":"a@b.c","tags":["admin"]}`),
+		[]byte("us_2"), []byte(`{"name":"bob"}`),
+		[]byte("us_3"), []byte(`{"email":"c@d.e"}`),
+		[]byte("zz_1"), []byte(`{"email":"z@z.z"}`),
+	)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Eval("scan bucket=eval_users prefix=us_ limit=10 | json .email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || string(rows[0].Value) != "a@b.c" || string(rows[1].Key) != "us_3" {
+		t.Fatal("unexpected rows", rows)
+	}
+	rows, err = db.Eval(`scan bucket=eval_users prefix=us_ after=us_1 | json .tags.0 | count`)
+	if err != nil || string(rows[0].Value) != "0" {
+		t.Fatal("unexpected count", rows, err)
+	}
+	rows, err = db.Eval(`get bucket=eval_users key=us_2 key=zz_1 | grep "bob" | keys`)
+	if err != nil || len(rows) != 1 || string(rows[0].Key) != "us_2" || rows[0].Value != nil {
+		t.Fatal("unexpected get rows", rows, err)
+	}
+	if _, err := db.Eval("scan | json .a"); err == nil {
+		t.Fatal("expect bucket required error")
+	}
+	if _, err := db.Eval("scan bucket=eval_users | nope"); err == nil {
+		t.Fatal("expect unknown filter error")
+	}
+	for _, q := range []string{"scan bucket=eval_users | limit -1", "scan bucket=eval_users limit=-1"} {
+		if _, err := db.Eval(q); err == nil {
+			t.Fatal("expect invalid limit error", q)
+		}
+	}
+}
+
+func TestDB_EvalRowsOutliveTx(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "eval.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("eval_grow")
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k"), []byte("v"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := d.Eval("scan bucket=eval_grow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// grow and remap the file after rows were read
+	if err := d.Update(func(tx *Tx) error {
+		for i := 0; i < 2000; i++ {
+			if err := tx.Put(name, Uint64ToBytes(uint64(i)), make([]byte, 1024)); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(name, []byte("k"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || string(rows[0].Key) != "k" || string(rows[0].Value) != "v" {
+		t.Fatal("unexpected rows", rows)
+	}
+}
@@ -0,0 +1,87 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// range of first bytes of bucket names reserved for shadow buckets, [_shadowMin, _shadowMax)
+const (
+	_shadowMin byte = 20
+	_shadowMax byte = 32
+)
+
+// legacyFormat format of files written before formats were stamped
+var legacyFormat = Format{Version: 1, Features: FeatureSortFixed | FeatureMetaKinds}
+
+// Features zbolt features used by file, detected from its shadow buckets and stamped format on Open
+type Features struct {
+	Format   Format   // stamped format, legacy format of files written before formats were stamped
+	Sort     bool     // sort buckets of SortPut
+	Deltas   bool     // delta buckets of EnableDelta
+	Versions bool     // version buckets of EnableVersioning
+	Meta     bool     // meta bucket of format, schemas and index state
+	Reverse  bool     // reverse buckets of PutWithReverse
+	Index    bool     // secondary index buckets
+	TTL      bool     // expiry index of PutTTL
+	Envelope bool     // values wrapped in envelope
+	Unknown  [][]byte // buckets in the range reserved for shadow buckets unknown to this version
+}
+
+// Features get zbolt features used by file when it was opened
+func (db *DB) Features() Features {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.features
+}
+
+// detectFeatures detect features used by file from names of its buckets, f is the stamped format or nil
+func (tx *Tx) detectFeatures(f *Format) (Features, error) {
+	var fs Features
+	err := tx.tx.ForEach(func(name []byte, _ backendBucket) error {
+		if !isShadowName(name) {
+			return nil
+		}
+		switch {
+		case bytes.Equal(name, _metaBucket):
+			fs.Meta = true
+		case bytes.HasPrefix(name, _keyPrefix), bytes.HasPrefix(name, _valuePrefix):
+			fs.Sort = true
+		case bytes.HasPrefix(name, _deltaPrefix):
+			fs.Deltas = true
+		case bytes.HasPrefix(name, _versionPrefix):
+			fs.Versions = true
+		case bytes.HasPrefix(name, _reversePrefix):
+			fs.Reverse = true
+		case bytes.HasPrefix(name, _indexPrefix):
+			fs.Index = true
+		case bytes.HasPrefix(name, _expiryPrefix):
+			fs.TTL = true
+		default:
+			fs.Unknown = append(fs.Unknown, append([]byte{}, name...))
+		}
+		return nil
+	})
+	if err != nil {
+		return fs, err
+	}
+	switch {
+	case f != nil:
+		fs.Format = *f
+	case fs.Sort:
+		// sort keys of unstamped files are fixed 8 bytes, must be migrated before use
+		fs.Format = legacyFormat
+	default:
+		fs.Format = Format{Version: FormatVersion, Features: defaultFeatures}
+	}
+	fs.Envelope = fs.Format.Features&FeatureEnvelope != 0
+	return fs, nil
+}
+
+// check refuse features unknown to this version
+func (fs Features) check() error {
+	if len(fs.Unknown) > 0 {
+		return fmt.Errorf("%w: unknown shadow buckets %q, upgrade zbolt", ErrFormatNewer, fs.Unknown)
+	}
+	return checkFormat(&fs.Format)
+}
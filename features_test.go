@@ -0,0 +1,67 @@
+package zbolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestDB_Features(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	tx.SortPut([]byte("board"), []byte("1"), []byte("k"), []byte("v"))
+	tx.PutTTL([]byte("sessions"), time.Hour, []byte("s"), []byte("v"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	d, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := d.Features()
+	d.Close()
+	if !fs.Sort || !fs.TTL || !fs.Meta || fs.Versions || fs.Format.Version != FormatVersion || len(fs.Unknown) != 0 {
+		t.Fatalf("unexpected features %+v", fs)
+	}
+
+	bdb, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucket([]byte{_shadowMax - 1, 'x'})
+		return err
+	})
+	bdb.Close()
+	if _, err := Open(path); !errors.Is(err, ErrFormatNewer) {
+		t.Fatal("expect ErrFormatNewer for unknown shadow bucket, got", err)
+	}
+}
+
+func TestOpen_LegacySort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	bdb, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket(BytesConcat(_keyPrefix, []byte("board")))
+		if err != nil {
+			return err
+		}
+		return b.Put(BytesConcat(Uint64ToBytes(1), []byte("k")), []byte("v"))
+	})
+	bdb.Close()
+	if _, err := Open(path); !errors.Is(err, ErrFormatOlder) {
+		t.Fatal("expect ErrFormatOlder for unstamped sort buckets, got", err)
+	}
+}
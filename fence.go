@@ -0,0 +1,46 @@
+package zbolt
+
+import (
+	"fmt"
+)
+
+var _epochMetaKey = []byte("epoch")
+
+// Fence start a new write epoch stored in meta bucket and return it, transactions requiring an older epoch fail.
+// Coordinators call it after a failover or restore to invalidate in-flight writers
+func (db *DB) Fence() (epoch uint64, err error) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	epoch = tx.Epoch() + 1
+	if err := tx.putMeta(_epochMetaKey, Uint64ToBytes(epoch)); err != nil {
+		return 0, err
+	}
+	return epoch, tx.Commit()
+}
+
+// Epoch get current write epoch, 0 if never fenced
+func (tx *Tx) Epoch() uint64 {
+	if tx.err != nil {
+		return 0
+	}
+	b := tx.tx.Bucket(_metaBucket)
+	if b == nil {
+		return 0
+	}
+	if v := b.Get(_epochMetaKey); len(v) == 8 {
+		return BytesToUint64(v)
+	}
+	return 0
+}
+
+// RequireEpoch check tx writes in epoch, otherwise ErrStaleEpoch is accumulated in tx so its writes and commit fail.
+// Writable transactions hold off Fence, so a checked transaction commits before the next epoch starts
+func (tx *Tx) RequireEpoch(epoch uint64) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if cur := tx.Epoch(); cur != epoch {
+		return tx.Error(fmt.Errorf("%w: require %d, current %d", ErrStaleEpoch, epoch, cur))
+	}
+	return nil
+}
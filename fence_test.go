@@ -0,0 +1,47 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDB_Fence(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx := d.NewTx(false)
+	epoch := tx.Epoch()
+	tx.Rollback()
+	if epoch != 0 {
+		t.Fatal("unexpected epoch", epoch)
+	}
+	if epoch, err = d.Fence(); err != nil || epoch != 1 {
+		t.Fatal("fence fail", epoch, err)
+	}
+
+	tx = d.NewTx(true)
+	if err := tx.RequireEpoch(epoch); err != nil {
+		t.Fatal(err)
+	}
+	tx.Put([]byte("b"), []byte("k"), []byte("v"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if next, _ := d.Fence(); next != 2 {
+		t.Fatal("unexpected epoch", next)
+	}
+	tx = d.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.RequireEpoch(epoch); !errors.Is(err, ErrStaleEpoch) {
+		t.Fatal("expect ErrStaleEpoch, got", err)
+	}
+	if err := tx.Put([]byte("b"), []byte("k"), []byte("stale")); !errors.Is(err, ErrStaleEpoch) {
+		t.Fatal("stale write not rejected", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrStaleEpoch) {
+		t.Fatal("stale commit not rejected", err)
+	}
+}
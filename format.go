@@ -0,0 +1,152 @@
+package zbolt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var _formatMetaKey = []byte("format")
+
+// FormatVersion version of zbolt file format written by this package
+const FormatVersion uint32 = 2
+
+// format features stamped in meta bucket
+const (
+	FeatureSortFixed   uint32 = 1 << iota // sort buckets use 8 bytes sort key
+	FeatureEnvelope                       // values may be wrapped in envelope
+	FeatureMetaKinds                      // meta bucket keys prefixed by kind, like "schema:"
+	FeatureSortEscaped                    // sort buckets use escaped sort key of any length
+
+	knownFeatures   = FeatureSortFixed | FeatureEnvelope | FeatureMetaKinds | FeatureSortEscaped
+	defaultFeatures = FeatureSortEscaped | FeatureMetaKinds
+)
+
+// formatMigrations migrate file from format version v to v+1 in the same transaction, updating features of f
+var formatMigrations = map[uint32]func(tx *Tx, f *Format) error{
+	1: migrateSortEscaped,
+}
+
+// Format zbolt format stamped in meta bucket when file is created
+type Format struct {
+	Version  uint32
+	Features uint32
+}
+
+// Format get format stamped in meta bucket, nil if not stamped
+func (tx *Tx) Format() (*Format, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	b := tx.tx.Bucket(_metaBucket)
+	if b == nil {
+		return nil, nil
+	}
+	v := b.Get(_formatMetaKey)
+	if v == nil {
+		return nil, nil
+	}
+	var f Format
+	if err := json.Unmarshal(v, &f); err != nil {
+		return nil, tx.Error(err)
+	}
+	return &f, nil
+}
+
+// setFormat stamp format in meta bucket
+func (tx *Tx) setFormat(f Format) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return tx.putMeta(_formatMetaKey, b)
+}
+
+// setFeature add feature to stamped format if missing
+func (tx *Tx) setFeature(feature uint32) error {
+	f, err := tx.Format()
+	if err != nil || f == nil || f.Features&feature != 0 {
+		return err
+	}
+	f.Features |= feature
+	return tx.setFormat(*f)
+}
+
+// checkFormat check format of opened file is supported, return ErrFormatOlder if it must be migrated by MigrateFormat.
+// It does not stamp files, see openFormat
+func checkFormat(f *Format) error {
+	switch {
+	case f.Version > FormatVersion:
+		return fmt.Errorf("%w: file version %d, supported %d, upgrade zbolt", ErrFormatNewer, f.Version, FormatVersion)
+	case f.Features&^knownFeatures != 0:
+		return fmt.Errorf("%w: unknown features %#x, upgrade zbolt", ErrFormatNewer, f.Features&^knownFeatures)
+	case f.Version < FormatVersion:
+		return fmt.Errorf("%w: file version %d, supported %d, run MigrateFormat", ErrFormatOlder, f.Version, FormatVersion)
+	}
+	return nil
+}
+
+// openFormat check format and features of DB on Open, stamp format when missing
+func (db *DB) openFormat() error {
+	tx := db.NewTx(false)
+	f, err := tx.Format()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	fs, err := tx.detectFeatures(f)
+	tx.Rollback()
+	if err != nil {
+		return err
+	}
+	db.mu.Lock()
+	db.features = fs
+	db.mu.Unlock()
+	if err := fs.check(); err != nil || f != nil || db.engine().IsReadOnly() {
+		return err
+	}
+	tx = db.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.setFormat(fs.Format); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrateFormat migrate file at path to FormatVersion, return the version it was migrated from.
+// File must not be opened
+func MigrateFormat(path string) (uint32, error) {
+	bdb, err := openBackend(path, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer bdb.Close()
+	db := &DB{db: bdb}
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	f, err := tx.Format()
+	if err != nil {
+		return 0, err
+	}
+	if f == nil {
+		fs, err := tx.detectFeatures(nil)
+		if err != nil {
+			return 0, err
+		}
+		f = &fs.Format
+	}
+	from := f.Version
+	if err := checkFormat(f); err == nil || f.Version > FormatVersion {
+		return from, err
+	}
+	for ; f.Version < FormatVersion; f.Version++ {
+		if m := formatMigrations[f.Version]; m != nil {
+			if err := m(tx, f); err != nil {
+				return from, fmt.Errorf("migrate format %d to %d: %w", f.Version, f.Version+1, err)
+			}
+		}
+	}
+	if err := tx.setFormat(*f); err != nil {
+		return from, err
+	}
+	return from, tx.Commit()
+}
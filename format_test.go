@@ -0,0 +1,66 @@
+package zbolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+// stampFormat overwrite format stamped in file
+func stampFormat(t *testing.T, path string, f Format) {
+	bdb, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bdb.Close()
+	tx := NewDB(bdb).NewTx(true)
+	defer tx.Rollback()
+	tx.setFormat(f)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpen_Format(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "format.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetEnvelope([]byte("enveloped"), &EnvelopeOptions{})
+	tx := d.NewTx(true)
+	tx.Put([]byte("enveloped"), []byte("k"), []byte("v"))
+	f, err := tx.Format()
+	if err != nil || f == nil || f.Version != FormatVersion || f.Features != defaultFeatures|FeatureEnvelope {
+		t.Fatal("unexpected format", f, err)
+	}
+	tx.Commit()
+	d.Close()
+
+	stampFormat(t, path, Format{Version: FormatVersion + 1, Features: defaultFeatures})
+	if _, err := Open(path); !errors.Is(err, ErrFormatNewer) {
+		t.Fatal("expect ErrFormatNewer, got", err)
+	}
+	if _, err := MigrateFormat(path); !errors.Is(err, ErrFormatNewer) {
+		t.Fatal("expect ErrFormatNewer, got", err)
+	}
+	stampFormat(t, path, Format{Version: FormatVersion, Features: 1 << 31})
+	if _, err := Open(path); !errors.Is(err, ErrFormatNewer) {
+		t.Fatal("expect ErrFormatNewer for unknown feature, got", err)
+	}
+
+	stampFormat(t, path, Format{Version: FormatVersion - 1, Features: defaultFeatures})
+	if _, err := Open(path); !errors.Is(err, ErrFormatOlder) {
+		t.Fatal("expect ErrFormatOlder, got", err)
+	}
+	if from, err := MigrateFormat(path); err != nil || from != FormatVersion-1 {
+		t.Fatal("migrate fail", from, err)
+	}
+	d, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+}
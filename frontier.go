@@ -0,0 +1,125 @@
+package zbolt
+
+import (
+	"net/url"
+	"time"
+)
+
+// FrontierOptions options of Frontier
+type FrontierOptions struct {
+	ExpectedURLs  int           // sizing of visited bloom filter, 0 means 1000000
+	FalsePositive float64       // false positive rate of visited bloom filter, 0 means 0.01
+	DomainDelay   time.Duration // minimum delay between two urls of the same domain popped
+}
+
+// Frontier crawl frontier, a queue of urls prioritized by sort key, a visited set with bloom prefilter
+// and per-domain rate state, stored in buckets name/queue, name/visited and name/domains
+type Frontier struct {
+	queue   []byte
+	visited []byte
+	domains []byte
+	delay   time.Duration
+}
+
+// Frontier open crawl frontier stored in buckets prefixed by name, nil opts use defaults
+func (db *DB) Frontier(name string, opts *FrontierOptions) (*Frontier, error) {
+	var o FrontierOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.ExpectedURLs <= 0 {
+		o.ExpectedURLs = 1000000
+	}
+	if o.FalsePositive <= 0 {
+		o.FalsePositive = 0.01
+	}
+	f := &Frontier{
+		queue:   []byte(name + "/queue"),
+		visited: []byte(name + "/visited"),
+		domains: []byte(name + "/domains"),
+		delay:   o.DomainDelay,
+	}
+	if err := db.EnableBloom(f.visited, o.ExpectedURLs, o.FalsePositive); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Push add url to queue with priority, lower priority is popped first.
+// Return false if url is already visited or queued
+func (f *Frontier) Push(tx *Tx, u string, priority uint64) (bool, error) {
+	if tx.err != nil {
+		return false, tx.err
+	}
+	if f.Visited(tx, u) || len(tx.SortGet(f.queue, []byte(u))) > 0 {
+		return false, tx.err
+	}
+	if err := tx.SortPut(f.queue, Uint64ToBytes(priority), []byte(u), []byte{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Visited check url was popped from the frontier
+func (f *Frontier) Visited(tx *Tx, u string) bool {
+	return len(tx.Get(f.visited, []byte(u))) > 0
+}
+
+// Pop take at most n urls with lowest priority whose domain is not delayed at now, mark them visited
+// and delay their domain by DomainDelay
+func (f *Frontier) Pop(tx *Tx, n int, now time.Time) ([]string, error) {
+	urls := []string{}
+	if tx.err != nil {
+		return urls, tx.err
+	}
+	ready := make(map[string]bool)
+	var keys [][]byte
+	var cursor SortCursor
+	for len(urls) < n {
+		var entries []Entry
+		entries, cursor = tx.SortPage(f.queue, cursor, n)
+		for _, e := range entries {
+			domain := domainOf(string(e.Key))
+			r, ok := ready[domain]
+			if !ok {
+				gets := tx.Get(f.domains, []byte(domain))
+				r = len(gets) == 0 || !BytesToTime(gets[1]).After(now)
+			}
+			if r && len(urls) < n {
+				urls = append(urls, string(e.Key))
+				keys = append(keys, append([]byte{}, e.Key...))
+			}
+			// at most one url of a domain per pop when delayed
+			ready[domain] = r && f.delay <= 0
+		}
+		if cursor == nil {
+			break
+		}
+	}
+	if tx.err != nil {
+		return []string{}, tx.err
+	}
+	if len(keys) == 0 {
+		return urls, nil
+	}
+	if err := tx.SortDelete(f.queue, keys...); err != nil {
+		return []string{}, err
+	}
+	for _, key := range keys {
+		if err := tx.Put(f.visited, key, TimeToBytes(now)); err != nil {
+			return []string{}, err
+		}
+		if err := tx.Put(f.domains, []byte(domainOf(string(key))), TimeToBytes(now.Add(f.delay))); err != nil {
+			return []string{}, err
+		}
+	}
+	return urls, nil
+}
+
+// domainOf host of url, the url itself if it has no host
+func domainOf(u string) string {
+	if p, err := url.Parse(u); err == nil && p.Host != "" {
+		return p.Hostname()
+	}
+	return u
+}
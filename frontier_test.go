@@ -0,0 +1,45 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrontier(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	f, err := d.Frontier("crawl", &FrontierOptions{ExpectedURLs: 100, DomainDelay: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for i, u := range []string{"http://a.com/1", "http://a.com/2", "http://b.com/1", "http://c.com/1"} {
+		if ok, err := f.Push(tx, u, uint64(i)); !ok || err != nil {
+			t.Fatal("push fail", u, ok, err)
+		}
+	}
+	if ok, _ := f.Push(tx, "http://a.com/1", 9); ok {
+		t.Fatal("queued url pushed again")
+	}
+	now := time.Now()
+	urls, err := f.Pop(tx, 2, now)
+	if err != nil || len(urls) != 2 || urls[0] != "http://a.com/1" || urls[1] != "http://b.com/1" {
+		t.Fatal("unexpected pop", urls, err)
+	}
+	if !f.Visited(tx, "http://a.com/1") {
+		t.Fatal("popped url not visited")
+	}
+	if ok, _ := f.Push(tx, "http://a.com/1", 0); ok {
+		t.Fatal("visited url pushed again")
+	}
+	if urls, _ := f.Pop(tx, 2, now); len(urls) != 1 || urls[0] != "http://c.com/1" {
+		t.Fatal("delayed domain popped", urls)
+	}
+	if urls, _ := f.Pop(tx, 2, now.Add(time.Hour)); len(urls) != 1 || urls[0] != "http://a.com/2" {
+		t.Fatal("unexpected pop after delay", urls)
+	}
+}
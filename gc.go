@@ -0,0 +1,175 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// GCPolicy policy to remove old versions and tombstones of versioned bucket
+type GCPolicy struct {
+	MaxVersions int           // keep at most MaxVersions versions of a key, 0 representative of no limit
+	MaxAge      time.Duration // remove versions older than MaxAge, 0 representative of no limit
+	MinVersions int           // always keep MinVersions newest versions of a key
+}
+
+// GCStats metrics of version gc
+type GCStats struct {
+	Runs           uint64
+	Versions       uint64 // removed versions
+	Tombstones     uint64 // removed tombstones
+	ReclaimedBytes uint64
+	LastRun        time.Time
+}
+
+// gcState background compactor state of DB
+type gcState struct {
+	mu     sync.Mutex
+	stats  GCStats
+	worker periodic
+}
+
+// SetGCPolicy set gc policy of versioned bucket
+func (db *DB) SetGCPolicy(name []byte, policy GCPolicy) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.gc = &policy
+	})
+}
+
+// GCStats get metrics of version gc
+func (db *DB) GCStats() GCStats {
+	db.gc.mu.Lock()
+	defer db.gc.mu.Unlock()
+	return db.gc.stats
+}
+
+// StartGC start background compactor applying gc policies every interval, remove at most batch versions per transaction
+func (db *DB) StartGC(interval time.Duration, batch int) {
+	db.gc.worker.start(db, "gc", interval, func() { db.RunGC(batch) })
+}
+
+// StopGC stop background compactor and wait it exit
+func (db *DB) StopGC() {
+	db.gc.worker.halt()
+}
+
+// RunGC apply gc policies to all versioned buckets once, remove at most batch versions per transaction
+func (db *DB) RunGC(batch int) error {
+	if batch <= 0 {
+		batch = 1000
+	}
+	db.mu.RLock()
+	policies := make(map[string]GCPolicy)
+	for name, c := range db.buckets {
+		if c.gc != nil {
+			policies[name] = *c.gc
+		}
+	}
+	db.mu.RUnlock()
+	var stats GCStats
+	for name, policy := range policies {
+		var from []byte
+		for {
+			tx := db.NewTx(true)
+			next, err := tx.gcBatch([]byte(name), policy, from, batch, &stats)
+			if err == nil {
+				err = tx.Commit()
+			}
+			tx.Rollback()
+			if err != nil {
+				return err
+			}
+			if next == nil {
+				break
+			}
+			from = next
+		}
+	}
+	db.gc.mu.Lock()
+	db.gc.stats.Runs++
+	db.gc.stats.Versions += stats.Versions
+	db.gc.stats.Tombstones += stats.Tombstones
+	db.gc.stats.ReclaimedBytes += stats.ReclaimedBytes
+	db.gc.stats.LastRun = time.Now()
+	db.gc.mu.Unlock()
+	return nil
+}
+
+// gcBatch remove versions of keys start from history key from, return history key to continue or nil if finished
+func (tx *Tx) gcBatch(name []byte, policy GCPolicy, from []byte, batch int, stats *GCStats) ([]byte, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	h := tx.tx.Bucket(shadowName(_versionPrefix, name))
+	if h == nil {
+		return nil, nil
+	}
+	now := uint64(time.Now().UnixNano())
+	var removes [][]byte
+	var key []byte
+	var group [][2][]byte
+	collect := func() {
+		n := len(group)
+		keepFrom := 0 // index of the oldest version to keep
+		for i, kv := range group {
+			if n-i <= policy.MinVersions {
+				break
+			}
+			// versions stamped in the future by a skewed clock are of age 0
+			var age uint64
+			if at := binary.BigEndian.Uint64(kv[0][len(kv[0])-8:]); at < now {
+				age = now - at
+			}
+			if (policy.MaxVersions > 0 && n-i > policy.MaxVersions) || (policy.MaxAge > 0 && age > uint64(policy.MaxAge)) {
+				keepFrom = i + 1
+			}
+		}
+		// a tombstone without older version carries no information
+		for keepFrom < n && group[keepFrom][1][0] == versionDelete {
+			keepFrom++
+		}
+		for _, kv := range group[:keepFrom] {
+			removes = append(removes, kv[0])
+			stats.ReclaimedBytes += uint64(len(kv[0]) + len(kv[1]))
+			if kv[1][0] == versionDelete {
+				stats.Tombstones++
+			} else {
+				stats.Versions++
+			}
+		}
+		group = group[:0]
+	}
+	var next []byte
+	c := h.Cursor()
+	var k, v []byte
+	if from == nil {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(from)
+	}
+	for ; k != nil; k, v = c.Next() {
+		kk, rest, ok := unescapeKey(k)
+		if !ok || len(rest) != 8 || len(v) == 0 {
+			continue
+		}
+		if !bytes.Equal(kk, key) {
+			collect()
+			if len(removes) >= batch {
+				next = append([]byte{}, k...)
+				break
+			}
+			key = kk
+		}
+		group = append(group, [2][]byte{append([]byte{}, k...), append([]byte{}, v...)})
+	}
+	if next == nil {
+		collect()
+	}
+	for _, k := range removes {
+		if tx.Error(h.Delete(k)) != nil {
+			return nil, tx.err
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,79 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_RunGC(t *testing.T) {
+	name := []byte("gc_versioned")
+	db.EnableVersioning(name, true)
+	defer db.EnableVersioning(name, false)
+	db.SetGCPolicy(name, GCPolicy{MaxVersions: 2})
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	for _, v := range []string{"1", "2", "3", "4"} {
+		tx.Put(name, []byte("a"), []byte(v))
+		time.Sleep(time.Millisecond)
+	}
+	tx.Put(name, []byte("b"), []byte("1"))
+	time.Sleep(time.Millisecond)
+	tx.Delete(name, []byte("b"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	before := db.GCStats()
+	if err := db.RunGC(1); err != nil {
+		t.Fatal(err)
+	}
+	stats := db.GCStats()
+	if stats.Runs != before.Runs+1 || stats.Versions-before.Versions < 2 || stats.ReclaimedBytes == before.ReclaimedBytes {
+		t.Fatal("unexpected gc stats", before, stats)
+	}
+
+	tx = db.NewTx(true)
+	n := 0
+	tx.ForEach(BytesConcat(_versionPrefix, name), func(k, v []byte) error {
+		n++
+		return nil
+	})
+	if n != 4 {
+		t.Fatal("expect 4 versions left, got", n)
+	}
+	if v := tx.GetAsOf(name, []byte("a"), time.Now()); string(v) != "4" {
+		t.Fatal("unexpected newest version", string(v))
+	}
+	tx.DeleteBucket(name)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDB_RunGCFutureVersion(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("gc_future")
+	d.EnableVersioning(name, true)
+	d.SetGCPolicy(name, GCPolicy{MaxAge: time.Hour})
+	// version written by a clock running ahead
+	if err := d.Update(func(tx *Tx) error {
+		h, err := tx.tx.CreateBucketIfNotExists(BytesConcat(_versionPrefix, name))
+		if err != nil {
+			return err
+		}
+		return h.Put(versionKey([]byte("a"), time.Now().Add(time.Minute)), []byte{versionPut, '1'})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RunGC(0); err != nil {
+		t.Fatal(err)
+	}
+	if stats := d.GCStats(); stats.Versions != 0 {
+		t.Fatal("future version removed", stats)
+	}
+}
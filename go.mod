@@ -1,8 +1,17 @@
 module github.com/dukangxu/zbolt
 
-go 1.14
+go 1.18
 
 require (
 	github.com/boltdb/bolt v1.3.1
-	golang.org/x/sys v0.0.0-20200602225109-6fdc65e7d980 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/term v0.5.0
+	golang.org/x/text v0.13.0
+	google.golang.org/protobuf v1.33.0
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
@@ -0,0 +1,46 @@
+package zbolt
+
+import "time"
+
+// HealthReport state of DB for readiness and liveness probes
+type HealthReport struct {
+	Healthy        bool      `json:"healthy"`
+	Writable       bool      `json:"writable"` // a no-op write transaction committed
+	ReadOnly       bool      `json:"read_only"`
+	ShuttingDown   bool      `json:"shutting_down"`
+	Error          string    `json:"error,omitempty"` // error of writable check
+	OpenTx         int       `json:"open_tx"`
+	FreePages      int       `json:"free_pages"`
+	PendingPages   int       `json:"pending_pages"` // pages freed but still used by open read transactions
+	LastBackup     time.Time `json:"last_backup"`
+	LastCompaction time.Time `json:"last_compaction"`
+	Workers        []string  `json:"workers"` // running background workers
+}
+
+// Health check DB is writable by committing a no-op transaction and collect its state.
+// Read only DB is healthy without the writable check
+func (db *DB) Health() HealthReport {
+	r := HealthReport{
+		ReadOnly:       db.engine().IsReadOnly(),
+		ShuttingDown:   db.isClosing(),
+		OpenTx:         db.openTxCount(),
+		LastCompaction: db.GCStats().LastRun,
+		Workers:        db.workerNames(),
+	}
+	db.mu.RLock()
+	r.LastBackup = db.lastBackup
+	db.mu.RUnlock()
+	s := db.engine().Stats()
+	r.FreePages, r.PendingPages = s.FreePageN, s.PendingPageN
+	if !r.ReadOnly {
+		tx := db.NewTx(true)
+		err := tx.Commit()
+		tx.Rollback()
+		if err != nil {
+			r.Error = err.Error()
+		}
+		r.Writable = err == nil
+	}
+	r.Healthy = (r.Writable || r.ReadOnly) && !r.ShuttingDown
+	return r
+}
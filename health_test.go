@@ -0,0 +1,36 @@
+package zbolt
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDB_Health(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "health.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.StartGC(time.Hour, 0)
+	tx := d.NewTx(false)
+	if _, err := tx.WriteTo(ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+	r := d.Health()
+	if !r.Healthy || !r.Writable || r.OpenTx != 0 || r.LastBackup.IsZero() || len(r.Workers) != 1 || r.Workers[0] != "gc" {
+		t.Fatal("unexpected health", r)
+	}
+
+	tx = d.NewTx(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	d.Shutdown(ctx)
+	if r := d.Health(); !r.Healthy || r.ShuttingDown || r.OpenTx != 1 || len(r.Workers) != 1 {
+		t.Fatal("unexpected health after timed out shutdown", r)
+	}
+	tx.Rollback()
+	d.Close()
+}
@@ -0,0 +1,319 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+)
+
+var (
+	_indexPrefix   = []byte{26}
+	_indexMetaKey  = []byte("index:")
+	_indexReadyTag = []byte("ready")
+)
+
+// IndexSpec secondary index of bucket, maps index values extracted from records to their keys
+type IndexSpec struct {
+	Bucket []byte
+	Name   string
+	// Extract index values of record, nil if record is not indexed
+	Extract func(k, v []byte) [][]byte
+	// Batch records backfilled per transaction by BuildIndexOnline, 0 means 1000
+	Batch int
+	// Unique reject writes with ErrDuplicate when another key has the same index value
+	Unique bool
+}
+
+// index registered secondary index, ready once backfilled
+type index struct {
+	IndexSpec
+	ready int32
+	model bool // registered by a model, backfilled by a writable tx using it
+}
+
+// indexBucketName bucket of index entries
+func indexBucketName(bucket []byte, name string) []byte {
+	return shadowName(_indexPrefix, BytesConcat(escapeKey(bucket), []byte(name)))
+}
+
+// indexMetaKey key of index state in meta bucket
+func indexMetaKey(bucket []byte, name string) []byte {
+	return BytesConcat(_indexMetaKey, escapeKey(bucket), []byte(name))
+}
+
+// indexEntry key of index entry, like [escaped index value, collated key], so keys of equal index values
+// keep the order of keys in bucket
+func indexEntry(c Collation, value, key []byte) []byte {
+	return BytesConcat(escapeKey(value), c.member(key))
+}
+
+// splitIndexEntry split key of index entry into index value and key
+func splitIndexEntry(c Collation, k []byte) (value, key []byte, ok bool) {
+	value, rest, ok := unescapeKey(k)
+	if !ok {
+		return nil, nil, false
+	}
+	return value, c.memberKey(rest), true
+}
+
+// BuildIndexOnline register index and backfill it from existing records in small transactions,
+// writes made meanwhile maintain the index, so they are never blocked for the whole backfill.
+// The index is flipped to ready when backfill is done, an index already built in file is ready at once
+func (db *DB) BuildIndexOnline(spec IndexSpec) error {
+	idx := &index{IndexSpec: spec}
+	db.addIndex(idx)
+	var ready bool
+	if err := db.View(func(tx *Tx) error {
+		b := tx.tx.Bucket(_metaBucket)
+		ready = b != nil && bytes.Equal(b.Get(indexMetaKey(spec.Bucket, spec.Name)), _indexReadyTag)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !ready {
+		if err := db.backfillIndex(idx); err != nil {
+			db.dropIndex(idx)
+			return err
+		}
+	}
+	atomic.StoreInt32(&idx.ready, 1)
+	return nil
+}
+
+// backfillIndex add entries of existing records to index and mark it ready in file
+func (db *DB) backfillIndex(idx *index) error {
+	batch := idx.Batch
+	if batch <= 0 {
+		batch = 1000
+	}
+	var from []byte
+	for {
+		var next []byte
+		err := db.Update(func(tx *Tx) error {
+			b := tx.tx.Bucket(idx.Bucket)
+			if b == nil {
+				return nil
+			}
+			r := tx.reader(idx.Bucket)
+			c := b.Cursor()
+			var k, v []byte
+			if from == nil {
+				k, v = c.First()
+			} else {
+				k, v = c.Seek(from)
+			}
+			for n := 0; k != nil; k, v = c.Next() {
+				if n >= batch {
+					next = append([]byte{}, k...)
+					break
+				}
+				if err := tx.putIndex(idx, k, nil, r.value(k, v)); err != nil {
+					return err
+				}
+				n++
+			}
+			return tx.err
+		})
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			break
+		}
+		from = next
+	}
+	return db.Update(func(tx *Tx) error {
+		return tx.putMeta(indexMetaKey(idx.Bucket, idx.Name), _indexReadyTag)
+	})
+}
+
+// AddIndex register index of bucket maintained on every write, extract return index values of a record,
+// existing records are indexed before it return
+func (db *DB) AddIndex(bucket []byte, name string, extract func(k, v []byte) [][]byte) error {
+	return db.BuildIndexOnline(IndexSpec{Bucket: bucket, Name: name, Extract: extract})
+}
+
+// AddUniqueIndex like AddIndex, writes giving a record the index value of another key fail with ErrDuplicate
+func (db *DB) AddUniqueIndex(bucket []byte, name string, extract func(k, v []byte) [][]byte) error {
+	return db.BuildIndexOnline(IndexSpec{Bucket: bucket, Name: name, Extract: extract, Unique: true})
+}
+
+// GetByIndex get key values of bucket whose index value equal value, like [key1,value1,key2,value2, ...] ordered by key.
+// Return empty and set ErrIndexNotReady if index is not registered or still building
+func (tx *Tx) GetByIndex(bucket []byte, name string, value []byte) [][]byte {
+	if tx.err != nil {
+		return [][]byte{}
+	}
+	if !tx.db.IndexReady(bucket, name) {
+		tx.Error(fmt.Errorf("%w: %s of %q", ErrIndexNotReady, name, bucket))
+		return [][]byte{}
+	}
+	return tx.Get(bucket, tx.indexKeys(bucket, name, value)...)
+}
+
+// addIndex register index on its bucket, replace index of the same name
+func (db *DB) addIndex(idx *index) {
+	db.setConfig(idx.Bucket, func(c *bucketConfig) {
+		indexes := make([]*index, 0, len(c.indexes)+1)
+		for _, i := range c.indexes {
+			if i.Name != idx.Name {
+				indexes = append(indexes, i)
+			}
+		}
+		c.indexes = append(indexes, idx)
+	})
+}
+
+// dropIndex unregister index which failed to build and delete its entries
+func (db *DB) dropIndex(idx *index) {
+	db.setConfig(idx.Bucket, func(c *bucketConfig) {
+		indexes := make([]*index, 0, len(c.indexes))
+		for _, i := range c.indexes {
+			if i != idx {
+				indexes = append(indexes, i)
+			}
+		}
+		c.indexes = indexes
+	})
+	db.Update(func(tx *Tx) error {
+		if tx.tx.Bucket(indexBucketName(idx.Bucket, idx.Name)) == nil {
+			return nil
+		}
+		return tx.tx.DeleteBucket(indexBucketName(idx.Bucket, idx.Name))
+	})
+}
+
+// IndexReady report whether index of bucket is registered and backfilled
+func (db *DB) IndexReady(bucket []byte, name string) bool {
+	idx := db.index(bucket, name)
+	return idx != nil && atomic.LoadInt32(&idx.ready) == 1
+}
+
+// index get registered index of bucket, nil if not registered
+func (db *DB) index(bucket []byte, name string) *index {
+	for _, idx := range db.config(bucket).indexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	return nil
+}
+
+// updateIndexes replace index entries of key from old value to new value, nil value means not exist
+func (tx *Tx) updateIndexes(name []byte, indexes []*index, key, old, new []byte) error {
+	for _, idx := range indexes {
+		if err := tx.putIndex(idx, key, old, new); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putIndex replace entries of key in index from old value to new value
+func (tx *Tx) putIndex(idx *index, key, old, new []byte) error {
+	var olds, news [][]byte
+	if old != nil {
+		olds = idx.Extract(key, old)
+	}
+	if new != nil {
+		news = idx.Extract(key, new)
+	}
+	if len(olds) == 0 && len(news) == 0 {
+		return nil
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(indexBucketName(idx.Bucket, idx.Name))
+	if err != nil {
+		return err
+	}
+	c := tx.collation(idx.Bucket)
+	for _, v := range olds {
+		if !containsBytes(news, v) {
+			if err := b.Delete(indexEntry(c, v, key)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, v := range news {
+		if idx.Unique && !containsBytes(olds, v) && indexTaken(b, v, c.member(key)) {
+			return ErrDuplicate
+		}
+		if err := b.Put(indexEntry(c, v, key), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexTaken check index has an entry of value for a member other than member
+func indexTaken(b backendBucket, value, member []byte) bool {
+	prefix := escapeKey(value)
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if !bytes.Equal(k[len(prefix):], member) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexKeys get keys of records whose index value equal value
+func (tx *Tx) indexKeys(bucket []byte, name string, value []byte) [][]byte {
+	return tx.indexRange(bucket, name, value, value, 0)
+}
+
+// indexRange get keys of records whose index value is in [lo, hi] ordered by index value,
+// nil lo or hi means unbounded, limit = 0 representative of all
+func (tx *Tx) indexRange(bucket []byte, name string, lo, hi []byte, limit int) [][]byte {
+	keys := [][]byte{}
+	b := tx.tx.Bucket(indexBucketName(bucket, name))
+	if b == nil {
+		return keys
+	}
+	coll := tx.collation(bucket)
+	c := b.Cursor()
+	var k []byte
+	if lo == nil {
+		k, _ = c.First()
+	} else {
+		k, _ = c.Seek(escapeKey(lo))
+	}
+	for ; k != nil; k, _ = c.Next() {
+		value, key, ok := splitIndexEntry(coll, k)
+		if !ok {
+			continue
+		}
+		if hi != nil && bytes.Compare(value, hi) > 0 {
+			break
+		}
+		keys = append(keys, append([]byte{}, key...))
+		if limit > 0 && len(keys) >= limit {
+			break
+		}
+	}
+	return keys
+}
+
+// deleteIndexes delete index buckets of bucket
+func (tx *Tx) deleteIndexes(name []byte) error {
+	if tx.db == nil {
+		return nil
+	}
+	for _, idx := range tx.db.config(name).indexes {
+		if tx.tx.Bucket(indexBucketName(name, idx.Name)) != nil {
+			if err := tx.tx.DeleteBucket(indexBucketName(name, idx.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// containsBytes check bs contains b
+func containsBytes(bs [][]byte, b []byte) bool {
+	for _, x := range bs {
+		if bytes.Equal(x, b) {
+			return true
+		}
+	}
+	return false
+}
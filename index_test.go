@@ -0,0 +1,158 @@
+package zbolt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// emailIndex index users of values like "name:email" by email
+var emailIndex = func(k, v []byte) [][]byte {
+	if i := bytes.IndexByte(v, ':'); i >= 0 {
+		return [][]byte{v[i+1:]}
+	}
+	return nil
+}
+
+func TestDB_BuildIndexOnline(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	users := []byte("users")
+	d.Update(func(tx *Tx) error {
+		for i := 0; i < 50; i++ {
+			tx.Put(users, []byte(fmt.Sprintf("u%02d", i)), []byte(fmt.Sprintf("user%d:%d@example.com", i, i%10)))
+		}
+		return nil
+	})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { // live writes during backfill
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			d.Update(func(tx *Tx) error {
+				tx.Put(users, []byte(fmt.Sprintf("u%02d", i)), []byte(fmt.Sprintf("user%d:new@example.com", i)))
+				return tx.Delete(users, []byte(fmt.Sprintf("u%02d", 49-i)))
+			})
+		}
+	}()
+	if err := d.BuildIndexOnline(IndexSpec{Bucket: users, Name: "email", Extract: emailIndex, Batch: 3}); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if !d.IndexReady(users, "email") {
+		t.Fatal("index not ready")
+	}
+	d.View(func(tx *Tx) error {
+		if keys := tx.indexKeys(users, "email", []byte("new@example.com")); len(keys) != 20 {
+			t.Errorf("unexpected keys of new email %q", keys)
+		}
+		// entries match records exactly
+		n := 0
+		tx.ForEach(users, func(k, v []byte) error {
+			if !containsBytes(tx.indexKeys(users, "email", emailIndex(k, v)[0]), k) {
+				t.Errorf("missing entry of %s", k)
+			}
+			n++
+			return nil
+		})
+		entries := 0
+		tx.ForEach(indexBucketName(users, "email"), func(k, v []byte) error {
+			entries++
+			return nil
+		})
+		if entries != n || n != 30 {
+			t.Errorf("%d entries for %d records", entries, n)
+		}
+		return nil
+	})
+	// index built in file is ready without backfill
+	if err := d.BuildIndexOnline(IndexSpec{Bucket: users, Name: "email", Extract: func(k, v []byte) [][]byte {
+		t.Fatal("backfill of ready index")
+		return nil
+	}}); err != nil || !d.IndexReady(users, "email") {
+		t.Fatal(err)
+	}
+}
+
+func TestTx_GetByIndex(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	users := []byte("users")
+	d.Update(func(tx *Tx) error {
+		return tx.Put(users, []byte("u1"), []byte("alice:a@x"), []byte("u2"), []byte("bob:b@x"))
+	})
+	d.View(func(tx *Tx) error {
+		if tx.GetByIndex(users, "email", []byte("a@x")); !errors.Is(tx.Error(), ErrIndexNotReady) {
+			t.Error("expect ErrIndexNotReady, got", tx.Error())
+		}
+		return nil
+	})
+	if err := d.AddIndex(users, "email", emailIndex); err != nil {
+		t.Fatal(err)
+	}
+	d.Update(func(tx *Tx) error {
+		tx.Put(users, []byte("u3"), []byte("carol:a@x"), []byte("u2"), []byte("bob:c@x"))
+		if gets := tx.GetByIndex(users, "email", []byte("a@x")); len(gets) != 4 || string(gets[2]) != "u3" {
+			t.Errorf("unexpected get %q", gets)
+		}
+		if gets := tx.GetByIndex(users, "email", []byte("b@x")); len(gets) != 0 {
+			t.Errorf("stale entry %q", gets)
+		}
+		tx.Delete(users, []byte("u1"))
+		if gets := tx.GetByIndex(users, "email", []byte("a@x")); len(gets) != 2 {
+			t.Errorf("deleted record still indexed %q", gets)
+		}
+		return nil
+	})
+}
+
+func TestDB_AddUniqueIndex(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	users := []byte("users")
+	d.Update(func(tx *Tx) error {
+		return tx.Put(users, []byte("u1"), []byte("alice:a@x"), []byte("u2"), []byte("bob:a@x"))
+	})
+	if err := d.AddUniqueIndex(users, "email", emailIndex); err != ErrDuplicate {
+		t.Fatal("expect ErrDuplicate of existing records, got", err)
+	}
+	if d.index(users, "email") != nil {
+		t.Fatal("failed index still registered")
+	}
+	d.Update(func(tx *Tx) error {
+		return tx.Delete(users, []byte("u2"))
+	})
+	if err := d.AddUniqueIndex(users, "email", emailIndex); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(users, []byte("u3"), []byte("carol:a@x"))
+	}); err != ErrDuplicate {
+		t.Fatal("expect ErrDuplicate, got", err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		// rewriting the owner and moving a value between keys are allowed
+		tx.Put(users, []byte("u1"), []byte("alice2:a@x"))
+		tx.Put(users, []byte("u1"), []byte("alice:b@x"))
+		return tx.Put(users, []byte("u3"), []byte("carol:a@x"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	d.View(func(tx *Tx) error {
+		if gets := tx.GetByIndex(users, "email", []byte("a@x")); len(gets) != 2 || string(gets[0]) != "u3" {
+			t.Errorf("unexpected get %q", gets)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package zbolt
+
+// Iterator cursor over keys of a bucket in order, values are read like Get.
+// It is invalid when positioned past either end, when bucket does not exist or tx has an error
+type Iterator struct {
+	tx *Tx
+	c  backendCursor
+	r  *reader
+	k  []byte
+	v  []byte
+}
+
+// Cursor get iterator of bucket, position it with First, Last or Seek before use
+func (tx *Tx) Cursor(name []byte) *Iterator {
+	it := &Iterator{tx: tx}
+	if tx.err != nil {
+		return it
+	}
+	if b := tx.tx.Bucket(name); b != nil {
+		it.c, it.r = b.Cursor(), tx.reader(name)
+	}
+	return it
+}
+
+// First move to first key
+func (it *Iterator) First() bool {
+	return it.move(func() ([]byte, []byte) { return it.c.First() })
+}
+
+// Last move to last key
+func (it *Iterator) Last() bool {
+	return it.move(func() ([]byte, []byte) { return it.c.Last() })
+}
+
+// Seek move to first key >= key
+func (it *Iterator) Seek(key []byte) bool {
+	return it.move(func() ([]byte, []byte) { return it.c.Seek(key) })
+}
+
+// Next move to next key
+func (it *Iterator) Next() bool {
+	if !it.Valid() {
+		return false
+	}
+	return it.move(func() ([]byte, []byte) { return it.c.Next() })
+}
+
+// Prev move to previous key
+func (it *Iterator) Prev() bool {
+	if !it.Valid() {
+		return false
+	}
+	return it.move(func() ([]byte, []byte) { return it.c.Prev() })
+}
+
+// Valid check iterator is positioned on a key and tx has no error
+func (it *Iterator) Valid() bool {
+	return it.k != nil && it.tx.err == nil
+}
+
+// Key get current key, nil if not valid
+func (it *Iterator) Key() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	return it.k
+}
+
+// Value get current value, nil if not valid
+func (it *Iterator) Value() []byte {
+	if !it.Valid() {
+		return nil
+	}
+	return it.r.value(it.k, it.v)
+}
+
+// Err get error of tx stopping iteration
+func (it *Iterator) Err() error {
+	return it.tx.err
+}
+
+// move position cursor by fn unless tx failed or was canceled, report whether it is valid
+func (it *Iterator) move(fn func() ([]byte, []byte)) bool {
+	it.k, it.v = nil, nil
+	if it.c == nil || it.tx.err != nil || it.tx.canceled() {
+		return false
+	}
+	it.k, it.v = fn()
+	return it.Valid()
+}
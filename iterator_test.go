@@ -0,0 +1,42 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_Cursor(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("iter")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b"), []byte("2"), []byte("c"), []byte("3"))
+	it := tx.Cursor(name)
+	var s string
+	for ok := it.First(); ok; ok = it.Next() {
+		s += string(it.Key()) + string(it.Value())
+	}
+	if s != "a1b2c3" {
+		t.Fatal("unexpected forward iteration", s)
+	}
+	s = ""
+	for ok := it.Seek([]byte("bb")); ok; ok = it.Prev() {
+		s += string(it.Key())
+	}
+	if s != "cba" {
+		t.Fatal("unexpected backward iteration", s)
+	}
+	if it.Last(); string(it.Key()) != "c" {
+		t.Fatal("unexpected last", string(it.Key()))
+	}
+	tx.Error(ErrRecordNotFound)
+	if it.Valid() || it.First() || it.Err() != ErrRecordNotFound {
+		t.Fatal("iterator must stop on tx error")
+	}
+	if tx.Error(ErrNil); tx.Cursor([]byte("iter_missing")).First() {
+		t.Fatal("iterator of missing bucket must be invalid")
+	}
+}
@@ -0,0 +1,16 @@
+package zbolt
+
+// PutJSON put v encoded with encoding/json as value of key, whatever codec bucket has
+func (tx *Tx) PutJSON(name, key []byte, v interface{}) error {
+	return tx.putObject(name, key, v, CodecJSON)
+}
+
+// GetJSON decode value of key into out with encoding/json, return ErrRecordNotFound if key not exist
+func (tx *Tx) GetJSON(name, key []byte, out interface{}) error {
+	return tx.getObject(name, key, out, CodecJSON)
+}
+
+// GetJSONs decode values of keys into slice pointed by out, like *[]User, with encoding/json, keys not exist are skipped
+func (tx *Tx) GetJSONs(name []byte, keys [][]byte, out interface{}) error {
+	return tx.getObjects(name, keys, out, CodecJSON)
+}
@@ -0,0 +1,49 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_PutJSON(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	name := []byte("json_users")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.PutJSON(name, []byte("u1"), user{"alice", 30}); err != nil {
+		t.Fatal(err)
+	}
+	tx.PutJSON(name, []byte("u2"), &user{"bob", 40})
+	var u user
+	if err := tx.GetJSON(name, []byte("u2"), &u); err != nil || u.Name != "bob" || u.Age != 40 {
+		t.Fatal("unexpected get", u, err)
+	}
+	if err := tx.GetJSON(name, []byte("u9"), &u); err != ErrRecordNotFound {
+		t.Fatal("expect ErrRecordNotFound, got", err)
+	}
+	var users []user
+	if err := tx.GetJSONs(name, [][]byte{[]byte("u1"), []byte("u9"), []byte("u2")}, &users); err != nil || len(users) != 2 || users[0].Name != "alice" {
+		t.Fatal("unexpected multi get", users, err)
+	}
+	if err := tx.PutJSON(name, []byte("u3"), func() {}); err == nil {
+		t.Fatal("expect marshal error")
+	}
+	tx.Error(ErrNil)
+	// encoding/json is used whatever codec bucket has
+	gobs := []byte("json_gob_users")
+	db.SetBucketCodec(gobs, CodecGob)
+	defer db.SetBucketCodec(gobs, nil)
+	tx.PutJSON(gobs, []byte("u1"), &user{"carol", 50})
+	if gets := tx.Get(gobs, []byte("u1")); len(gets) != 2 || string(gets[1]) != `{"Name":"carol","Age":50}` {
+		t.Fatal("value not encoded as JSON", gets)
+	}
+	if err := tx.GetJSON(gobs, []byte("u1"), &u); err != nil || u.Name != "carol" {
+		t.Fatal("unexpected get from bucket with codec", u, err)
+	}
+	users = nil
+	if err := tx.GetJSONs(gobs, [][]byte{[]byte("u1")}, &users); err != nil || len(users) != 1 || users[0].Age != 50 {
+		t.Fatal("unexpected multi get from bucket with codec", users, err)
+	}
+}
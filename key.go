@@ -0,0 +1,114 @@
+package zbolt
+
+import (
+	"time"
+)
+
+// Key builder of order preserving composite keys, integers are fixed width,
+// strings and bytes are escaped and terminated so a part never runs into the next one
+type Key struct {
+	b []byte
+}
+
+// NewKey create empty composite key
+func NewKey() *Key {
+	return &Key{}
+}
+
+// Uint64 append v as 8 bytes big endian
+func (k *Key) Uint64(v uint64) *Key {
+	k.b = append(k.b, Uint64ToBytes(v)...)
+	return k
+}
+
+// Int64 append v as 8 bytes big endian with sign bit flipped, negative numbers sort first
+func (k *Key) Int64(v int64) *Key {
+	return k.Uint64(uint64(v) ^ 1<<63)
+}
+
+// Time append t as TimeToBytes
+func (k *Key) Time(t time.Time) *Key {
+	k.b = append(k.b, TimeToBytes(t)...)
+	return k
+}
+
+// String append escaped s
+func (k *Key) String(s string) *Key {
+	return k.Bytes([]byte(s))
+}
+
+// Bytes append escaped b
+func (k *Key) Bytes(b []byte) *Key {
+	k.b = append(k.b, escapeKey(b)...)
+	return k
+}
+
+// Build get bytes of key
+func (k *Key) Build() []byte {
+	return append([]byte{}, k.b...)
+}
+
+// KeyDecoder read parts of composite key in the order they were appended
+type KeyDecoder struct {
+	b   []byte
+	err error
+}
+
+// DecodeKey create decoder of composite key b
+func DecodeKey(b []byte) *KeyDecoder {
+	return &KeyDecoder{b: b}
+}
+
+// ReadUint64 read 8 bytes big endian
+func (d *KeyDecoder) ReadUint64() uint64 {
+	if d.err != nil || len(d.b) < 8 {
+		d.err = ErrKeyDecode
+		return 0
+	}
+	v := BytesToUint64(d.b[:8])
+	d.b = d.b[8:]
+	return v
+}
+
+// ReadInt64 read int64 appended by Key.Int64
+func (d *KeyDecoder) ReadInt64() int64 {
+	return int64(d.ReadUint64() ^ 1<<63)
+}
+
+// ReadTime read time appended by Key.Time
+func (d *KeyDecoder) ReadTime() time.Time {
+	if d.err != nil || len(d.b) < 8 {
+		d.err = ErrKeyDecode
+		return time.Time{}
+	}
+	t := BytesToTime(d.b[:8])
+	d.b = d.b[8:]
+	return t
+}
+
+// ReadString read string appended by Key.String
+func (d *KeyDecoder) ReadString() string {
+	return string(d.ReadBytes())
+}
+
+// ReadBytes read bytes appended by Key.Bytes
+func (d *KeyDecoder) ReadBytes() []byte {
+	if d.err != nil {
+		return nil
+	}
+	b, rest, ok := unescapeKey(d.b)
+	if !ok {
+		d.err = ErrKeyDecode
+		return nil
+	}
+	d.b = rest
+	return b
+}
+
+// Err get error of reads, not nil if key is shorter than the parts read or has bytes left
+func (d *KeyDecoder) Err() error {
+	if d.err == nil && len(d.b) != 0 {
+		return ErrKeyDecode
+	}
+	return d.err
+}
@@ -0,0 +1,44 @@
+package zbolt
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	at := time.Unix(1600000000, 0)
+	keys := [][]byte{
+		NewKey().Time(at).String("bob").Bytes([]byte{1}).Build(),
+		NewKey().Time(at).String("al").Bytes([]byte{2}).Build(),
+		NewKey().Time(at).String("al\x00x").Bytes([]byte{0}).Build(),
+		NewKey().Time(at.Add(-time.Second)).String("zed").Bytes(nil).Build(),
+		NewKey().Time(at).String("alice").Bytes([]byte{0}).Build(),
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	var users []string
+	for _, k := range keys {
+		d := DecodeKey(k)
+		d.ReadTime()
+		users = append(users, d.ReadString())
+		d.ReadBytes()
+		if err := d.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// variable length parts do not run into the next part
+	if want := []string{"zed", "al", "al\x00x", "alice", "bob"}; len(users) != len(want) || users[0] != want[0] || users[1] != want[1] || users[2] != want[2] || users[3] != want[3] {
+		t.Fatalf("unexpected order %q", users)
+	}
+	d := DecodeKey(NewKey().Int64(-5).Uint64(7).Build())
+	if d.ReadInt64() != -5 || d.ReadUint64() != 7 || d.Err() != nil {
+		t.Fatal("unexpected decode")
+	}
+	if bytes.Compare(NewKey().Int64(-5).Build(), NewKey().Int64(3).Build()) >= 0 {
+		t.Fatal("negative int not sorted first")
+	}
+	if d := DecodeKey([]byte{1, 2}); d.ReadUint64() != 0 || d.Err() == nil {
+		t.Fatal("expect decode error")
+	}
+}
@@ -0,0 +1,189 @@
+package zbolt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// keyCodec translate original keys of a bucket to stored keys and back, stored keys must keep the wanted order
+type keyCodec interface {
+	encode(key []byte) []byte
+	decode(k []byte) []byte
+}
+
+// keyCodecs codecs applied in order on encode and in reverse order on decode
+type keyCodecs []keyCodec
+
+func (cs keyCodecs) encode(key []byte) []byte {
+	for _, c := range cs {
+		key = c.encode(key)
+	}
+	return key
+}
+
+func (cs keyCodecs) decode(k []byte) []byte {
+	for i := len(cs) - 1; i >= 0; i-- {
+		k = cs[i].decode(k)
+	}
+	return k
+}
+
+// keyCodec codec of stored keys of bucket, nil if keys are stored as is
+func (c bucketConfig) keyCodec() keyCodec {
+	var cs keyCodecs
+	if c.prefix != nil {
+		cs = append(cs, c.prefix)
+	}
+	if c.collation != 0 {
+		cs = append(cs, c.collation)
+	}
+	if c.transform != nil {
+		cs = append(cs, c.transform)
+	}
+	switch len(cs) {
+	case 0:
+		return nil
+	case 1:
+		return cs[0]
+	}
+	return cs
+}
+
+// KeyTransform translate original keys of a bucket to stored keys and back, bolt order stored keys bytewise
+// so Encode decide the order of keys, like case folding or little endian integers. Decode(Encode(key)) must be key
+type KeyTransform struct {
+	Encode func(key []byte) []byte
+	Decode func(k []byte) []byte
+}
+
+// SetKeyTransform store keys of bucket encoded by t, every API still take and return original keys.
+// Transform must be set before the bucket is written, nil t restores stored keys as is
+func (db *DB) SetKeyTransform(name []byte, t *KeyTransform) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.transform = t
+	})
+	if t != nil {
+		atomic.StoreInt32(&db.wrapped, 1)
+	}
+}
+
+func (t *KeyTransform) encode(key []byte) []byte {
+	return t.Encode(key)
+}
+
+func (t *KeyTransform) decode(k []byte) []byte {
+	return t.Decode(k)
+}
+
+// keyTx transaction translating keys of buckets with key codec and hiding expired keys
+type keyTx struct {
+	backendTx
+	db *DB
+}
+
+// keyBucket bucket with key codec, take and return original keys
+type keyBucket struct {
+	backendBucket
+	c keyCodec
+}
+
+// keyCursor cursor of keyBucket
+type keyCursor struct {
+	backendCursor
+	c keyCodec
+}
+
+// wrapTx wrap buckets with key codec or expiring keys if any is set
+func (db *DB) wrapTx(tx backendTx) backendTx {
+	if atomic.LoadInt32(&db.wrapped) == 0 {
+		return tx
+	}
+	return keyTx{tx, db}
+}
+
+// wrap bucket with key codec and expiry of its name
+func (tx keyTx) wrap(name []byte, b backendBucket) backendBucket {
+	if b == nil {
+		return nil
+	}
+	c := tx.db.config(name)
+	if kc := c.keyCodec(); kc != nil {
+		b = keyBucket{b, kc}
+	}
+	if c.ttl {
+		if e := tx.backendTx.Bucket(expiryBucketName(name)); e != nil {
+			b = ttlBucket{b, e, time.Now()}
+		}
+	}
+	return b
+}
+
+func (tx keyTx) Bucket(name []byte) backendBucket {
+	return tx.wrap(name, tx.backendTx.Bucket(name))
+}
+
+func (tx keyTx) CreateBucketIfNotExists(name []byte) (backendBucket, error) {
+	b, err := tx.backendTx.CreateBucketIfNotExists(name)
+	return tx.wrap(name, b), err
+}
+
+func (tx keyTx) ForEach(fn func(name []byte, b backendBucket) error) error {
+	return tx.backendTx.ForEach(func(name []byte, b backendBucket) error {
+		return fn(name, tx.wrap(name, b))
+	})
+}
+
+func (b keyBucket) Get(key []byte) []byte {
+	return b.backendBucket.Get(b.c.encode(key))
+}
+
+func (b keyBucket) Put(key, value []byte) error {
+	return b.backendBucket.Put(b.c.encode(key), value)
+}
+
+func (b keyBucket) Delete(key []byte) error {
+	return b.backendBucket.Delete(b.c.encode(key))
+}
+
+func (b keyBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.backendBucket.ForEach(func(k, v []byte) error {
+		return fn(b.c.decode(k), v)
+	})
+}
+
+func (b keyBucket) Cursor() backendCursor {
+	return keyCursor{b.backendBucket.Cursor(), b.c}
+}
+
+func (c keyCursor) First() ([]byte, []byte) {
+	k, v := c.backendCursor.First()
+	return c.decode(k), v
+}
+
+func (c keyCursor) Last() ([]byte, []byte) {
+	k, v := c.backendCursor.Last()
+	return c.decode(k), v
+}
+
+func (c keyCursor) Next() ([]byte, []byte) {
+	k, v := c.backendCursor.Next()
+	return c.decode(k), v
+}
+
+func (c keyCursor) Prev() ([]byte, []byte) {
+	k, v := c.backendCursor.Prev()
+	return c.decode(k), v
+}
+
+func (c keyCursor) Seek(seek []byte) ([]byte, []byte) {
+	k, v := c.backendCursor.Seek(c.c.encode(seek))
+	return c.decode(k), v
+}
+
+// decode original key of stored key, nil at the end of bucket
+func (c keyCursor) decode(k []byte) []byte {
+	if k == nil {
+		return nil
+	}
+	return c.c.decode(k)
+}
@@ -0,0 +1,45 @@
+package zbolt
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestDB_SetKeyTransform(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("little_endian")
+	reverse := func(b []byte) []byte {
+		r := make([]byte, len(b))
+		for i := range b {
+			r[len(b)-1-i] = b[i]
+		}
+		return r
+	}
+	d.SetKeyTransform(name, &KeyTransform{Encode: reverse, Decode: reverse})
+	key := func(n uint32) []byte {
+		return binary.LittleEndian.AppendUint32(nil, n)
+	}
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for _, n := range []uint32{256, 1, 2} {
+		tx.Put(name, key(n), []byte("v"))
+	}
+	var got []uint32
+	tx.ForEach(name, func(k, v []byte) error {
+		got = append(got, binary.LittleEndian.Uint32(k))
+		return nil
+	})
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 256 {
+		t.Fatal("unexpected order", got)
+	}
+	if next := tx.Next(name, key(2), 0); len(next) != 2 || binary.LittleEndian.Uint32(next[0]) != 256 {
+		t.Fatal("unexpected next", next)
+	}
+	if gets := tx.Get(name, key(256)); len(gets) != 2 {
+		t.Fatal("get by original key fail")
+	}
+}
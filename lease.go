@@ -0,0 +1,176 @@
+package zbolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultLeaseTTL lease ttl when TryOpenPrimary get ttl <= 0
+const defaultLeaseTTL = 10 * time.Second
+
+// LeaseInfo content of lease file path.lock, written by the primary process and renewed every ttl/3
+type LeaseInfo struct {
+	PID       int
+	Host      string
+	Acquired  time.Time
+	Heartbeat time.Time
+	TTL       time.Duration
+}
+
+// Alive lease was renewed within its ttl
+func (l *LeaseInfo) Alive() bool {
+	return time.Since(l.Heartbeat) < l.TTL
+}
+
+// lease heartbeat of lease file held by DB
+type lease struct {
+	path string
+	mu   sync.Mutex
+	info LeaseInfo
+	stop chan struct{}
+	done chan struct{}
+}
+
+// leasePath path of lease file of database at path
+func leasePath(path string) string {
+	return path + ".lock"
+}
+
+// ReadLease read lease file of database at path, nil if no process holds the lease
+func ReadLease(path string) (*LeaseInfo, error) {
+	b, err := ioutil.ReadFile(leasePath(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var l LeaseInfo
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// heldBy error of live lease held by another process
+func heldBy(l *LeaseInfo) error {
+	return fmt.Errorf("%w: pid %d on %s, last heartbeat %s", ErrLeaseHeld, l.PID, l.Host, l.Heartbeat.Format(time.RFC3339))
+}
+
+// PingPrimary check the primary process of database at path renewed its lease within ttl,
+// return ErrRecordNotFound if no lease exists
+func PingPrimary(path string) (*LeaseInfo, error) {
+	l, err := ReadLease(path)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || !l.Alive() {
+		return l, ErrRecordNotFound
+	}
+	return l, nil
+}
+
+// TryOpenPrimary open database at path for writing without waiting for the file lock,
+// take the lease file path.lock and renew it every ttl/3 until Close.
+// Return ErrLeaseHeld if another process holds a live lease or the file lock
+func TryOpenPrimary(path string, ttl time.Duration) (*DB, error) {
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	if l, err := ReadLease(path); err != nil {
+		return nil, err
+	} else if l != nil && l.Alive() {
+		return nil, heldBy(l)
+	}
+	db, err := OpenWithOptions(path, &Options{Timeout: 100 * time.Millisecond})
+	if err == ErrTimeout {
+		return nil, fmt.Errorf("%w: file locked", ErrLeaseHeld)
+	}
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	now := time.Now()
+	db.lease = &lease{
+		path: leasePath(path),
+		info: LeaseInfo{PID: os.Getpid(), Host: host, Acquired: now, Heartbeat: now, TTL: ttl},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if err := db.lease.write(); err != nil {
+		db.engine().Close()
+		return nil, err
+	}
+	go db.lease.heartbeat()
+	return db, nil
+}
+
+// OpenReplicaReadOnly open database at path read only.
+// Bolt file lock does not allow readers while a primary is open, so return ErrLeaseHeld at once if a live lease exists
+func OpenReplicaReadOnly(path string) (*DB, error) {
+	if l, err := ReadLease(path); err != nil {
+		return nil, err
+	} else if l != nil && l.Alive() {
+		return nil, heldBy(l)
+	}
+	db, err := OpenWithOptions(path, &Options{ReadOnly: true})
+	if err == ErrTimeout {
+		return nil, fmt.Errorf("%w: file locked", ErrLeaseHeld)
+	}
+	return db, err
+}
+
+// Lease get lease held by DB, nil if not opened with TryOpenPrimary
+func (db *DB) Lease() *LeaseInfo {
+	if db.lease == nil {
+		return nil
+	}
+	db.lease.mu.Lock()
+	defer db.lease.mu.Unlock()
+	info := db.lease.info
+	return &info
+}
+
+// write write lease file atomically
+func (l *lease) write() error {
+	l.mu.Lock()
+	b, err := json.Marshal(l.info)
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// heartbeat renew lease every ttl/3 until released
+func (l *lease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.info.TTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			l.info.Heartbeat = now
+			l.mu.Unlock()
+			l.write()
+		}
+	}
+}
+
+// release stop heartbeat and remove lease file
+func (l *lease) release() {
+	close(l.stop)
+	<-l.done
+	os.Remove(l.path)
+}
@@ -0,0 +1,51 @@
+package zbolt
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryOpenPrimary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.db")
+	primary, err := TryOpenPrimary(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := PingPrimary(path); err != nil || l.PID != primary.Lease().PID {
+		t.Fatal("primary not alive", l, err)
+	}
+	if _, err := TryOpenPrimary(path, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatal("expect ErrLeaseHeld, got", err)
+	}
+	if _, err := OpenReplicaReadOnly(path); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatal("expect ErrLeaseHeld, got", err)
+	}
+	primary.Close()
+	if l, err := ReadLease(path); err != nil || l != nil {
+		t.Fatal("lease not released", l, err)
+	}
+
+	replica, err := OpenReplicaReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica.Close()
+
+	// stale lease of a crashed process is taken over
+	b, _ := json.Marshal(LeaseInfo{PID: 1, Host: "crashed", Heartbeat: time.Now().Add(-time.Hour), TTL: time.Second})
+	if err := ioutil.WriteFile(leasePath(path), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PingPrimary(path); err != ErrRecordNotFound {
+		t.Fatal("expect stale lease, got", err)
+	}
+	primary, err = TryOpenPrimary(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary.Close()
+}
@@ -0,0 +1,69 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MergeFunc merge operand into existing value of key, existing is nil if key not exist. Return the new value,
+// an error fails Merge
+type MergeFunc func(key, existing, operand []byte) ([]byte, error)
+
+// merge functions for SetMerge
+var (
+	// MergeAppend append operand to existing value
+	MergeAppend MergeFunc = func(key, existing, operand []byte) ([]byte, error) {
+		return BytesConcat(existing, operand), nil
+	}
+	// MergeMax keep the bytewise greater of existing value and operand
+	MergeMax MergeFunc = func(key, existing, operand []byte) ([]byte, error) {
+		if bytes.Compare(operand, existing) > 0 {
+			return operand, nil
+		}
+		return existing, nil
+	}
+	// MergeSum add operand to existing value, both 8 bytes big endian int64 like IncrBy, missing value is 0.
+	// Return ErrNotCounter if either is not 8 bytes
+	MergeSum MergeFunc = func(key, existing, operand []byte) ([]byte, error) {
+		if existing == nil {
+			existing = make([]byte, 8)
+		}
+		if len(existing) != 8 || len(operand) != 8 {
+			return nil, fmt.Errorf("%w: %q has %d bytes, operand %d bytes", ErrNotCounter, key, len(existing), len(operand))
+		}
+		return Uint64ToBytes(BytesToUint64(existing) + BytesToUint64(operand)), nil
+	}
+)
+
+// SetMerge merge operands of Merge into values of bucket with fn, nil fn disable Merge
+func (db *DB) SetMerge(name []byte, fn MergeFunc) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.merge = fn
+	})
+}
+
+// Merge merge operands in order into value of key in bucket by the function set with SetMerge,
+// return ErrNoMerge if none is set
+func (tx *Tx) Merge(name, key []byte, operands ...[]byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	var fn MergeFunc
+	if tx.db != nil {
+		fn = tx.db.config(name).merge
+	}
+	if fn == nil {
+		return tx.Error(fmt.Errorf("%w: %q", ErrNoMerge, name))
+	}
+	var v []byte
+	if gets := tx.Get(name, key); len(gets) == 2 {
+		v = append([]byte{}, gets[1]...)
+	}
+	for _, operand := range operands {
+		var err error
+		if v, err = fn(key, v, operand); err != nil {
+			return tx.Error(err)
+		}
+	}
+	return tx.Put(name, key, v)
+}
@@ -0,0 +1,55 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_Merge(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	log, totals := []byte("log"), []byte("totals")
+	d.SetMerge(log, MergeAppend)
+	d.SetMerge(totals, MergeSum)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.Merge(log, []byte("k"), []byte("a"), []byte("b"))
+	tx.Merge(log, []byte("k"), []byte("c"))
+	tx.Merge(totals, []byte("k"), Uint64ToBytes(2), Uint64ToBytes(3))
+	if err := tx.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if v := tx.Get(log, []byte("k")); string(v[1]) != "abc" {
+		t.Fatal("unexpected append merge", string(v[1]))
+	}
+	if n := tx.Counter(totals, []byte("k")); n != 5 {
+		t.Fatal("unexpected sum merge", n)
+	}
+	if err := tx.Merge([]byte("plain"), []byte("k"), []byte("x")); !errors.Is(err, ErrNoMerge) {
+		t.Fatal("expect ErrNoMerge", err)
+	}
+}
+
+func TestMergeSum(t *testing.T) {
+	for _, c := range [][2][]byte{{[]byte("short"), Uint64ToBytes(1)}, {Uint64ToBytes(1), []byte{1}}} {
+		if _, err := MergeSum([]byte("k"), c[0], c[1]); !errors.Is(err, ErrNotCounter) {
+			t.Fatal("expect ErrNotCounter", err)
+		}
+	}
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	totals := []byte("totals")
+	d.SetMerge(totals, MergeSum)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(totals, []byte("k"), []byte("abc"))
+	if err := tx.Merge(totals, []byte("k"), Uint64ToBytes(1)); !errors.Is(err, ErrNotCounter) {
+		t.Fatal("expect ErrNotCounter merging into short value", err)
+	}
+}
@@ -0,0 +1,292 @@
+package zbolt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// model struct mapped to bucket by zbolt tags
+type model struct {
+	bucket  []byte
+	key     []int // field index of key
+	indexes []modelIndex
+}
+
+// modelIndex field indexed by index name
+type modelIndex struct {
+	name  string
+	field []int
+}
+
+var (
+	_models    sync.Map // reflect.Type -> *model
+	_timeType  = reflect.TypeOf(time.Time{})
+	errNoModel = errors.New("zbolt: model must be a pointer to named struct with a zbolt:\"key\" field")
+)
+
+// modelOf parse zbolt tags of struct type t, like `zbolt:"key"` and `zbolt:"index:created_at"`.
+// Bucket of model is the package path and name of type, like github.com/me/app.User
+func modelOf(t reflect.Type) (*model, error) {
+	if m, ok := _models.Load(t); ok {
+		return m.(*model), nil
+	}
+	if t.Kind() != reflect.Struct || t.Name() == "" {
+		return nil, errNoModel
+	}
+	m := &model{bucket: []byte(t.PkgPath() + "." + t.Name())}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		for _, tag := range strings.Split(f.Tag.Get("zbolt"), ",") {
+			switch {
+			case tag == "key":
+				m.key = f.Index
+			case strings.HasPrefix(tag, "index:"):
+				m.indexes = append(m.indexes, modelIndex{name: strings.TrimPrefix(tag, "index:"), field: f.Index})
+			}
+		}
+	}
+	if m.key == nil {
+		return nil, errNoModel
+	}
+	_models.Store(t, m)
+	return m, nil
+}
+
+// RegisterModel register indexes of struct type pointed by v and backfill them from existing records like
+// BuildIndexOnline. Call it on start, otherwise indexes are built by the first writable tx using the model
+func (db *DB) RegisterModel(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errNoModel
+	}
+	t := rv.Elem().Type()
+	m, err := modelOf(t)
+	if err != nil {
+		return err
+	}
+	for _, mi := range m.indexes {
+		if db.index(m.bucket, mi.name) != nil {
+			continue
+		}
+		if err := db.BuildIndexOnline(IndexSpec{Bucket: m.bucket, Name: mi.name, Extract: db.modelExtract(t, m.bucket, mi.field)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// model get model of v, a pointer to struct, and register its indexes not registered by RegisterModel.
+// A writable tx backfill them, they are ready once it is committed
+func (tx *Tx) model(v interface{}) (*model, reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, rv, errNoModel
+	}
+	rv = rv.Elem()
+	m, err := modelOf(rv.Type())
+	if err != nil {
+		return nil, rv, err
+	}
+	for _, mi := range m.indexes {
+		idx := tx.db.index(m.bucket, mi.name)
+		if idx == nil {
+			idx = &index{IndexSpec: IndexSpec{Bucket: m.bucket, Name: mi.name, Extract: tx.db.modelExtract(rv.Type(), m.bucket, mi.field)}, model: true}
+			tx.db.addIndex(idx)
+		}
+		if err := tx.buildModelIndex(idx); err != nil {
+			return nil, rv, err
+		}
+	}
+	return m, rv, nil
+}
+
+// buildModelIndex backfill index registered by a model in tx if it is not ready, so it is ready after commit.
+// Index built in file is ready at once, read-only tx leave it not ready
+func (tx *Tx) buildModelIndex(idx *index) error {
+	if !idx.model || atomic.LoadInt32(&idx.ready) == 1 || tx.built[idx] {
+		return nil
+	}
+	if b := tx.tx.Bucket(_metaBucket); b != nil && bytes.Equal(b.Get(indexMetaKey(idx.Bucket, idx.Name)), _indexReadyTag) {
+		atomic.StoreInt32(&idx.ready, 1)
+		return nil
+	}
+	if !tx.tx.Writable() {
+		return nil
+	}
+	if b := tx.tx.Bucket(idx.Bucket); b != nil {
+		r := tx.reader(idx.Bucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := tx.putIndex(idx, k, nil, r.value(k, v)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.putMeta(indexMetaKey(idx.Bucket, idx.Name), _indexReadyTag); err != nil {
+		return err
+	}
+	if tx.built == nil {
+		tx.built = make(map[*index]bool)
+	}
+	tx.built[idx] = true
+	tx.afterCommit(func() { atomic.StoreInt32(&idx.ready, 1) })
+	return nil
+}
+
+// modelExtract index extractor decoding records of type t stored in bucket and encoding field
+func (db *DB) modelExtract(t reflect.Type, bucket []byte, field []int) func(k, v []byte) [][]byte {
+	return func(k, v []byte) [][]byte {
+		rv := reflect.New(t)
+		if err := db.Codec(bucket).Unmarshal(v, rv.Interface()); err != nil {
+			return nil
+		}
+		b, err := encodeField(rv.Elem().FieldByIndex(field))
+		if err != nil {
+			return nil
+		}
+		return [][]byte{b}
+	}
+}
+
+// encodeField encode field value keeping order
+func encodeField(v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Int64Codec{}.Encode(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Uint64ToBytes(v.Uint()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	case reflect.Struct:
+		if v.Type() == _timeType {
+			return TimeToBytes(v.Interface().(time.Time)), nil
+		}
+	}
+	return nil, fmt.Errorf("zbolt: unsupported key or index field type %s", v.Type())
+}
+
+// SaveModel put struct pointed by v to bucket of its type, indexes of tagged fields are maintained.
+// Zero unsigned integer key is assigned the next sequence of bucket
+func (tx *Tx) SaveModel(v interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	m, rv, err := tx.model(v)
+	if err != nil {
+		return tx.Error(err)
+	}
+	kf := rv.FieldByIndex(m.key)
+	switch kf.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if kf.Uint() == 0 {
+			seq, err := tx.NextSequence(m.bucket)
+			if err != nil {
+				return err
+			}
+			kf.SetUint(seq)
+		}
+	}
+	key, err := encodeField(kf)
+	if err != nil {
+		return tx.Error(err)
+	}
+	return tx.PutObject(m.bucket, key, v)
+}
+
+// LoadModel fill struct pointed by v by its key field, return ErrRecordNotFound if not exist
+func (tx *Tx) LoadModel(v interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	m, rv, err := tx.model(v)
+	if err != nil {
+		return tx.Error(err)
+	}
+	key, err := encodeField(rv.FieldByIndex(m.key))
+	if err != nil {
+		return tx.Error(err)
+	}
+	return tx.GetObject(m.bucket, key, v)
+}
+
+// DeleteModel delete struct pointed by v by its key field and its index entries
+func (tx *Tx) DeleteModel(v interface{}) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	m, rv, err := tx.model(v)
+	if err != nil {
+		return tx.Error(err)
+	}
+	key, err := encodeField(rv.FieldByIndex(m.key))
+	if err != nil {
+		return tx.Error(err)
+	}
+	return tx.Delete(m.bucket, key)
+}
+
+// FindModels load structs whose indexed field equal value into slice pointed by out, like *[]User, ordered by key
+func (tx *Tx) FindModels(out interface{}, index string, value interface{}) error {
+	return tx.FindModelsRange(out, index, value, value, 0)
+}
+
+// FindModelsRange load structs whose indexed field is in [from, to] into slice pointed by out, ordered by the field,
+// nil from or to means unbounded, limit = 0 representative of all
+func (tx *Tx) FindModelsRange(out interface{}, index string, from, to interface{}, limit int) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	s := reflect.ValueOf(out)
+	if s.Kind() != reflect.Ptr || s.Elem().Kind() != reflect.Slice {
+		return tx.Error(errors.New("zbolt: out must be a pointer to slice"))
+	}
+	s = s.Elem()
+	m, _, err := tx.model(reflect.New(s.Type().Elem()).Interface())
+	if err != nil {
+		return tx.Error(err)
+	}
+	idx := tx.db.index(m.bucket, index)
+	if idx == nil {
+		return tx.Error(fmt.Errorf("zbolt: model %s has no index %q", m.bucket, index))
+	}
+	if !tx.db.IndexReady(m.bucket, index) && !tx.built[idx] {
+		return tx.Error(fmt.Errorf("%w: %s of %q", ErrIndexNotReady, index, m.bucket))
+	}
+	var lo, hi []byte
+	if from != nil {
+		if lo, err = encodeField(reflect.ValueOf(from)); err != nil {
+			return tx.Error(err)
+		}
+	}
+	if to != nil {
+		if hi, err = encodeField(reflect.ValueOf(to)); err != nil {
+			return tx.Error(err)
+		}
+	}
+	for _, key := range tx.indexRange(m.bucket, index, lo, hi, limit) {
+		e := reflect.New(s.Type().Elem())
+		if err := tx.GetObject(m.bucket, key, e.Interface()); err != nil {
+			if err == ErrRecordNotFound {
+				continue
+			}
+			return err
+		}
+		s.Set(reflect.Append(s, e.Elem()))
+	}
+	return nil
+}
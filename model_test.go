@@ -0,0 +1,141 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type Article struct {
+	ID        uint64    `zbolt:"key"`
+	Author    string    `zbolt:"index:author"`
+	CreatedAt time.Time `zbolt:"index:created_at"`
+	Title     string
+}
+
+func TestTx_SaveModel(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for i, author := range []string{"alice", "bob", "alice", "carol"} {
+		a := &Article{Author: author, CreatedAt: base.Add(time.Duration(i) * time.Hour), Title: "t"}
+		if err := tx.SaveModel(a); err != nil {
+			t.Fatal(err)
+		}
+		if a.ID != uint64(i+1) {
+			t.Fatal("key not assigned", a.ID)
+		}
+	}
+	a := Article{ID: 2}
+	if err := tx.LoadModel(&a); err != nil || a.Author != "bob" || !a.CreatedAt.Equal(base.Add(time.Hour)) {
+		t.Fatal("unexpected load", a, err)
+	}
+	var found []Article
+	if err := tx.FindModels(&found, "author", "alice"); err != nil || len(found) != 2 || found[0].ID != 1 || found[1].ID != 3 {
+		t.Fatal("unexpected find", found, err)
+	}
+	// changed indexed field moves the index entry
+	a.Author = "alice"
+	tx.SaveModel(&a)
+	found = nil
+	if tx.FindModels(&found, "author", "bob"); len(found) != 0 {
+		t.Fatal("stale index entry", found)
+	}
+	found = nil
+	if err := tx.FindModelsRange(&found, "created_at", base.Add(time.Hour), nil, 2); err != nil || len(found) != 2 || found[0].ID != 2 || found[1].ID != 3 {
+		t.Fatal("unexpected range", found, err)
+	}
+	tx.DeleteModel(&Article{ID: 1})
+	if err := tx.LoadModel(&Article{ID: 1}); err != ErrRecordNotFound {
+		t.Fatal("expect ErrRecordNotFound, got", err)
+	}
+	found = nil
+	if tx.FindModels(&found, "author", "alice"); len(found) != 2 {
+		t.Fatal("deleted model still indexed", found)
+	}
+	if err := tx.SaveModel(&struct{ Name string }{}); err != errNoModel {
+		t.Fatal("expect errNoModel, got", err)
+	}
+	tx.Error(ErrNil)
+	if err := tx.SaveModel(&struct {
+		ID uint64 `zbolt:"key"`
+	}{}); err != errNoModel {
+		t.Fatal("expect errNoModel for unnamed struct, got", err)
+	}
+}
+
+type Note struct {
+	ID     uint64 `zbolt:"key"`
+	Author string `zbolt:"index:author"`
+}
+
+func TestTx_SaveModelBackfill(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	bucket := []byte("github.com/dukangxu/zbolt.Note")
+	// records stored before the model was used
+	if err := d.Update(func(tx *Tx) error {
+		return tx.PutObject(bucket, Uint64ToBytes(1), &Note{ID: 1, Author: "alice"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	if err := tx.SaveModel(&Note{ID: 2, Author: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	var found []Note
+	if err := tx.FindModels(&found, "author", "alice"); err != nil || len(found) != 2 {
+		t.Fatal("existing record not indexed", found, err)
+	}
+	tx.Rollback()
+	if d.IndexReady(bucket, "author") {
+		t.Fatal("index of rolled back tx must not be ready")
+	}
+	if err := d.View(func(tx *Tx) error {
+		return tx.FindModels(&found, "author", "alice")
+	}); !errors.Is(err, ErrIndexNotReady) {
+		t.Fatal("expect ErrIndexNotReady, got", err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.SaveModel(&Note{ID: 3, Author: "bob"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	found = nil
+	if err := d.View(func(tx *Tx) error {
+		return tx.FindModels(&found, "author", "alice")
+	}); err != nil || len(found) != 1 || found[0].ID != 1 {
+		t.Fatal("unexpected find after backfill", found, err)
+	}
+}
+
+func TestDB_RegisterModel(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	bucket := []byte("github.com/dukangxu/zbolt.Note")
+	if err := d.Update(func(tx *Tx) error {
+		return tx.PutObject(bucket, Uint64ToBytes(1), &Note{ID: 1, Author: "alice"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RegisterModel(&Note{}); err != nil || !d.IndexReady(bucket, "author") {
+		t.Fatal("index not built", err)
+	}
+	var found []Note
+	if err := d.View(func(tx *Tx) error {
+		return tx.FindModels(&found, "author", "alice")
+	}); err != nil || len(found) != 1 {
+		t.Fatal("unexpected find", found, err)
+	}
+}
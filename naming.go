@@ -0,0 +1,213 @@
+package zbolt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// BucketNamer join parts into bucket names, Sep and Escape inside parts are escaped by Escape
+// so that different parts never join into the same name
+type BucketNamer struct {
+	Sep    byte
+	Escape byte
+}
+
+// DefaultBucketNamer namer of BucketName, joins parts with "_" like BucketNameConcat
+var DefaultBucketNamer = BucketNamer{Sep: '_', Escape: '\\'}
+
+// BucketName join parts into bucket name by DefaultBucketNamer, parts containing "_" can not collide
+func BucketName(parts ...[]byte) []byte {
+	return DefaultBucketNamer.Name(parts...)
+}
+
+// SplitBucketName split bucket name joined by BucketName into parts
+func SplitBucketName(name []byte) ([][]byte, error) {
+	return DefaultBucketNamer.Split(name)
+}
+
+// Name join parts into bucket name
+func (n BucketNamer) Name(parts ...[]byte) []byte {
+	var name []byte
+	for i, p := range parts {
+		if i != 0 {
+			name = append(name, n.Sep)
+		}
+		for _, c := range p {
+			if c == n.Sep || c == n.Escape {
+				name = append(name, n.Escape)
+			}
+			name = append(name, c)
+		}
+	}
+	return name
+}
+
+// Split split bucket name joined by Name into parts, return ErrKeyDecode if name ends with a dangling escape
+func (n BucketNamer) Split(name []byte) ([][]byte, error) {
+	parts := [][]byte{{}}
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case n.Escape:
+			if i++; i == len(name) {
+				return nil, ErrKeyDecode
+			}
+			parts[len(parts)-1] = append(parts[len(parts)-1], name[i])
+		case n.Sep:
+			parts = append(parts, []byte{})
+		default:
+			parts[len(parts)-1] = append(parts[len(parts)-1], c)
+		}
+	}
+	return parts, nil
+}
+
+// MigrateBucketName rename bucket named BucketNameConcat(parts...) to n.Name(parts...) with its sort, delta, version,
+// reverse, expiry and index buckets, schema and index state. Return false if no bucket has the old name.
+// Options configured on DB are keyed by name and must be set again for the new name
+func (tx *Tx) MigrateBucketName(n BucketNamer, parts ...[]byte) (bool, error) {
+	if tx.err != nil {
+		return false, tx.err
+	}
+	from, to := BucketNameConcat(parts...), n.Name(parts...)
+	if bytes.Equal(from, to) {
+		return tx.tx.Bucket(from) != nil, nil
+	}
+	return tx.renameBucket(from, to)
+}
+
+// RenameBucket rename bucket old to new with its sort, delta, version, reverse, expiry and index buckets,
+// schema and index state, copying keys as bolt has no rename. Return ErrRecordNotFound if old does not exist
+// and ErrBucketExists if new does. Options configured on DB are keyed by name and must be set again for new
+func (tx *Tx) RenameBucket(old, new []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if tx.Error(checkBucketName(new)) != nil {
+		return tx.err
+	}
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	var found bool
+	for _, prefix := range _bucketPrefixes {
+		if tx.tx.Bucket(shadowName(prefix, new)) != nil {
+			return fmt.Errorf("%w: %q", ErrBucketExists, new)
+		}
+		found = found || tx.tx.Bucket(shadowName(prefix, old)) != nil
+	}
+	if !found {
+		return ErrRecordNotFound
+	}
+	_, err := tx.renameBucket(old, new)
+	return tx.Error(err)
+}
+
+// _bucketPrefixes prefixes of a bucket and its shadow buckets named after it
+var _bucketPrefixes = [][]byte{nil, _keyPrefix, _valuePrefix, _deltaPrefix, _versionPrefix, _reversePrefix, _expiryPrefix}
+
+// renameBucket move bucket from to to with its shadow and index buckets and meta keys, return false if nothing moved
+func (tx *Tx) renameBucket(from, to []byte) (bool, error) {
+	var moved bool
+	for _, prefix := range _bucketPrefixes {
+		ok, err := tx.moveBucket(shadowName(prefix, from), shadowName(prefix, to))
+		if err != nil {
+			return false, err
+		}
+		moved = moved || ok
+	}
+	// index buckets and index state are keyed by escaped bucket name followed by index name
+	fromIndex, toIndex := shadowName(_indexPrefix, escapeKey(from)), shadowName(_indexPrefix, escapeKey(to))
+	var names [][]byte
+	if err := tx.tx.ForEach(func(name []byte, _ backendBucket) error {
+		if bytes.HasPrefix(name, fromIndex) {
+			names = append(names, append([]byte{}, name...))
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if _, err := tx.moveBucket(name, BytesConcat(toIndex, name[len(fromIndex):])); err != nil {
+			return false, err
+		}
+	}
+	if meta := tx.tx.Bucket(_metaBucket); meta != nil {
+		renames := map[string][]byte{
+			string(BytesConcat(_schemaMetaKey, from)):   BytesConcat(_schemaMetaKey, to),
+			string(BytesConcat(_envelopeMetaKey, from)): BytesConcat(_envelopeMetaKey, to),
+		}
+		fromMeta := BytesConcat(_indexMetaKey, escapeKey(from))
+		c := meta.Cursor()
+		for k, _ := c.Seek(fromMeta); k != nil && bytes.HasPrefix(k, fromMeta); k, _ = c.Next() {
+			renames[string(k)] = BytesConcat(_indexMetaKey, escapeKey(to), k[len(fromMeta):])
+		}
+		for k, nk := range renames {
+			v := meta.Get([]byte(k))
+			if v == nil {
+				continue
+			}
+			if err := meta.Put(nk, append([]byte{}, v...)); err != nil {
+				return false, err
+			}
+			if err := meta.Delete([]byte(k)); err != nil {
+				return false, err
+			}
+		}
+	}
+	return moved, nil
+}
+
+// moveBucket move keys and sequence of bucket from to new bucket to, return false if from does not exist
+// and ErrBucketExists if to exists
+func (tx *Tx) moveBucket(from, to []byte) (bool, error) {
+	ok, err := copyBucket(tx.tx, tx.tx, from, to)
+	if !ok || err != nil {
+		return false, err
+	}
+	return true, tx.tx.DeleteBucket(from)
+}
+
+// shadowName name of internal bucket of kind for bucket name. Internal buckets have their own namespace, the names
+// starting with a kind byte in [_shadowMin, _shadowMax), so the name of a bucket is never escaped and never taken
+// for another bucket as user bucket names are rejected from it by checkBucketName. nil kind is the bucket itself
+func shadowName(kind, name []byte) []byte {
+	return BytesConcat(kind, name)
+}
+
+// isShadowName check name is in the namespace of internal buckets
+func isShadowName(name []byte) bool {
+	return len(name) > 0 && name[0] >= _shadowMin && name[0] < _shadowMax
+}
+
+// checkBucketName reject user bucket name in the namespace of internal buckets, starting with a reserved byte,
+// which would be taken for an internal bucket of another bucket. Every call creating a user bucket check it
+func checkBucketName(name []byte) error {
+	if isShadowName(name) {
+		return fmt.Errorf("%w: %q", ErrReservedName, name)
+	}
+	return nil
+}
+
+// MigrateReservedName move bucket of file at path written before names were checked, whose name start with
+// a reserved byte, to name to. Only the bucket itself is moved, as internal buckets of buckets named so can not
+// be told apart from those of other buckets. File must not be opened
+func MigrateReservedName(path string, name, to []byte) error {
+	if err := checkBucketName(to); err != nil {
+		return err
+	}
+	bdb, err := openBackend(path, nil)
+	if err != nil {
+		return err
+	}
+	defer bdb.Close()
+	tx := (&DB{db: bdb}).NewTx(true)
+	defer tx.Rollback()
+	ok, err := tx.moveBucket(name, to)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRecordNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,165 @@
+package zbolt
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestBucketName(t *testing.T) {
+	a, b := BucketName([]byte("a_b"), []byte("c")), BucketName([]byte("a"), []byte("b_c"))
+	if bytes.Equal(a, b) {
+		t.Fatal("names collide", string(a))
+	}
+	if string(BucketName([]byte("user"), []byte("idx"))) != "user_idx" {
+		t.Fatal("plain parts must join like BucketNameConcat")
+	}
+	n := BucketNamer{Sep: '/', Escape: '%'}
+	parts := [][]byte{[]byte("a/b"), {}, []byte("50%"), []byte("_")}
+	got, err := n.Split(n.Name(parts...))
+	if err != nil || len(got) != len(parts) {
+		t.Fatal("unexpected split", got, err)
+	}
+	for i := range parts {
+		if !bytes.Equal(got[i], parts[i]) {
+			t.Fatalf("part %d: %q != %q", i, got[i], parts[i])
+		}
+	}
+	if _, err := n.Split([]byte("a%")); err != ErrKeyDecode {
+		t.Fatal("expect ErrKeyDecode", err)
+	}
+}
+
+func TestTx_MigrateBucketName(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	parts := [][]byte{[]byte("tenant_1"), []byte("scores")}
+	old := BucketNameConcat(parts...)
+	tx := d.NewTx(true)
+	tx.Put(old, []byte("k"), []byte("v"))
+	tx.NextSequence(old)
+	tx.SortPut(old, []byte("1"), []byte("k"), []byte("v"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx = d.NewTx(true)
+	moved, err := tx.MigrateBucketName(DefaultBucketNamer, parts...)
+	if err != nil || !moved {
+		t.Fatal("bucket not moved", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	name := BucketName(parts...)
+	tx = d.NewTx(false)
+	defer tx.Rollback()
+	if tx.tx.Bucket(old) != nil || string(tx.Get(name, []byte("k"))[1]) != "v" {
+		t.Fatal("keys not moved")
+	}
+	if tx.Sequence(name) != 1 {
+		t.Fatal("sequence not moved", tx.Sequence(name))
+	}
+	if got := tx.SortGet(name, []byte("k")); len(got) != 3 || string(got[1]) != "1" {
+		t.Fatal("sort buckets not moved", got)
+	}
+}
+
+func TestTx_RenameBucket(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	old, new := []byte("old"), []byte("new")
+	tx.Put(old, []byte("k"), []byte("v"))
+	tx.SortPut(old, []byte("1"), []byte("sk"), []byte("sv"))
+	tx.Put([]byte("taken"), []byte("k"), []byte("v"))
+	if err := tx.RenameBucket(old, []byte("taken")); !errors.Is(err, ErrBucketExists) {
+		t.Fatal("expect ErrBucketExists", err)
+	}
+	if err := tx.RenameBucket(old, new); err != nil {
+		t.Fatal(err)
+	}
+	if tx.tx.Bucket(old) != nil || tx.tx.Bucket(BytesConcat(_keyPrefix, old)) != nil {
+		t.Fatal("old buckets left")
+	}
+	if gets := tx.Get(new, []byte("k")); len(gets) != 2 || string(gets[1]) != "v" {
+		t.Fatal("unexpected renamed value", gets)
+	}
+	if kvs := tx.SortNext(new, nil, 0); len(kvs) != 2 || string(kvs[1]) != "sv" {
+		t.Fatal("unexpected renamed sort entries", kvs)
+	}
+	if err := tx.RenameBucket(old, []byte("other")); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatal("expect ErrRecordNotFound", err)
+	}
+}
+
+func TestMigrateReservedName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reserved.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserved := []byte{_shadowMax - 1, 'x'}
+	tx := d.NewTx(true)
+	if err := tx.Put(reserved, []byte("k"), []byte("v")); !errors.Is(err, ErrReservedName) {
+		t.Fatal("expect ErrReservedName", err)
+	}
+	tx.Rollback()
+	// bucket written before names were checked
+	tx = d.NewTx(true)
+	b, _ := tx.tx.CreateBucketIfNotExists(reserved)
+	b.Put([]byte("k"), []byte("v"))
+	tx.Commit()
+	d.Close()
+
+	if _, err := Open(path); !errors.Is(err, ErrFormatNewer) {
+		t.Fatal("expect reserved bucket to be refused", err)
+	}
+	if err := MigrateReservedName(path, reserved, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	d, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx = d.NewTx(false)
+	defer tx.Rollback()
+	if got := tx.Get([]byte("x"), []byte("k")); len(got) != 2 {
+		t.Fatal("bucket not moved")
+	}
+}
+
+func TestReservedNameNotCreated(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "reserved.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	reserved := []byte{_deltaPrefix[0], 'x'}
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	if v := tx.Get(reserved, []byte("k")); len(v) != 0 || !errors.Is(tx.err, ErrReservedName) {
+		t.Fatal("expect ErrReservedName", tx.err)
+	}
+	tx.Error(ErrNil)
+	if tx.Next(reserved, nil, 1); !errors.Is(tx.err, ErrReservedName) {
+		t.Fatal("expect ErrReservedName", tx.err)
+	}
+	tx.Error(ErrNil)
+	if err := tx.Load(reserved, bytes.NewReader(nil)); !errors.Is(err, ErrReservedName) {
+		t.Fatal("expect ErrReservedName", err)
+	}
+	tx.Error(ErrNil)
+	if tx.tx.Bucket(reserved) != nil {
+		t.Fatal("reserved bucket created")
+	}
+}
@@ -0,0 +1,84 @@
+package zbolt
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// openTimeout time to wait for file lock on Open
+const openTimeout = 3 * time.Second
+
+// Options options of OpenWithOptions, zero value is the same as Open
+type Options struct {
+	Mode            os.FileMode   // file mode when file is created, default 0600
+	Timeout         time.Duration // time to wait for file lock, default 3s, < 0 wait forever
+	ReadOnly        bool          // open with shared file lock, writable transactions fail
+	NoGrowSync      bool          // skip fsync when growing file, see bolt.Options
+	NoSync          bool          // skip fsync after commit, unsafe on crash, see bolt.DB.NoSync
+	InitialMmapSize int           // initial mmap size in bytes, avoid remapping blocking writers while read transactions are open
+	MmapFlags       int           // flags passed to mmap, like syscall.MAP_POPULATE
+	Backend         Backend       // storage engine, default BackendBolt
+	Schemas         []Schema      // schemas of buckets validated on open like RegisterSchema, open fail with *SchemaError
+}
+
+// OpenWithOptions open file like Open with options of the underlying bolt DB, nil options is the same as Open
+func OpenWithOptions(path string, o *Options) (*DB, error) {
+	bdb, err := openBackend(path, o)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{db: bdb, opts: o}
+	if err := db.openFormat(); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	if err := db.openWarm(); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	if err := db.openTTL(); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	if err := db.openSchemas(); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// openBackend open storage engine selected by options without checking format
+func openBackend(path string, o *Options) (backend, error) {
+	if o == nil {
+		o = &Options{}
+	}
+	mode := o.Mode
+	if mode == 0 {
+		mode = 0600
+	}
+	timeout := o.Timeout
+	switch {
+	case timeout == 0:
+		timeout = openTimeout
+	case timeout < 0:
+		timeout = 0
+	}
+	backend := o.Backend
+	if backend == "" {
+		backend = BackendBolt
+	}
+	opener, ok := backendOpeners[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q", backend)
+	}
+	return opener(path, backendOptions{
+		Mode:            mode,
+		Timeout:         timeout,
+		ReadOnly:        o.ReadOnly,
+		NoGrowSync:      o.NoGrowSync,
+		NoSync:          o.NoSync,
+		InitialMmapSize: o.InitialMmapSize,
+		MmapFlags:       o.MmapFlags,
+	})
+}
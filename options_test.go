@@ -0,0 +1,39 @@
+package zbolt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenWithOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.db")
+	d, err := OpenWithOptions(path, &Options{Mode: 0640, NoSync: true, InitialMmapSize: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put([]byte("b"), []byte("k"), []byte("v"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0640 {
+		t.Fatal("unexpected file mode", fi.Mode(), err)
+	}
+
+	d, err = OpenWithOptions(path, &Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err := d.Update(func(tx *Tx) error { return nil }); err == nil {
+		t.Fatal("expect read only db reject writable tx")
+	}
+	d.View(func(tx *Tx) error {
+		if gets := tx.Get([]byte("b"), []byte("k")); len(gets) != 2 {
+			t.Fatal("unexpected values", gets)
+		}
+		return nil
+	})
+}
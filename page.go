@@ -0,0 +1,221 @@
+package zbolt
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// bolt file format constants
+const (
+	boltMagic          uint32 = 0xED0CDAED
+	boltVersion        uint32 = 2
+	pageHeaderSize            = 16
+	pageElementSize           = 16
+	metaChecksumOffset        = 56
+	bucketHeaderSize          = 16
+
+	branchPageFlag   uint16 = 0x01
+	leafPageFlag     uint16 = 0x02
+	metaPageFlag     uint16 = 0x04
+	freelistPageFlag uint16 = 0x10
+
+	bucketLeafFlag uint32 = 0x01
+)
+
+// rawFile bolt file read into memory, parsed without opening it with bolt
+type rawFile struct {
+	data     []byte
+	pageSize int
+}
+
+// rawMeta meta page of bolt file
+type rawMeta struct {
+	magic    uint32
+	version  uint32
+	pageSize uint32
+	flags    uint32
+	root     uint64
+	sequence uint64
+	freelist uint64
+	pgid     uint64
+	txid     uint64
+	checksum uint64
+	err      error
+}
+
+// rawPage page of bolt file, body include overflow pages
+type rawPage struct {
+	id       uint64
+	flags    uint16
+	count    uint16
+	overflow uint32
+	body     []byte
+}
+
+// rawElement element of branch or leaf page
+type rawElement struct {
+	flags uint32
+	key   []byte
+	value []byte // leaf element value
+	pgid  uint64 // branch element child page
+}
+
+// parseMeta parse meta page body and validate it like bolt does
+func parseMeta(b []byte) rawMeta {
+	if len(b) < metaChecksumOffset+8 {
+		return rawMeta{err: ErrInvalidFile}
+	}
+	le := binary.LittleEndian
+	m := rawMeta{
+		magic:    le.Uint32(b[0:]),
+		version:  le.Uint32(b[4:]),
+		pageSize: le.Uint32(b[8:]),
+		flags:    le.Uint32(b[12:]),
+		root:     le.Uint64(b[16:]),
+		sequence: le.Uint64(b[24:]),
+		freelist: le.Uint64(b[32:]),
+		pgid:     le.Uint64(b[40:]),
+		txid:     le.Uint64(b[48:]),
+		checksum: le.Uint64(b[56:]),
+	}
+	switch {
+	case m.magic != boltMagic || m.version != boltVersion:
+		m.err = ErrInvalidFile
+	case m.checksum != 0 && m.checksum != metaChecksum(b):
+		m.err = ErrChecksum
+	case m.pageSize < 512 || m.pageSize&(m.pageSize-1) != 0:
+		m.err = ErrInvalidFile
+	}
+	return m
+}
+
+// metaChecksum checksum of meta page body
+func metaChecksum(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b[:metaChecksumOffset])
+	return h.Sum64()
+}
+
+// newRawFile detect page size from meta pages, default 4096
+func newRawFile(data []byte) *rawFile {
+	f := &rawFile{data: data, pageSize: 4096}
+	if len(data) >= pageHeaderSize+metaChecksumOffset+8 {
+		if m := parseMeta(data[pageHeaderSize:]); m.err == nil {
+			f.pageSize = int(m.pageSize)
+			return f
+		}
+	}
+	// meta0 broken, look for meta1 at common page sizes
+	for size := 512; size <= 65536; size *= 2 {
+		off := size + pageHeaderSize
+		if off+metaChecksumOffset+8 > len(data) {
+			break
+		}
+		if m := parseMeta(data[off:]); m.err == nil && int(m.pageSize) == size {
+			f.pageSize = size
+			break
+		}
+	}
+	return f
+}
+
+// pageCount count of pages in file
+func (f *rawFile) pageCount() uint64 {
+	return uint64(len(f.data) / f.pageSize)
+}
+
+// page get page by id, false if out of file
+func (f *rawFile) page(id uint64) (rawPage, bool) {
+	if id >= f.pageCount() {
+		return rawPage{}, false
+	}
+	return parsePage(f.data[id*uint64(f.pageSize):], f.pageSize)
+}
+
+// meta get meta page 0 or 1
+func (f *rawFile) meta(i uint64) rawMeta {
+	p, ok := f.page(i)
+	if !ok {
+		return rawMeta{err: ErrInvalidFile}
+	}
+	return parseMeta(p.body)
+}
+
+// parsePage parse page at the start of b
+func parsePage(b []byte, pageSize int) (rawPage, bool) {
+	if len(b) < pageHeaderSize {
+		return rawPage{}, false
+	}
+	le := binary.LittleEndian
+	p := rawPage{
+		id:       le.Uint64(b[0:]),
+		flags:    le.Uint16(b[8:]),
+		count:    le.Uint16(b[10:]),
+		overflow: le.Uint32(b[12:]),
+	}
+	end := (uint64(p.overflow) + 1) * uint64(pageSize)
+	if end > uint64(len(b)) {
+		end = uint64(len(b))
+	}
+	p.body = b[pageHeaderSize:end]
+	return p, true
+}
+
+// elements parse elements of branch or leaf page, skip elements out of page
+func (p rawPage) elements() []rawElement {
+	le := binary.LittleEndian
+	var es []rawElement
+	for i := 0; i < int(p.count); i++ {
+		off := i * pageElementSize
+		if off+pageElementSize > len(p.body) {
+			break
+		}
+		b := p.body[off:]
+		pos, ksize := int(le.Uint32(b[4:])), int(le.Uint32(b[8:]))
+		if p.flags&branchPageFlag != 0 {
+			pos, ksize = int(le.Uint32(b[0:])), int(le.Uint32(b[4:]))
+			if off+pos+ksize > len(p.body) {
+				continue
+			}
+			es = append(es, rawElement{key: p.body[off+pos : off+pos+ksize], pgid: le.Uint64(b[8:])})
+			continue
+		}
+		vsize := int(le.Uint32(b[12:]))
+		if off+pos+ksize+vsize > len(p.body) {
+			continue
+		}
+		k := p.body[off+pos : off+pos+ksize]
+		es = append(es, rawElement{flags: le.Uint32(b[0:]), key: k, value: p.body[off+pos+ksize : off+pos+ksize+vsize]})
+	}
+	return es
+}
+
+// walk walk leaf elements of tree from root page, inline bucket page given by inline
+func (f *rawFile) walk(root uint64, inline []byte, seen map[uint64]bool, fn func(e rawElement)) {
+	if root == 0 {
+		if p, ok := parsePage(inline, len(inline)); ok && p.flags&leafPageFlag != 0 {
+			for _, e := range p.elements() {
+				fn(e)
+			}
+		}
+		return
+	}
+	if seen[root] {
+		return
+	}
+	seen[root] = true
+	p, ok := f.page(root)
+	if !ok || p.id != root {
+		return
+	}
+	switch {
+	case p.flags&branchPageFlag != 0:
+		for _, e := range p.elements() {
+			f.walk(e.pgid, nil, seen, fn)
+		}
+	case p.flags&leafPageFlag != 0:
+		for _, e := range p.elements() {
+			fn(e)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package zbolt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// PageInfo page of bolt file
+type PageInfo struct {
+	ID       uint64
+	Type     string // meta, freelist, branch, leaf, overflow, free, unused (past high water) or unknown
+	Count    int    // elements of branch or leaf page, page ids of freelist page
+	Overflow int    // count of overflow pages following the page
+	Parent   uint64 // first page of the chain for overflow page
+	Bucket   string // owner bucket, nested buckets joined by "/", empty for root bucket and unowned pages
+	Owned    bool   // page is reachable from the meta page
+}
+
+// BucketPages pages owned by a bucket
+type BucketPages struct {
+	Name   string
+	Pages  []uint64 // owned pages including overflow pages
+	Keys   int
+	Inline bool // bucket stored inline in the page of parent bucket
+}
+
+// PageReport page layout of bolt file
+type PageReport struct {
+	PageSize  int
+	Meta      int    // meta page in use
+	TxID      uint64 // transaction id of meta page in use
+	HighWater uint64 // page count recorded in meta page
+	Pages     []PageInfo
+	Free      []uint64
+	Buckets   []BucketPages
+}
+
+// InspectPages read bolt file at path page by page without opening it with bolt,
+// report page types, overflow chains, freelist and pages owned by buckets
+func InspectPages(path string) (*PageReport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newRawFile(data).inspect()
+}
+
+// inspect build page report of file
+func (f *rawFile) inspect() (*PageReport, error) {
+	m0, m1 := f.meta(0), f.meta(1)
+	meta, current := m0, 0
+	if m0.err != nil || (m1.err == nil && m1.txid > m0.txid) {
+		meta, current = m1, 1
+	}
+	if meta.err != nil {
+		return nil, meta.err
+	}
+	r := &PageReport{PageSize: f.pageSize, Meta: current, TxID: meta.txid, HighWater: meta.pgid}
+	r.Pages = make([]PageInfo, f.pageCount())
+	for id := uint64(0); id < f.pageCount(); id++ {
+		p, _ := f.page(id)
+		info := PageInfo{ID: id, Type: "unknown"}
+		if id >= meta.pgid {
+			info.Type = "unused"
+		} else if p.id == id {
+			info.Overflow = int(p.overflow)
+			switch {
+			case p.flags&metaPageFlag != 0:
+				info.Type = "meta"
+			case p.flags&freelistPageFlag != 0:
+				info.Type = "freelist"
+			case p.flags&branchPageFlag != 0:
+				info.Type, info.Count = "branch", int(p.count)
+			case p.flags&leafPageFlag != 0:
+				info.Type, info.Count = "leaf", int(p.count)
+			}
+		}
+		r.Pages[id] = info
+		if info.Type == "unknown" || info.Type == "unused" {
+			continue
+		}
+		for i := uint64(1); i <= uint64(info.Overflow) && id+i < f.pageCount(); i++ {
+			r.Pages[id+i] = PageInfo{ID: id + i, Type: "overflow", Parent: id}
+		}
+		id += uint64(info.Overflow)
+	}
+	r.Pages[0].Owned, r.Pages[1].Owned = true, true
+	r.Free = f.freelist(meta.freelist)
+	if meta.freelist < f.pageCount() {
+		r.Pages[meta.freelist].Count = len(r.Free)
+		f.own(r, meta.freelist, "", nil)
+	}
+	for _, id := range r.Free {
+		if id < f.pageCount() {
+			r.Pages[id].Type = "free"
+		}
+	}
+	f.ownTree(r, meta.root, "", nil, make(map[uint64]bool))
+	return r, nil
+}
+
+// freelist page ids in freelist page
+func (f *rawFile) freelist(id uint64) []uint64 {
+	p, ok := f.page(id)
+	if !ok || p.id != id || p.flags&freelistPageFlag == 0 {
+		return nil
+	}
+	count, body := uint64(p.count), p.body
+	if count == 0xFFFF && len(body) >= 8 {
+		count, body = binary.LittleEndian.Uint64(body), body[8:]
+	}
+	var ids []uint64
+	for i := uint64(0); i < count && (i+1)*8 <= uint64(len(body)); i++ {
+		ids = append(ids, binary.LittleEndian.Uint64(body[i*8:]))
+	}
+	return ids
+}
+
+// own mark page and its overflow pages owned by bucket
+func (f *rawFile) own(r *PageReport, id uint64, name string, b *BucketPages) {
+	for i := id; i <= id+uint64(r.Pages[id].Overflow) && i < uint64(len(r.Pages)); i++ {
+		r.Pages[i].Bucket, r.Pages[i].Owned = name, true
+		if b != nil {
+			b.Pages = append(b.Pages, i)
+		}
+	}
+}
+
+// ownTree mark pages of bucket tree from root page, b is nil for root bucket
+func (f *rawFile) ownTree(r *PageReport, root uint64, name string, b *BucketPages, seen map[uint64]bool) {
+	if seen[root] || root >= uint64(len(r.Pages)) {
+		return
+	}
+	seen[root] = true
+	p, _ := f.page(root)
+	if p.id != root {
+		return
+	}
+	f.own(r, root, name, b)
+	if p.flags&branchPageFlag != 0 {
+		for _, e := range p.elements() {
+			f.ownTree(r, e.pgid, name, b, seen)
+		}
+		return
+	}
+	if p.flags&leafPageFlag == 0 {
+		return
+	}
+	for _, e := range p.elements() {
+		if e.flags&bucketLeafFlag == 0 {
+			if b != nil {
+				b.Keys++
+			}
+			continue
+		}
+		if len(e.value) < bucketHeaderSize {
+			continue
+		}
+		child := BucketPages{Name: string(e.key)}
+		if b != nil {
+			child.Name = name + "/" + child.Name
+		}
+		i := len(r.Buckets)
+		r.Buckets = append(r.Buckets, child)
+		if sub := binary.LittleEndian.Uint64(e.value); sub != 0 {
+			f.ownTree(r, sub, child.Name, &child, seen)
+		} else {
+			child.Inline = true
+			f.walk(0, e.value[bucketHeaderSize:], nil, func(e rawElement) {
+				if e.flags&bucketLeafFlag == 0 {
+					child.Keys++
+				}
+			})
+		}
+		r.Buckets[i] = child
+	}
+}
+
+// WriteTo write report as text
+func (r *PageReport) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "page size %d, meta %d, txid %d, high water %d, pages %d, free %d\n",
+		r.PageSize, r.Meta, r.TxID, r.HighWater, len(r.Pages), len(r.Free))
+	fmt.Fprintf(&sb, "%-8s %-9s %6s %8s  %s\n", "ID", "TYPE", "COUNT", "OVERFLOW", "BUCKET")
+	for _, p := range r.Pages {
+		owner := fmt.Sprintf("%q", p.Bucket)
+		switch {
+		case p.Type == "overflow":
+			owner = fmt.Sprintf("%s (of %d)", owner, p.Parent)
+		case !p.Owned:
+			owner = "-"
+		case p.Bucket == "" && (p.Type == "branch" || p.Type == "leaf"):
+			owner = "<root>"
+		case p.Bucket == "":
+			owner = ""
+		}
+		fmt.Fprintf(&sb, "%-8d %-9s %6d %8d  %s\n", p.ID, p.Type, p.Count, p.Overflow, owner)
+	}
+	for _, b := range r.Buckets {
+		inline := ""
+		if b.Inline {
+			inline = ", inline"
+		}
+		fmt.Fprintf(&sb, "bucket %q: %d pages, %d keys%s\n", b.Name, len(b.Pages), b.Keys, inline)
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
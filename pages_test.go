@@ -0,0 +1,60 @@
+package zbolt
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	tx.Put([]byte("big"), []byte("k"), bytes.Repeat([]byte{1}, 20000))
+	tx.Put([]byte("small"), []byte("a"), []byte("1"), []byte("b"), []byte("2"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx = d.NewTx(true)
+	tx.Delete([]byte("small"), []byte("b"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	r, err := InspectPages(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var big, small *BucketPages
+	for i := range r.Buckets {
+		switch r.Buckets[i].Name {
+		case "big":
+			big = &r.Buckets[i]
+		case "small":
+			small = &r.Buckets[i]
+		}
+	}
+	if big == nil || small == nil || big.Keys != 1 || small.Keys != 1 || !small.Inline {
+		t.Fatal("unexpected buckets", r.Buckets)
+	}
+	if len(big.Pages) < 2 {
+		t.Fatal("overflow pages not owned by bucket", big.Pages)
+	}
+	for _, id := range big.Pages[1:] {
+		if p := r.Pages[id]; p.Type != "overflow" || p.Bucket != "big" {
+			t.Fatal("unexpected overflow page", p)
+		}
+	}
+	if len(r.Free) == 0 {
+		t.Fatal("expect free pages after update")
+	}
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil || !strings.Contains(buf.String(), `bucket "big"`) {
+		t.Fatal("unexpected output", buf.String(), err)
+	}
+}
@@ -0,0 +1,207 @@
+package zbolt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// PageToken opaque continuation token of a paginated read, empty for the first page and when no page is left.
+// It encodes the last key returned and the direction, sealed when a key is set by SetPageTokenKey
+type PageToken string
+
+// kinds of paginated reads, a token is only accepted by the kind of read returning it
+const (
+	pageNext byte = iota + 1
+	pagePrev
+	pageSortNext
+	pageSortPrev
+)
+
+// pageTokenVersion version of encoding of page tokens
+const pageTokenVersion byte = 1
+
+// SetPageTokenKey seal page tokens with AES-GCM key of 16, 24 or 32 bytes so clients can not read or forge them,
+// nil key only encode them. Tokens issued before the key changes are rejected
+func (db *DB) SetPageTokenKey(key []byte) error {
+	var aead cipher.AEAD
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		if aead, err = cipher.NewGCM(block); err != nil {
+			return err
+		}
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tokenKey = aead
+	return nil
+}
+
+// NextPage get limit count key value after token like Next, limit must be > 0. Return token of the following page
+func (tx *Tx) NextPage(name []byte, token PageToken, limit int) ([][]byte, PageToken, error) {
+	return tx.plainPage(name, token, limit, pageNext)
+}
+
+// PrevPage get limit count key value front token like Prev, from the last key, limit must be > 0.
+// Return token of the following page
+func (tx *Tx) PrevPage(name []byte, token PageToken, limit int) ([][]byte, PageToken, error) {
+	return tx.plainPage(name, token, limit, pagePrev)
+}
+
+// plainPage get page of bucket in direction of kind
+func (tx *Tx) plainPage(name []byte, token PageToken, limit int, kind byte) ([][]byte, PageToken, error) {
+	if tx.err != nil {
+		return [][]byte{}, "", tx.err
+	}
+	if limit <= 0 {
+		return [][]byte{}, "", tx.Error(fmt.Errorf("%w: limit must be > 0", ErrInvalidToken))
+	}
+	key, err := tx.db.openPageToken(token, kind)
+	if err != nil {
+		return [][]byte{}, "", err
+	}
+	if token != "" && len(key) == 0 {
+		return [][]byte{}, "", nil
+	}
+	var kvs [][]byte
+	if kind == pageNext {
+		kvs = tx.Next(name, key, limit+1)
+	} else {
+		kvs = tx.Prev(name, key, limit+1)
+	}
+	if tx.err != nil || len(kvs) <= 2*limit {
+		return kvs, "", tx.err
+	}
+	kvs = kvs[:2*limit]
+	next, err := tx.db.sealPageToken(kind, kvs[len(kvs)-2])
+	return kvs, next, err
+}
+
+// SortNextPage get limit count entries of bucket with sort after token in order of sort keys, limit must be > 0.
+// Entries with equal sort keys are never skipped across pages. Return token of the following page
+func (tx *Tx) SortNextPage(name []byte, token PageToken, limit int) ([]Entry, PageToken, error) {
+	return tx.sortPage(name, token, limit, pageSortNext)
+}
+
+// SortPrevPage get limit count entries of bucket with sort front token in reverse order of sort keys, from the last one.
+// Return token of the following page
+func (tx *Tx) SortPrevPage(name []byte, token PageToken, limit int) ([]Entry, PageToken, error) {
+	return tx.sortPage(name, token, limit, pageSortPrev)
+}
+
+// sortPage get page of bucket with sort in direction of kind, token hold the stored key of the last entry
+func (tx *Tx) sortPage(name []byte, token PageToken, limit int, kind byte) ([]Entry, PageToken, error) {
+	entries := []Entry{}
+	if tx.err != nil {
+		return entries, "", tx.err
+	}
+	if limit <= 0 {
+		return entries, "", tx.Error(fmt.Errorf("%w: limit must be > 0", ErrInvalidToken))
+	}
+	last, err := tx.db.openPageToken(token, kind)
+	if err != nil {
+		return entries, "", err
+	}
+	b := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	if b == nil || token != "" && len(last) == 0 {
+		return entries, "", nil
+	}
+	o, fields := tx.sortLayout(name)
+	forward := kind == pageSortNext
+	c := b.Cursor()
+	var k, v []byte
+	switch {
+	case len(last) == 0 && forward:
+		k, v = c.First()
+	case len(last) == 0:
+		k, v = c.Last()
+	case forward:
+		if k, v = c.Seek(last); bytes.Equal(k, last) {
+			k, v = c.Next()
+		}
+	default:
+		if k, v = c.Seek(last); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+	var next []byte
+	for ; k != nil; k, v = step(c, forward) {
+		if tx.canceled() {
+			return []Entry{}, "", tx.err
+		}
+		e, ok := o.entry(k, v, fields)
+		if !ok {
+			continue
+		}
+		if len(entries) == limit {
+			next = last
+			break
+		}
+		entries = append(entries, e)
+		last = k
+	}
+	if next == nil {
+		return entries, "", nil
+	}
+	t, err := tx.db.sealPageToken(kind, next)
+	return entries, t, err
+}
+
+// step move cursor forward or backward
+func step(c backendCursor, forward bool) ([]byte, []byte) {
+	if forward {
+		return c.Next()
+	}
+	return c.Prev()
+}
+
+// sealPageToken encode key and kind of read into token, sealed by page token key if set
+func (db *DB) sealPageToken(kind byte, key []byte) (PageToken, error) {
+	b := BytesConcat([]byte{pageTokenVersion, kind}, key)
+	db.mu.RLock()
+	aead := db.tokenKey
+	db.mu.RUnlock()
+	if aead != nil {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		b = aead.Seal(nonce, nonce, b, nil)
+	}
+	return PageToken(base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// openPageToken decode key of token issued for kind of read, nil for empty token.
+// Return ErrInvalidToken if token is malformed, forged or issued by another kind of read
+func (db *DB) openPageToken(token PageToken, kind byte) ([]byte, error) {
+	if token == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(string(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	db.mu.RLock()
+	aead := db.tokenKey
+	db.mu.RUnlock()
+	if aead != nil {
+		if len(b) < aead.NonceSize() {
+			return nil, ErrInvalidToken
+		}
+		if b, err = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	if len(b) < 3 || b[0] != pageTokenVersion || b[1] != kind {
+		return nil, ErrInvalidToken
+	}
+	return b[2:], nil
+}
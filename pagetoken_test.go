@@ -0,0 +1,106 @@
+package zbolt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTx_NextPage(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.SetPageTokenKey([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	name := []byte("pages")
+	err = db.Update(func(tx *Tx) error {
+		for i := 0; i < 5; i++ {
+			k := []byte(fmt.Sprint(i))
+			tx.Put(name, k, k)
+			// equal sort keys must not be skipped across pages
+			tx.SortPut(name, []byte("s"), k, k)
+		}
+		return tx.Error()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := db.NewTx(false)
+	defer tx.Rollback()
+	var keys string
+	var token PageToken
+	for {
+		kvs, next, err := tx.NextPage(name, token, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			keys += string(kvs[i])
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if keys != "01234" {
+		t.Fatal("unexpected next pages", keys)
+	}
+	if _, _, err := tx.PrevPage(name, token, 2); !errors.Is(err, ErrInvalidToken) {
+		t.Fatal("expect token of NextPage refused by PrevPage", err)
+	}
+	if _, _, err := tx.NextPage(name, forge(token), 2); !errors.Is(err, ErrInvalidToken) {
+		t.Fatal("expect forged token refused", err)
+	}
+	keys, token = "", ""
+	for {
+		kvs, next, err := tx.PrevPage(name, token, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			keys += string(kvs[i])
+		}
+		if token = next; token == "" {
+			break
+		}
+	}
+	if keys != "43210" {
+		t.Fatal("unexpected prev pages", keys)
+	}
+	for _, forward := range []bool{true, false} {
+		keys, token = "", ""
+		for {
+			page := tx.SortPrevPage
+			if forward {
+				page = tx.SortNextPage
+			}
+			entries, next, err := page(name, token, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				keys += string(e.Key)
+			}
+			if token = next; token == "" {
+				break
+			}
+		}
+		if forward && keys != "01234" || !forward && keys != "43210" {
+			t.Fatal("unexpected sort pages", forward, keys)
+		}
+	}
+}
+
+// forge flip a byte in the middle of token
+func forge(token PageToken) PageToken {
+	b := []byte(token)
+	if b[4] == 'A' {
+		b[4] = 'B'
+	} else {
+		b[4] = 'A'
+	}
+	return PageToken(b)
+}
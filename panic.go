@@ -0,0 +1,50 @@
+package zbolt
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError panic recovered from closure of View or Update, the transaction is rolled back
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error message with panic value and stack trace
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in transaction: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// SetRepanic panic again after rolling back instead of returning PanicError from View and Update
+func (db *DB) SetRepanic(enabled bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.repanic = enabled
+}
+
+// call run fn with tx, recover panic of fn as PanicError after rolling back tx
+func (db *DB) call(tx *Tx, fn func(tx *Tx) error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		stack := debug.Stack()
+		tx.Rollback()
+		db.mu.RLock()
+		repanic := db.repanic
+		db.mu.RUnlock()
+		if repanic {
+			panic(r)
+		}
+		err = &PanicError{Value: r, Stack: stack}
+	}()
+	return fn(tx)
+}
@@ -0,0 +1,93 @@
+package zbolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+)
+
+// DiffSummary cheap summary of bucket snapshot which differ from the previous one
+type DiffSummary struct {
+	Bucket   []byte
+	Count    int    // count of keys
+	Delta    int    // change of count since previous summary
+	LastKey  []byte // greatest key, nil if bucket is empty
+	Checksum uint64 // fnv-1a of keys and values
+	At       time.Time
+}
+
+// PollChanges summarize bucket every interval and send a summary when it differ from the previous one,
+// the first snapshot is the baseline. Channel is closed when db is shut down or closed
+func (db *DB) PollChanges(bucket []byte, interval time.Duration) <-chan DiffSummary {
+	return db.PollChangesContext(context.Background(), bucket, interval)
+}
+
+// PollChangesContext poll like PollChanges until ctx is done
+func (db *DB) PollChangesContext(ctx context.Context, bucket []byte, interval time.Duration) <-chan DiffSummary {
+	out := make(chan DiffSummary)
+	stop, done := make(chan struct{}), make(chan struct{})
+	unregister := db.RegisterWorker("poll", func(ctx context.Context) error {
+		close(stop)
+		<-done
+		return nil
+	})
+	go func() {
+		defer close(done)
+		defer close(out)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		prev, err := db.summarize(bucket)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				unregister()
+				return
+			case <-ticker.C:
+			}
+			cur, cerr := db.summarize(bucket)
+			if cerr != nil {
+				continue
+			}
+			if err == nil && cur.Checksum == prev.Checksum && cur.Count == prev.Count {
+				continue
+			}
+			cur.Delta = cur.Count - prev.Count
+			prev, err = cur, nil
+			select {
+			case out <- cur:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				unregister()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// summarize summary of bucket in a read only snapshot
+func (db *DB) summarize(bucket []byte) (DiffSummary, error) {
+	s := DiffSummary{Bucket: append([]byte{}, bucket...), At: time.Now()}
+	h := fnv.New64a()
+	var n [binary.MaxVarintLen64]byte
+	err := db.View(func(tx *Tx) error {
+		return tx.ForEach(bucket, func(k, v []byte) error {
+			s.Count++
+			if bytes.Compare(k, s.LastKey) > 0 {
+				s.LastKey = append(s.LastKey[:0], k...)
+			}
+			h.Write(n[:binary.PutUvarint(n[:], uint64(len(k)))])
+			h.Write(k)
+			h.Write(n[:binary.PutUvarint(n[:], uint64(len(v)))])
+			h.Write(v)
+			return nil
+		})
+	})
+	s.Checksum = h.Sum64()
+	return s, err
+}
@@ -0,0 +1,42 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_PollChanges(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []byte("users")
+	tx := d.NewTx(true)
+	tx.Put(users, []byte("u1"), []byte("alice"))
+	tx.Commit()
+	changes := d.PollChanges(users, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	tx = d.NewTx(true)
+	tx.Put(users, []byte("u2"), []byte("bob"))
+	tx.Commit()
+	select {
+	case s := <-changes:
+		if s.Count != 2 || s.Delta != 1 || string(s.LastKey) != "u2" {
+			t.Fatal("unexpected summary", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("change not polled")
+	}
+
+	tx = d.NewTx(true)
+	tx.Put(users, []byte("u1"), []byte("carol"))
+	tx.Commit()
+	if s := <-changes; s.Count != 2 || s.Delta != 0 {
+		t.Fatal("unexpected summary of update", s)
+	}
+	d.Close()
+	if _, ok := <-changes; ok {
+		t.Fatal("changes not closed on close")
+	}
+}
@@ -0,0 +1,94 @@
+package zbolt
+
+import (
+	"time"
+)
+
+// pqFields sort key of priority queue, priority then time the item becomes visible
+var pqFields = SortFields{{Kind: FieldUint64}, {Kind: FieldTime}}
+
+// PQItem item of priority queue
+type PQItem struct {
+	ID        uint64
+	Priority  uint64
+	NotBefore time.Time
+	Payload   []byte
+}
+
+// PQ durable priority queue whose items are invisible until their time, stored in bucket with sort.
+// Items are popped by lowest priority, then earliest time, then order of push
+type PQ struct {
+	db   *DB
+	name []byte
+}
+
+// PQ get priority queue stored in bucket name
+func (db *DB) PQ(name []byte) *PQ {
+	db.SetSortFields(name, pqFields)
+	return &PQ{db: db, name: name}
+}
+
+// Push add payload with priority, visible to PopReady from notBefore on, return id of the item
+func (q *PQ) Push(priority uint64, notBefore time.Time, payload []byte) (uint64, error) {
+	sortKey, err := pqFields.Key(priority, notBefore)
+	if err != nil {
+		return 0, err
+	}
+	var id uint64
+	err = q.db.Update(func(tx *Tx) error {
+		if id, err = tx.NextSequence(q.name); err != nil {
+			return err
+		}
+		return tx.SortPut(q.name, sortKey, Uint64ToBytes(id), payload)
+	})
+	return id, err
+}
+
+// PopReady remove and return at most limit items visible at now in priority order, limit = 0 representative of all
+func (q *PQ) PopReady(now time.Time, limit int) ([]PQItem, error) {
+	items := []PQItem{}
+	err := q.db.Update(func(tx *Tx) error {
+		var keys [][]byte
+		var cursor SortCursor
+		for {
+			var entries []Entry
+			entries, cursor = tx.SortPage(q.name, cursor, 100)
+			for _, e := range entries {
+				if len(e.Fields) != len(pqFields) || e.Fields[1].(time.Time).After(now) {
+					continue
+				}
+				items = append(items, PQItem{
+					ID:        BytesToUint64(e.Key),
+					Priority:  e.Fields[0].(uint64),
+					NotBefore: e.Fields[1].(time.Time),
+					Payload:   append([]byte{}, e.Value...),
+				})
+				keys = append(keys, append([]byte{}, e.Key...))
+				if limit > 0 && len(items) >= limit {
+					break
+				}
+			}
+			if cursor == nil || limit > 0 && len(items) >= limit {
+				break
+			}
+		}
+		if tx.err != nil || len(keys) == 0 {
+			return tx.err
+		}
+		return tx.SortDelete(q.name, keys...)
+	})
+	if err != nil {
+		return []PQItem{}, err
+	}
+	return items, nil
+}
+
+// Len count items of queue, visible or not
+func (q *PQ) Len() (int, error) {
+	var n int
+	err := q.db.View(func(tx *Tx) error {
+		n = tx.SortCount(q.name)
+		return nil
+	})
+	return n, err
+}
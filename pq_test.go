@@ -0,0 +1,53 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPQ(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	q := d.PQ([]byte("jobs"))
+	now := time.Now()
+	for _, item := range []struct {
+		priority uint64
+		delay    time.Duration
+		payload  string
+	}{
+		{2, 0, "b"},
+		{1, time.Hour, "delayed"},
+		{1, 0, "a"},
+		{2, -time.Minute, "early"},
+		{3, 0, "c"},
+	} {
+		if _, err := q.Push(item.priority, now.Add(item.delay), []byte(item.payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pop := func(at time.Time, limit int) (s string) {
+		items, err := q.PopReady(at, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			s += string(item.Payload) + ","
+		}
+		return s
+	}
+	if s := pop(now, 3); s != "a,early,b," {
+		t.Fatal("unexpected pop", s)
+	}
+	if s := pop(now, 0); s != "c," {
+		t.Fatal("unexpected pop", s)
+	}
+	if n, _ := q.Len(); n != 1 {
+		t.Fatal("delayed item must stay", n)
+	}
+	if s := pop(now.Add(2*time.Hour), 0); s != "delayed," {
+		t.Fatal("unexpected pop", s)
+	}
+}
@@ -0,0 +1,52 @@
+package zbolt
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// tags of stored keys of bucket with key prefix, keep keys without prefix ordered around the prefixed ones
+const (
+	prefixBefore byte = iota // key less than prefix, stored as is
+	prefixStrip              // key with prefix, stored without it
+	prefixAfter              // key greater than prefix, stored as is
+)
+
+// keyPrefix common prefix of keys stripped before storing
+type keyPrefix []byte
+
+// SetKeyPrefix store keys of bucket starting with prefix without it, shrinking files where keys share a long prefix.
+// Keys without the prefix are still allowed and order is kept. Prefix must be set before the bucket is written, nil disable it
+func (db *DB) SetKeyPrefix(name, prefix []byte) {
+	if len(prefix) == 0 {
+		prefix = nil
+	}
+	db.setConfig(name, func(c *bucketConfig) {
+		c.prefix = keyPrefix(append([]byte(nil), prefix...))
+	})
+	if prefix != nil {
+		atomic.StoreInt32(&db.wrapped, 1)
+	}
+}
+
+// encode stored key of original key, like [tag, key without prefix]
+func (p keyPrefix) encode(key []byte) []byte {
+	switch {
+	case bytes.HasPrefix(key, p):
+		return BytesConcat([]byte{prefixStrip}, key[len(p):])
+	case bytes.Compare(key, p) < 0:
+		return BytesConcat([]byte{prefixBefore}, key)
+	}
+	return BytesConcat([]byte{prefixAfter}, key)
+}
+
+// decode original key of stored key
+func (p keyPrefix) decode(k []byte) []byte {
+	switch {
+	case len(k) == 0:
+		return k
+	case k[0] == prefixStrip:
+		return BytesConcat(p, k[1:])
+	}
+	return k[1:]
+}
@@ -0,0 +1,44 @@
+package zbolt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDB_SetKeyPrefix(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("prefix_urls")
+	prefix := "https://example.com/tenant/"
+	d.SetKeyPrefix(name, []byte(prefix))
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for _, k := range []string{prefix + "b", "a", prefix + "a", "z", prefix + "c"} {
+		tx.Put(name, []byte(k), []byte("v"))
+	}
+	var keys []string
+	tx.ForEach(name, func(k, v []byte) error {
+		keys = append(keys, strings.TrimPrefix(string(k), prefix))
+		return nil
+	})
+	if fmt.Sprint(keys) != "[a a b c z]" {
+		t.Fatal("unexpected order", keys)
+	}
+	if gets := tx.Get(name, []byte(prefix+"b")); len(gets) != 2 || string(gets[0]) != prefix+"b" {
+		t.Fatalf("unexpected get %q", gets)
+	}
+	if next := tx.Next(name, []byte(prefix+"a"), 1); len(next) != 2 || string(next[0]) != prefix+"b" {
+		t.Fatalf("unexpected next %q", next)
+	}
+	if prev := tx.Prev(name, []byte(prefix+"a"), 1); len(prev) != 2 || string(prev[0]) != "a" {
+		t.Fatalf("unexpected prev %q", prev)
+	}
+	// stored keys are stripped
+	if k, _ := tx.tx.(keyTx).backendTx.Bucket(name).Cursor().Seek([]byte{prefixStrip}); string(k) != "\x01a" {
+		t.Fatalf("prefix not stripped %q", k)
+	}
+}
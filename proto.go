@@ -0,0 +1,75 @@
+package zbolt
+
+import (
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoKV key and protobuf message value
+type ProtoKV struct {
+	Key     []byte
+	Message proto.Message
+}
+
+// PutProto put protobuf message m as value of key
+func (tx *Tx) PutProto(name, key []byte, m proto.Message) error {
+	return tx.PutProtos(name, ProtoKV{Key: key, Message: m})
+}
+
+// PutProtos put protobuf messages of keys
+func (tx *Tx) PutProtos(name []byte, kvs ...ProtoKV) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	bs := make([][]byte, 0, 2*len(kvs))
+	for _, kv := range kvs {
+		b, err := proto.Marshal(kv.Message)
+		if err != nil {
+			return tx.Error(err)
+		}
+		bs = append(bs, kv.Key, b)
+	}
+	return tx.Put(name, bs...)
+}
+
+// GetProto unmarshal value of key into m, return ErrRecordNotFound if key not exist
+func (tx *Tx) GetProto(name, key []byte, m proto.Message) error {
+	gets := tx.Get(name, key)
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(gets) != 2 {
+		return ErrRecordNotFound
+	}
+	return tx.Error(proto.Unmarshal(gets[1], m))
+}
+
+// GetProtos get values of keys unmarshalled into messages created by factory, keys not exist are skipped
+func (tx *Tx) GetProtos(name []byte, keys [][]byte, factory func() proto.Message) ([]ProtoKV, error) {
+	return tx.protoKVs(tx.Get(name, keys...), factory)
+}
+
+// SortNextProto like SortNext with values unmarshalled into messages created by factory
+func (tx *Tx) SortNextProto(name []byte, key []byte, limit int, factory func() proto.Message) ([]ProtoKV, error) {
+	return tx.protoKVs(tx.SortNext(name, key, limit), factory)
+}
+
+// SortPrevProto like SortPrev with values unmarshalled into messages created by factory
+func (tx *Tx) SortPrevProto(name []byte, key []byte, limit int, factory func() proto.Message) ([]ProtoKV, error) {
+	return tx.protoKVs(tx.SortPrev(name, key, limit), factory)
+}
+
+// protoKVs unmarshal [key1,value1,key2,value2, ...]
+func (tx *Tx) protoKVs(kvs [][]byte, factory func() proto.Message) ([]ProtoKV, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	res := make([]ProtoKV, 0, len(kvs)/2)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		m := factory()
+		if err := proto.Unmarshal(kvs[i+1], m); err != nil {
+			return nil, tx.Error(err)
+		}
+		res = append(res, ProtoKV{Key: kvs[i], Message: m})
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package zbolt
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestTx_PutProto(t *testing.T) {
+	name := []byte("proto_names")
+	factory := func() proto.Message { return &wrapperspb.StringValue{} }
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.PutProto(name, []byte("k1"), wrapperspb.String("alice")); err != nil {
+		t.Fatal(err)
+	}
+	tx.PutProtos(name, ProtoKV{[]byte("k2"), wrapperspb.String("bob")})
+	var m wrapperspb.StringValue
+	if err := tx.GetProto(name, []byte("k2"), &m); err != nil || m.Value != "bob" {
+		t.Fatal("unexpected get", m.Value, err)
+	}
+	if err := tx.GetProto(name, []byte("k9"), &m); err != ErrRecordNotFound {
+		t.Fatal("expect ErrRecordNotFound, got", err)
+	}
+	if kvs, err := tx.GetProtos(name, [][]byte{[]byte("k1"), []byte("k9"), []byte("k2")}, factory); err != nil || len(kvs) != 2 ||
+		kvs[1].Message.(*wrapperspb.StringValue).Value != "bob" {
+		t.Fatal("unexpected multi get", kvs, err)
+	}
+
+	timeline := []byte("proto_timeline")
+	for i, s := range []string{"a", "b", "c"} {
+		b, _ := proto.Marshal(wrapperspb.String(s))
+		tx.SortPut(timeline, Uint64ToBytes(uint64(i+1)), []byte(s), b)
+	}
+	page, err := tx.SortNextProto(timeline, Uint64ToBytes(1), 2, factory)
+	if err != nil || len(page) != 2 || string(page[0].Key) != "b" || page[1].Message.(*wrapperspb.StringValue).Value != "c" {
+		t.Fatal("unexpected page", page, err)
+	}
+	if page, _ := tx.SortPrevProto(timeline, nil, 1, factory); len(page) != 1 || string(page[0].Key) != "c" {
+		t.Fatal("unexpected prev page", page)
+	}
+}
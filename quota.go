@@ -0,0 +1,27 @@
+package zbolt
+
+// Quota limits of a bucket, 0 representative of no limit
+type Quota struct {
+	MaxKeys  int64
+	MaxBytes int64 // bytes of keys and values
+}
+
+// SetQuota limit keys and bytes of bucket, Put and SortPut growing it over the limits fail with ErrBucketFull.
+// Enable fast count of bucket if not enabled yet, nil quota remove limits
+func (db *DB) SetQuota(name []byte, q *Quota) error {
+	if q != nil && db.config(name).count == nil {
+		if err := db.EnableFastCount(name); err != nil {
+			return err
+		}
+	}
+	db.setConfig(name, func(c *bucketConfig) {
+		c.quota = q
+	})
+	return nil
+}
+
+// exceeded check a write changing count by d grow it over quota, pending are changes of the transaction not committed yet
+func (q *Quota) exceeded(count, pending, d BucketCount) bool {
+	return (q.MaxKeys > 0 && d.Keys > 0 && count.Keys+pending.Keys+d.Keys > q.MaxKeys) ||
+		(q.MaxBytes > 0 && d.Bytes > 0 && count.Bytes+pending.Bytes+d.Bytes > q.MaxBytes)
+}
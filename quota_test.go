@@ -0,0 +1,60 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestDB_SetQuota(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name, timeline := []byte("uploads"), []byte("feed")
+	d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k1"), []byte("v1"))
+	})
+	if err := d.SetQuota(name, &Quota{MaxKeys: 3, MaxBytes: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k2"), []byte("v2"), []byte("k3"), []byte("v3"), []byte("k4"), []byte("v4"))
+	}); err != ErrBucketFull {
+		t.Fatal("expect ErrBucketFull of keys, got", err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		// overwrite and delete do not grow key count
+		tx.Put(name, []byte("k1"), []byte("x1"), []byte("k2"), []byte("v2"), []byte("k3"), []byte("v3"))
+		tx.Delete(name, []byte("k3"))
+		return tx.Put(name, []byte("k4"), []byte("v4"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k1"), make([]byte, 100))
+	}); err != ErrBucketFull {
+		t.Fatal("expect ErrBucketFull of bytes, got", err)
+	}
+	if c, _ := d.FastCount(name); c.Keys != 3 {
+		t.Fatal("unexpected count", c)
+	}
+
+	d.SetQuota(timeline, &Quota{MaxKeys: 2})
+	if err := d.Update(func(tx *Tx) error {
+		tx.SortPut(timeline, Uint64ToBytes(1), []byte("e1"), []byte("v"), []byte("e2"), []byte("v"))
+		return tx.SortPut(timeline, Uint64ToBytes(2), []byte("e1"), []byte("v"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Update(func(tx *Tx) error {
+		return tx.SortPut(timeline, Uint64ToBytes(3), []byte("e3"), []byte("v"))
+	}); err != ErrBucketFull {
+		t.Fatal("expect ErrBucketFull of sort bucket, got", err)
+	}
+	d.Update(func(tx *Tx) error {
+		return tx.SortDelete(timeline, []byte("e1"))
+	})
+	if c, _ := d.FastCount(timeline); c.Keys != 1 {
+		t.Fatal("unexpected sort count", c)
+	}
+}
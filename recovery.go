@@ -0,0 +1,178 @@
+package zbolt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/boltdb/bolt"
+	"go.etcd.io/bbolt"
+)
+
+// RecoveryReport what OpenWithRecovery did to open the file
+type RecoveryReport struct {
+	Recovered         bool   // file could not be opened as is
+	UsedAlternateMeta bool   // broken meta page was replaced by the other one, original file kept as Path.corrupt
+	Salvaged          bool   // meta pages unusable, data scanned from leaf pages into a new file
+	Path              string // path of the opened file
+	Buckets           int    // salvaged buckets
+	Keys              int    // salvaged keys
+	Duplicates        int    // salvaged bucket names found more than once, the copy with most keys is kept
+}
+
+// String summary of report
+func (r *RecoveryReport) String() string {
+	switch {
+	case r.Salvaged:
+		return fmt.Sprintf("salvaged %d keys in %d buckets into %s, %d duplicate buckets dropped", r.Keys, r.Buckets, r.Path, r.Duplicates)
+	case r.UsedAlternateMeta:
+		return fmt.Sprintf("recovered %s using alternate meta page", r.Path)
+	}
+	return fmt.Sprintf("opened %s without recovery", r.Path)
+}
+
+// openSafe open file and turn panics of bolt into errors
+func openSafe(path string) (db *DB, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			db, err = nil, fmt.Errorf("%w: %v", ErrInvalidFile, r)
+		}
+	}()
+	return Open(path)
+}
+
+// corrupted check error of opening file is caused by broken pages, not by a lock timeout or an IO error
+func corrupted(err error) bool {
+	for _, target := range []error{ErrInvalidFile, bolt.ErrInvalid, bolt.ErrChecksum, bolt.ErrVersionMismatch,
+		bbolt.ErrInvalid, bbolt.ErrChecksum, bbolt.ErrVersionMismatch} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenWithRecovery open file like Open, when it is unreadable because of broken meta pages
+// rewrite the broken meta page with the valid one, or if none is valid scan leaf pages into file path.recovered and open it.
+// Errors not caused by broken pages, like ErrTimeout when file is held by another handle, are returned unchanged
+func OpenWithRecovery(path string) (*DB, *RecoveryReport, error) {
+	report := &RecoveryReport{Path: path}
+	db, err := openSafe(path)
+	if err == nil || !corrupted(err) {
+		return db, report, err
+	}
+	report.Recovered = true
+	data, rerr := ioutil.ReadFile(path)
+	if rerr != nil {
+		return nil, report, rerr
+	}
+	f := newRawFile(data)
+	m0, m1 := f.meta(0), f.meta(1)
+	if (m0.err == nil) != (m1.err == nil) {
+		if db, err := f.fixMeta(path, m0.err == nil); err == nil {
+			report.UsedAlternateMeta = true
+			return db, report, nil
+		}
+	}
+	report.Salvaged = true
+	report.Path = path + ".recovered"
+	if err := os.Remove(report.Path); err != nil && !os.IsNotExist(err) {
+		return nil, report, err
+	}
+	db, err = Open(report.Path)
+	if err != nil {
+		return nil, report, err
+	}
+	if err := f.salvage(db, report); err != nil {
+		db.Close()
+		return nil, report, err
+	}
+	return db, report, nil
+}
+
+// fixMeta copy valid meta page over the broken one, keep original file as path.corrupt, then open it
+func (f *rawFile) fixMeta(path string, meta0Valid bool) (*DB, error) {
+	if err := ioutil.WriteFile(path+".corrupt", f.data, 0600); err != nil {
+		return nil, err
+	}
+	good, bad := uint64(1), uint64(0)
+	if meta0Valid {
+		good, bad = 0, 1
+	}
+	page := make([]byte, f.pageSize)
+	copy(page, f.data[good*uint64(f.pageSize):])
+	binary.LittleEndian.PutUint64(page, bad)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteAt(page, int64(bad)*int64(f.pageSize)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return openSafe(path)
+}
+
+// salvagedBucket bucket found while scanning leaf pages
+type salvagedBucket struct {
+	sequence uint64
+	kvs      [][]byte
+}
+
+// salvage scan all leaf pages for buckets and write their key values to db
+func (f *rawFile) salvage(db *DB, report *RecoveryReport) error {
+	buckets := make(map[string]*salvagedBucket)
+	for id := uint64(2); id < f.pageCount(); id++ {
+		p, ok := f.page(id)
+		if !ok || p.id != id || p.flags&leafPageFlag == 0 {
+			continue
+		}
+		for _, e := range p.elements() {
+			if e.flags&bucketLeafFlag == 0 || len(e.value) < bucketHeaderSize || len(e.key) == 0 {
+				continue
+			}
+			b := &salvagedBucket{sequence: binary.LittleEndian.Uint64(e.value[8:])}
+			root := binary.LittleEndian.Uint64(e.value)
+			f.walk(root, e.value[bucketHeaderSize:], make(map[uint64]bool), func(kv rawElement) {
+				if kv.flags&bucketLeafFlag == 0 && len(kv.key) > 0 {
+					b.kvs = append(b.kvs, kv.key, kv.value)
+				}
+			})
+			if old := buckets[string(e.key)]; old != nil {
+				report.Duplicates++
+				if len(old.kvs) >= len(b.kvs) {
+					continue
+				}
+			}
+			buckets[string(e.key)] = b
+		}
+	}
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	for name, b := range buckets {
+		bucket, err := tx.tx.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(b.kvs); i += 2 {
+			if err := bucket.Put(b.kvs[i], b.kvs[i+1]); err != nil {
+				return err
+			}
+		}
+		if err := bucket.SetSequence(b.sequence); err != nil {
+			return err
+		}
+		report.Buckets++
+		report.Keys += len(b.kvs) / 2
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,126 @@
+package zbolt
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createTestFile(t *testing.T, n int) string {
+	path := filepath.Join(t.TempDir(), "recovery.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := d.NewTx(true)
+	for i := 0; i < n; i++ {
+		tx.Put([]byte("users"), []byte(fmt.Sprintf("key%05d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	tx.Put([]byte("small"), []byte("a"), []byte("1"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// corruptPage overwrite page id of file with garbage
+func corruptPage(t *testing.T, path string, id int64, pageSize int64) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	garbage := make([]byte, 64)
+	for i := range garbage {
+		garbage[i] = 0xAB
+	}
+	if _, err := f.WriteAt(garbage, id*pageSize+pageHeaderSize); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenWithRecovery_AlternateMeta(t *testing.T) {
+	path := createTestFile(t, 10)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// keep the meta page holding the last commit
+	f := newRawFile(data)
+	meta0Valid := f.meta(0).txid > f.meta(1).txid
+	if meta0Valid {
+		corruptPage(t, path, 1, 4096)
+	} else {
+		corruptPage(t, path, 0, 4096)
+	}
+	d, report, err := OpenWithRecovery(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Salvaged {
+		t.Fatal("unexpected salvage", report)
+	}
+	tx := d.NewTx(false)
+	if gets := tx.Get([]byte("small"), []byte("a")); len(gets) != 2 {
+		t.Fatal("data lost", gets)
+	}
+	tx.Rollback()
+	d.Close()
+
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = newRawFile(data).fixMeta(path, meta0Valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	data, _ = ioutil.ReadFile(path)
+	if f = newRawFile(data); f.meta(0).err != nil || f.meta(1).err != nil {
+		t.Fatal("meta page not fixed", f.meta(0).err)
+	}
+}
+
+func TestOpenWithRecovery_Salvage(t *testing.T) {
+	path := createTestFile(t, 1000)
+	corruptPage(t, path, 0, 4096)
+	corruptPage(t, path, 1, 4096)
+	if _, err := Open(path); err == nil {
+		t.Fatal("expect open broken file fail")
+	}
+	d, report, err := OpenWithRecovery(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if !report.Salvaged || report.Buckets != 3 || report.Keys != 1002 { // include meta bucket
+		t.Fatal("unexpected report", report)
+	}
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	if gets := tx.Get([]byte("users"), []byte("key00999")); len(gets) != 2 || string(gets[1]) != "value999" {
+		t.Fatal("salvaged data missing", gets)
+	}
+}
+
+func TestOpenWithRecovery_Locked(t *testing.T) {
+	path := createTestFile(t, 10)
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, report, err := OpenWithRecovery(path); !errors.Is(err, ErrTimeout) || report.Recovered {
+		t.Fatal("expect ErrTimeout without recovery, got", err, report)
+	}
+	if _, err := os.Stat(path + ".recovered"); !os.IsNotExist(err) {
+		t.Fatal("healthy file must not be salvaged", err)
+	}
+}
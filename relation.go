@@ -0,0 +1,171 @@
+package zbolt
+
+import (
+	"bytes"
+)
+
+// KeyMapper map a child record to the parent key it references, return nil if no reference.
+// value is decoded like values returned by Get
+type KeyMapper func(key, value []byte) []byte
+
+// RelationOptions options of Relate
+type RelationOptions struct {
+	// Cascade delete children together with parent, otherwise Delete on parent fail with ErrHasChildren
+	Cascade bool
+	// Enforce Put on child fail with ErrForeignKey if the referenced parent key not exist
+	Enforce bool
+}
+
+// Relation declared relation between parent bucket and child bucket, read by every Put and Delete so never changed
+type Relation struct {
+	parent    []byte
+	child     []byte
+	keyMapper KeyMapper
+	opts      RelationOptions
+}
+
+// Relate declare child bucket records reference parent bucket keys, keyMapper return the parent key of a child record.
+// Delete on parent fail with ErrHasChildren unless opts set Cascade, nil opts is the default.
+// Child bucket can be plain bucket or Sort* layout bucket. Delete on parent scan the whole child bucket and its
+// sort bucket to find children, so its cost grow with the size of child bucket
+func (db *DB) Relate(parent, child []byte, keyMapper KeyMapper, opts *RelationOptions) *Relation {
+	r := &Relation{parent: parent, child: child, keyMapper: keyMapper}
+	if opts != nil {
+		r.opts = *opts
+	}
+	db.mu.Lock()
+	db.relations = append(db.relations, r)
+	db.mu.Unlock()
+	return r
+}
+
+// relationsOf get relations which parent is name
+func (db *DB) relationsOf(name []byte) []*Relation {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var rs []*Relation
+	for _, r := range db.relations {
+		if bytes.Equal(r.parent, name) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// relationsTo get relations which child is name
+func (db *DB) relationsTo(name []byte) []*Relation {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var rs []*Relation
+	for _, r := range db.relations {
+		if r.opts.Enforce && bytes.Equal(r.child, name) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// hasKey check key exist in plain bucket or sort bucket
+func (tx *Tx) hasKey(name, key []byte) bool {
+	if b := tx.tx.Bucket(name); b != nil && b.Get(key) != nil {
+		return true
+	}
+	if b := tx.tx.Bucket(shadowName(_valuePrefix, name)); b != nil && b.Get(key) != nil {
+		return true
+	}
+	return false
+}
+
+// checkParents check parent keys referenced by key values exist, input like [key1,value1,key2,value2, ...]
+func (tx *Tx) checkParents(name []byte, kvs [][]byte) error {
+	if tx.db == nil {
+		return nil
+	}
+	for _, r := range tx.db.relationsTo(name) {
+		for i := 0; i+1 < len(kvs); i += 2 {
+			parent := r.keyMapper(kvs[i], kvs[i+1])
+			if parent != nil && !tx.hasKey(r.parent, parent) {
+				return ErrForeignKey
+			}
+		}
+	}
+	return nil
+}
+
+// children get keys in plain bucket and sort bucket of relation child which reference parent keys,
+// skipping keys already deleted by the cascade in progress
+func (tx *Tx) children(r *Relation, parents map[string]bool) (plain [][]byte, sorted [][]byte) {
+	if b := tx.tx.Bucket(r.child); b != nil {
+		reader := tx.reader(r.child)
+		b.ForEach(func(k, v []byte) error {
+			if !tx.cascade[cascadeKey(r.child, k)] && parents[string(r.keyMapper(k, reader.value(k, v)))] {
+				plain = append(plain, append([]byte{}, k...))
+			}
+			return nil
+		})
+	}
+	if b := tx.tx.Bucket(shadowName(_keyPrefix, r.child)); b != nil {
+		o := tx.sortOrder(r.child)
+		b.ForEach(func(k, v []byte) error {
+			if _, member, ok := o.split(k); ok && len(member) > 0 && !tx.cascade[cascadeKey(r.child, member)] &&
+				parents[string(r.keyMapper(member, v))] {
+				sorted = append(sorted, append([]byte{}, member...))
+			}
+			return nil
+		})
+	}
+	return plain, sorted
+}
+
+// cascadeKey key of record of bucket in cascade of tx
+func cascadeKey(name, key []byte) string {
+	return string(BytesConcat(escapeKey(name), key))
+}
+
+// deleteChildren check or cascade delete children of parent keys before they are deleted.
+// Keys deleted by the cascade are remembered until it ends, so records referencing themselves
+// or each other, like in a relation of a bucket with itself, are deleted once
+func (tx *Tx) deleteChildren(name []byte, keys [][]byte) error {
+	if tx.db == nil || len(keys) == 0 {
+		return nil
+	}
+	rs := tx.db.relationsOf(name)
+	if len(rs) == 0 {
+		return nil
+	}
+	if tx.cascade == nil {
+		tx.cascade = map[string]bool{}
+		defer func() { tx.cascade = nil }()
+	}
+	parents := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		parents[string(key)] = true
+		tx.cascade[cascadeKey(name, key)] = true
+	}
+	for _, r := range rs {
+		plain, sorted := tx.children(r, parents)
+		if len(plain) == 0 && len(sorted) == 0 {
+			continue
+		}
+		if !r.opts.Cascade {
+			return ErrHasChildren
+		}
+		for _, key := range plain {
+			tx.cascade[cascadeKey(r.child, key)] = true
+		}
+		for _, key := range sorted {
+			tx.cascade[cascadeKey(r.child, key)] = true
+		}
+		if len(plain) != 0 {
+			if err := tx.Delete(r.child, plain...); err != nil {
+				return err
+			}
+		}
+		if len(sorted) != 0 {
+			if err := tx.SortDelete(r.child, sorted...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestDB_Relate(t *testing.T) {
+	users := []byte("relate_users")
+	orders := []byte("relate_orders")
+	db.Relate(users, orders, func(k, v []byte) []byte {
+		return v
+	}, nil)
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(users, []byte("u1"), []byte("alice"), []byte("u2"), []byte("bob"))
+	tx.Put(orders, []byte("o1"), []byte("u1"), []byte("o2"), []byte("u2"))
+	if err := tx.Delete(users, []byte("u1")); err != ErrHasChildren {
+		t.Fatal("expect ErrHasChildren, got", err)
+	}
+	tx.Error(ErrNil)
+	if gets := tx.Get(users, []byte("u1")); len(gets) != 2 {
+		t.Fatal("parent deleted with children", gets)
+	}
+}
+
+func TestDB_RelateCascade(t *testing.T) {
+	users := []byte("cascade_users")
+	orders := []byte("cascade_orders")
+	timeline := []byte("cascade_timeline")
+	mapper := func(k, v []byte) []byte {
+		return v
+	}
+	db.Relate(users, orders, mapper, &RelationOptions{Cascade: true})
+	db.Relate(users, timeline, mapper, &RelationOptions{Cascade: true})
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(users, []byte("u1"), []byte("alice"), []byte("u2"), []byte("bob"))
+	tx.Put(orders, []byte("o1"), []byte("u1"), []byte("o2"), []byte("u2"), []byte("o3"), []byte("u1"))
+	tx.SortPut(timeline, Uint64ToBytes(1), []byte("e1"), []byte("u1"))
+	tx.SortPut(timeline, Uint64ToBytes(2), []byte("e2"), []byte("u2"))
+	if err := tx.Delete(users, []byte("u1")); err != nil {
+		t.Fatal(err)
+	}
+	if gets := tx.Get(orders, []byte("o1"), []byte("o2"), []byte("o3")); len(gets) != 2 || string(gets[0]) != "o2" {
+		t.Fatal("children not cascade deleted", gets)
+	}
+	if next := tx.SortNext(timeline, nil, 0); len(next) != 2 || string(next[0]) != "e2" {
+		t.Fatal("sort children not cascade deleted", next)
+	}
+}
+
+func TestDB_RelateEnforce(t *testing.T) {
+	users := []byte("enforce_users")
+	orders := []byte("enforce_orders")
+	db.Relate(users, orders, func(k, v []byte) []byte {
+		return v
+	}, &RelationOptions{Enforce: true})
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(users, []byte("u1"), []byte("alice"))
+	if err := tx.Put(orders, []byte("o1"), []byte("u1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Put(orders, []byte("o2"), []byte("u9")); err != ErrForeignKey {
+		t.Fatal("expect ErrForeignKey, got", err)
+	}
+	tx.Error(ErrNil)
+	if err := tx.SortPut(orders, Uint64ToBytes(1), []byte("o3"), []byte("u9")); err != ErrForeignKey {
+		t.Fatal("expect ErrForeignKey, got", err)
+	}
+}
+
+func TestDB_RelateSelfCascade(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	nodes := []byte("nodes")
+	// values of children are compressed, keyMapper see them decoded
+	d.SetEnvelope(nodes, &EnvelopeOptions{Flags: EnvelopeCompressed})
+	d.Relate(nodes, nodes, func(k, v []byte) []byte {
+		return v
+	}, &RelationOptions{Cascade: true})
+
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	// a is the root of b, c and their descendants, y and z reference each other, e reference itself
+	tx.Put(nodes, []byte("a"), []byte("-"), []byte("b"), []byte("a"), []byte("c"), []byte("a"), []byte("d"), []byte("c"))
+	tx.Put(nodes, []byte("x"), []byte("d"), []byte("y"), []byte("z"), []byte("z"), []byte("y"), []byte("e"), []byte("e"))
+	if err := tx.Delete(nodes, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if found := tx.Exists(nodes, []byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("x")); found[0] || found[1] || found[2] || found[3] || found[4] {
+		t.Fatal("descendants not deleted", found)
+	}
+	if err := tx.Delete(nodes, []byte("y")); err != nil {
+		t.Fatal("expect records referencing each other deleted", err)
+	}
+	if found := tx.Exists(nodes, []byte("z")); found[0] {
+		t.Fatal("child in cycle not deleted")
+	}
+	if err := tx.Delete(nodes, []byte("e")); err != nil {
+		t.Fatal("expect record referencing itself deleted", err)
+	}
+}
@@ -0,0 +1,141 @@
+package zbolt
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// RestoreFrom write backup stream r, like written by Backup, to file path and open it.
+// The stream is validated as a bolt file of a supported format before it replaces path,
+// so a truncated or foreign stream leave path untouched. path must not be opened
+func RestoreFrom(r io.Reader, path string) (*DB, error) {
+	tmp, _, err := prepareRestore(r, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	return Open(path)
+}
+
+// ReplaceFrom replace file of db by backup stream r, validated like RestoreFrom. New transactions are rejected
+// with ErrShuttingDown while open ones finish, then file is swapped and reopened with the same options.
+// Options configured on DB are kept, fast counts and bloom filters are rebuilt. Return ErrNoFile for memory DB
+func (db *DB) ReplaceFrom(r io.Reader) error {
+	f, ok := db.engine().(backendFile)
+	if !ok {
+		return ErrNoFile
+	}
+	path := f.Path()
+	tmp, fs, err := prepareRestore(r, path, db.opts)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if !atomic.CompareAndSwapInt32(&db.closing, 0, 1) {
+		return ErrShuttingDown
+	}
+	// NewTx count itself before checking closing, so no transaction begins on the old file once this returns
+	for atomic.LoadInt32(&db.openTxs) != 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	db.engineMu.Lock()
+	defer db.engineMu.Unlock()
+	if err := db.db.Close(); err != nil {
+		atomic.StoreInt32(&db.closing, 0)
+		return err
+	}
+	rerr := os.Rename(tmp, path)
+	// reopen the old file if rename failed, db stays rejecting transactions if it can not be opened
+	bdb, err := openBackend(path, db.opts)
+	if err != nil {
+		return err
+	}
+	db.db = bdb
+	defer atomic.StoreInt32(&db.closing, 0)
+	if rerr != nil {
+		return rerr
+	}
+	db.mu.Lock()
+	db.features = fs
+	db.mu.Unlock()
+	// counted before transactions are accepted so no write is missed
+	btx, err := bdb.Begin(false)
+	if err != nil {
+		return err
+	}
+	tx := &Tx{tx: btx, db: db, start: time.Now(), done: true}
+	defer tx.Rollback()
+	if err := db.loadTTL(tx); err != nil {
+		return err
+	}
+	tx.tx = db.wrapTx(btx)
+	return db.rebuildWarm(tx)
+}
+
+// prepareRestore write r to a temporary file beside path, check its meta pages and its format opening it with o.
+// Return the temporary file and its features
+func prepareRestore(r io.Reader, path string, o *Options) (string, Features, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".restore-*")
+	if err != nil {
+		return "", Features{}, err
+	}
+	tmp := f.Name()
+	fs, err := func() (Features, error) {
+		_, err := io.Copy(f, r)
+		if err == nil {
+			err = f.Sync()
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return Features{}, err
+		}
+		if err := checkBoltFile(tmp); err != nil {
+			return Features{}, err
+		}
+		db, err := OpenWithOptions(tmp, o)
+		if err != nil {
+			return Features{}, err
+		}
+		fs := db.Features()
+		return fs, db.Close()
+	}()
+	if err != nil {
+		os.Remove(tmp)
+		return "", Features{}, err
+	}
+	return tmp, fs, nil
+}
+
+// checkBoltFile check magic and checksum of meta pages of bolt file at path and that no page of it is missing
+func checkBoltFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	m, err := latestMeta(f)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: file of %d bytes too short", ErrInvalidFile, st.Size())
+	}
+	if err != nil {
+		return err
+	}
+	if size := int64(m.pgid) * int64(m.pageSize); st.Size() < size {
+		return fmt.Errorf("%w: truncated to %d of %d bytes", ErrInvalidFile, st.Size(), size)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package zbolt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// backupOf backup of a new db holding kvs in bucket name
+func backupOf(t *testing.T, name []byte, kvs ...[]byte) []byte {
+	d, err := Open(filepath.Join(t.TempDir(), "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, kvs...) }); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := d.Backup(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRestoreFrom(t *testing.T) {
+	name := []byte("restore")
+	b := backupOf(t, name, []byte("k"), []byte("v"))
+	path := filepath.Join(t.TempDir(), "restored.db")
+	if _, err := RestoreFrom(bytes.NewReader(b[:len(b)/2]), path); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("expect truncated stream refused", err)
+	}
+	if _, err := RestoreFrom(bytes.NewReader([]byte("not a bolt file")), path); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("expect foreign stream refused", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expect path untouched", err)
+	}
+	d, err := RestoreFrom(bytes.NewReader(b), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx := d.NewTx(false)
+	defer tx.Rollback()
+	if gets := tx.Get(name, []byte("k")); len(gets) != 2 || string(gets[1]) != "v" {
+		t.Fatal("unexpected restored value", gets)
+	}
+}
+
+func TestDB_ReplaceFrom(t *testing.T) {
+	name := []byte("replace")
+	b := backupOf(t, name, []byte("a"), []byte("1"), []byte("b"), []byte("2"))
+	d, err := Open(filepath.Join(t.TempDir(), "live.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err := d.Update(func(tx *Tx) error { return tx.Put(name, []byte("old"), []byte("0")) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.EnableFastCount(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReplaceFrom(bytes.NewReader(b[:100])); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("expect truncated stream refused", err)
+	}
+	if err := d.ReplaceFrom(bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := d.FastCount(name); c.Keys != 2 {
+		t.Fatal("expect fast count rebuilt", c)
+	}
+	err = d.Update(func(tx *Tx) error {
+		if gets := tx.Get(name, []byte("old"), []byte("a")); len(gets) != 2 || string(gets[1]) != "1" {
+			t.Fatal("unexpected replaced values", gets)
+		}
+		return tx.Put(name, []byte("c"), []byte("3"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.ReplaceFrom(bytes.NewReader(b)); !errors.Is(err, ErrNoFile) {
+		t.Fatal("expect ErrNoFile", err)
+	}
+}
+
+func TestDB_ReplaceFromBeginningTx(t *testing.T) {
+	name := []byte("replace")
+	b := backupOf(t, name, []byte("a"), []byte("1"))
+	d, err := Open(filepath.Join(t.TempDir(), "live.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx1 := d.NewTx(true)
+	began := make(chan *Tx)
+	go func() {
+		// blocked on the writer lock held by tx1
+		began <- d.NewTx(true)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	done := make(chan error)
+	go func() { done <- d.ReplaceFrom(bytes.NewReader(b)) }()
+	time.Sleep(20 * time.Millisecond)
+	tx1.Rollback()
+	tx2 := <-began
+	if err := tx2.Put(name, []byte("old"), []byte("0")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx2.Commit(); err != nil {
+		t.Fatal("tx begun before replace must commit to the old file", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	err = d.View(func(tx *Tx) error {
+		if gets := tx.Get(name, []byte("a"), []byte("old")); len(gets) != 2 {
+			t.Fatal("expect file replaced after tx committed", gets)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
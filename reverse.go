@@ -0,0 +1,76 @@
+package zbolt
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+var _reversePrefix = []byte{25}
+
+// reverseHashSize bytes of value hash in reverse bucket key
+const reverseHashSize = 16
+
+// reverseKey key of reverse bucket, like [hash(value), key]
+func reverseKey(key, value []byte) []byte {
+	h := sha256.Sum256(value)
+	return BytesConcat(h[:reverseHashSize], key)
+}
+
+// PutWithReverse put key value to bucket and map the value back to key in a reverse bucket, so KeysForValue can find it
+func (tx *Tx) PutWithReverse(name, key, value []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if b := tx.tx.Bucket(name); b != nil {
+		if tx.Error(tx.deleteReverse(name, b, key)) != nil {
+			return tx.err
+		}
+	}
+	if tx.Put(name, key, value) != nil {
+		return tx.err
+	}
+	r, err := tx.tx.CreateBucketIfNotExists(shadowName(_reversePrefix, name))
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	return tx.Error(r.Put(reverseKey(key, value), nil))
+}
+
+// KeysForValue get keys of bucket put by PutWithReverse whose value equal value,
+// keys changed later by Put without reverse are skipped
+func (tx *Tx) KeysForValue(name, value []byte) [][]byte {
+	if tx.err != nil {
+		return [][]byte{}
+	}
+	r := tx.tx.Bucket(shadowName(_reversePrefix, name))
+	b := tx.tx.Bucket(name)
+	if r == nil || b == nil {
+		return [][]byte{}
+	}
+	prefix := reverseKey(nil, value)
+	keys := [][]byte{}
+	c := r.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if tx.canceled() {
+			return [][]byte{}
+		}
+		key := k[len(prefix):]
+		if bytes.Equal(tx.get(name, b, key), value) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// deleteReverse delete reverse entry of the current value of key
+func (tx *Tx) deleteReverse(name []byte, b backendBucket, key []byte) error {
+	r := tx.tx.Bucket(shadowName(_reversePrefix, name))
+	if r == nil {
+		return nil
+	}
+	old := tx.get(name, b, key)
+	if old == nil {
+		return nil
+	}
+	return r.Delete(reverseKey(key, old))
+}
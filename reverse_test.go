@@ -0,0 +1,26 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_PutWithReverse(t *testing.T) {
+	name := []byte("reverse_tokens")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.PutWithReverse(name, []byte("alice"), []byte("t1"))
+	tx.PutWithReverse(name, []byte("bob"), []byte("t1"))
+	tx.PutWithReverse(name, []byte("carol"), []byte("t2"))
+	if keys := tx.KeysForValue(name, []byte("t1")); len(keys) != 2 || string(keys[0]) != "alice" || string(keys[1]) != "bob" {
+		t.Fatalf("unexpected keys %q", keys)
+	}
+	tx.PutWithReverse(name, []byte("alice"), []byte("t2"))
+	tx.Delete(name, []byte("carol"))
+	tx.Put(name, []byte("bob"), []byte("t3"))
+	if keys := tx.KeysForValue(name, []byte("t2")); len(keys) != 1 || string(keys[0]) != "alice" {
+		t.Fatalf("unexpected keys %q", keys)
+	}
+	if keys := tx.KeysForValue(name, []byte("t1")); len(keys) != 0 {
+		t.Fatalf("expect stale key skipped, got %q", keys)
+	}
+}
@@ -0,0 +1,79 @@
+package zbolt
+
+import (
+	"time"
+)
+
+// Sample value recorded in ring at time
+type Sample struct {
+	Time  time.Time
+	Value []byte
+}
+
+// Ring fixed capacity circular buffer of samples stored in bucket, the oldest sample is overwritten when full.
+// Samples are keyed by slot, the sequence of bucket count samples ever added
+type Ring struct {
+	name     []byte
+	capacity uint64
+}
+
+// Ring get ring of samples stored in bucket name holding at most capacity samples.
+// Capacity must not change once the ring is written
+func (db *DB) Ring(name []byte, capacity int) *Ring {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Ring{name: name, capacity: uint64(capacity)}
+}
+
+// Add record value at time t, overwriting the oldest sample when ring is full
+func (r *Ring) Add(tx *Tx, t time.Time, value []byte) error {
+	seq, err := tx.NextSequence(r.name)
+	if err != nil {
+		return err
+	}
+	return tx.U64Put(r.name, U64KV{Key: (seq - 1) % r.capacity, Value: BytesConcat(TimeToBytes(t), value)})
+}
+
+// Len count of samples held by ring
+func (r *Ring) Len(tx *Tx) int {
+	if n := tx.Sequence(r.name); n < r.capacity {
+		return int(n)
+	}
+	return int(r.capacity)
+}
+
+// Last get the n newest samples in the order they were added, n = 0 representative of all
+func (r *Ring) Last(tx *Tx, n int) []Sample {
+	samples := []Sample{}
+	head, count := tx.Sequence(r.name), uint64(r.Len(tx))
+	if n > 0 && uint64(n) < count {
+		count = uint64(n)
+	}
+	for seq := head - count; seq < head; seq++ {
+		if s, ok := r.sample(tx, seq); ok {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// Range get samples with time in [from, to) in the order they were added
+func (r *Ring) Range(tx *Tx, from, to time.Time) []Sample {
+	samples := []Sample{}
+	for _, s := range r.Last(tx, 0) {
+		if !s.Time.Before(from) && s.Time.Before(to) {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// sample get sample added at sequence seq, counted from 0
+func (r *Ring) sample(tx *Tx, seq uint64) (Sample, bool) {
+	v := tx.U64Get(r.name, seq%r.capacity)
+	if len(v) < 8 {
+		return Sample{}, false
+	}
+	return Sample{Time: BytesToTime(v[:8]), Value: v[8:]}, true
+}
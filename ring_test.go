@@ -0,0 +1,44 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_Ring(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	r := d.Ring([]byte("cpu"), 3)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	if samples := r.Last(tx, 0); len(samples) != 0 {
+		t.Fatal("unexpected samples of empty ring", samples)
+	}
+	at := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		if err := r.Add(tx, at.Add(time.Duration(i)*time.Second), []byte{byte('0' + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := r.Len(tx); n != 3 {
+		t.Fatal("unexpected len", n)
+	}
+	values := func(samples []Sample) (s string) {
+		for _, sample := range samples {
+			s += string(sample.Value)
+		}
+		return s
+	}
+	if s := values(r.Last(tx, 0)); s != "234" {
+		t.Fatal("unexpected samples", s)
+	}
+	if s := values(r.Last(tx, 2)); s != "34" {
+		t.Fatal("unexpected last samples", s)
+	}
+	if s := values(r.Range(tx, at.Add(time.Second), at.Add(4*time.Second))); s != "23" {
+		t.Fatal("unexpected range", s)
+	}
+}
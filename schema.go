@@ -0,0 +1,161 @@
+package zbolt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	_metaBucket    = []byte{24}
+	_schemaMetaKey = []byte("schema:")
+)
+
+// MigrateFunc migrate values of bucket from codec version from to version to
+type MigrateFunc func(tx *Tx, bucket []byte, from, to uint32) error
+
+// Schema codec of values stored in bucket
+type Schema struct {
+	Bucket  []byte
+	ID      string
+	Version uint32
+	// Migrate called in the same transaction when stored version is lower than Version, nil means versions are compatible
+	Migrate MigrateFunc `json:"-"`
+}
+
+// SchemaError schema registered for bucket can't be used with the one stored, Err is ErrSchemaMismatch or ErrSchemaVersion
+type SchemaError struct {
+	Bucket     []byte
+	Stored     Schema
+	Registered Schema
+	Err        error
+}
+
+// Error message with bucket and both schemas
+func (e *SchemaError) Error() string {
+	if e.Err == ErrSchemaMismatch {
+		return fmt.Sprintf("%v: bucket %q stored %q, registered %q", e.Err, e.Bucket, e.Stored.ID, e.Registered.ID)
+	}
+	return fmt.Sprintf("%v: bucket %q stored version %d, registered %d", e.Err, e.Bucket, e.Stored.Version, e.Registered.Version)
+}
+
+// Unwrap ErrSchemaMismatch or ErrSchemaVersion
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterSchema validate schema of bucket against the one stored in meta bucket and store it.
+// Return *SchemaError wrapping ErrSchemaMismatch if stored schema id differ, ErrSchemaVersion if stored version
+// is newer than Version, Migrate is called when stored version is older. See Options.Schemas to register on open
+func (db *DB) RegisterSchema(s Schema) error {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.registerSchema(s); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// registerSchema validate, migrate and store schema of bucket in tx
+func (tx *Tx) registerSchema(s Schema) error {
+	old, err := tx.Schema(s.Bucket)
+	if err != nil {
+		return err
+	}
+	if err := checkSchema(old, s); err != nil {
+		return err
+	}
+	if old != nil {
+		if old.Version == s.Version {
+			return nil
+		}
+		if s.Migrate != nil {
+			if err := s.Migrate(tx, s.Bucket, old.Version, s.Version); err != nil {
+				return err
+			}
+		}
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return tx.putMeta(BytesConcat(_schemaMetaKey, s.Bucket), b)
+}
+
+// checkSchema check schema s registered can be used with old stored, nil old is never registered
+func checkSchema(old *Schema, s Schema) error {
+	if old == nil {
+		return nil
+	}
+	if old.ID != s.ID {
+		return &SchemaError{Bucket: s.Bucket, Stored: *old, Registered: s, Err: ErrSchemaMismatch}
+	}
+	if old.Version > s.Version {
+		return &SchemaError{Bucket: s.Bucket, Stored: *old, Registered: s, Err: ErrSchemaVersion}
+	}
+	return nil
+}
+
+// openSchemas validate schemas of options on open, migrate and store them unless read only,
+// in which case stored versions older than registered are refused as they can't be migrated
+func (db *DB) openSchemas() error {
+	if db.opts == nil || len(db.opts.Schemas) == 0 {
+		return nil
+	}
+	if db.opts.ReadOnly {
+		return db.View(func(tx *Tx) error {
+			for _, s := range db.opts.Schemas {
+				old, err := tx.Schema(s.Bucket)
+				if err != nil {
+					return err
+				}
+				if err := checkSchema(old, s); err != nil {
+					return err
+				}
+				if old != nil && old.Version < s.Version {
+					return &SchemaError{Bucket: s.Bucket, Stored: *old, Registered: s, Err: ErrSchemaVersion}
+				}
+			}
+			return nil
+		})
+	}
+	return db.Update(func(tx *Tx) error {
+		for _, s := range db.opts.Schemas {
+			if err := tx.registerSchema(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Schema get schema of bucket stored in meta bucket, nil if not registered
+func (tx *Tx) Schema(bucket []byte) (*Schema, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	b := tx.tx.Bucket(_metaBucket)
+	if b == nil {
+		return nil, nil
+	}
+	v := b.Get(BytesConcat(_schemaMetaKey, bucket))
+	if v == nil {
+		return nil, nil
+	}
+	var s Schema
+	if err := json.Unmarshal(v, &s); err != nil {
+		return nil, tx.Error(err)
+	}
+	return &s, nil
+}
+
+// putMeta put value of key in meta bucket
+func (tx *Tx) putMeta(key, value []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(_metaBucket)
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	return tx.Error(b.Put(key, value))
+}
@@ -0,0 +1,89 @@
+package zbolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDB_RegisterSchema(t *testing.T) {
+	name := []byte("schema_users")
+	tx := db.NewTx(true)
+	tx.Delete(_metaBucket, BytesConcat(_schemaMetaKey, name))
+	tx.Put(name, []byte("u1"), []byte("alice"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.RegisterSchema(Schema{Bucket: name, ID: "user", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RegisterSchema(Schema{Bucket: name, ID: "account", Version: 1}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatal("expect ErrSchemaMismatch, got", err)
+	}
+	var from, to uint32
+	err := db.RegisterSchema(Schema{Bucket: name, ID: "user", Version: 2, Migrate: func(tx *Tx, bucket []byte, f, t uint32) error {
+		from, to = f, t
+		return tx.Put(bucket, []byte("u1"), []byte(`{"name":"alice"}`))
+	}})
+	if err != nil || from != 1 || to != 2 {
+		t.Fatal("migrate not called", err, from, to)
+	}
+	if err := db.RegisterSchema(Schema{Bucket: name, ID: "user", Version: 1}); !errors.Is(err, ErrSchemaVersion) {
+		t.Fatal("expect ErrSchemaVersion, got", err)
+	}
+
+	tx = db.NewTx(false)
+	defer tx.Rollback()
+	if gets := tx.Get(name, []byte("u1")); string(gets[1]) != `{"name":"alice"}` {
+		t.Fatal("migration not committed", gets)
+	}
+	if s, err := tx.Schema(name); err != nil || s.Version != 2 {
+		t.Fatal("unexpected stored schema", s, err)
+	}
+}
+
+func TestOpenWithOptions_Schemas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.db")
+	user := Schema{Bucket: []byte("users"), ID: "user", Version: 2}
+	d, err := OpenWithOptions(path, &Options{Schemas: []Schema{user}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	var se *SchemaError
+	_, err = OpenWithOptions(path, &Options{Schemas: []Schema{{Bucket: user.Bucket, ID: "account", Version: 2}}})
+	if !errors.As(err, &se) || !errors.Is(err, ErrSchemaMismatch) || se.Stored.ID != "user" {
+		t.Fatal("expect schema mismatch on open, got", err)
+	}
+	_, err = OpenWithOptions(path, &Options{Schemas: []Schema{{Bucket: user.Bucket, ID: "user", Version: 1}}})
+	if !errors.As(err, &se) || !errors.Is(err, ErrSchemaVersion) {
+		t.Fatal("expect newer stored version refused on open, got", err)
+	}
+	_, err = OpenWithOptions(path, &Options{ReadOnly: true, Schemas: []Schema{{Bucket: user.Bucket, ID: "user", Version: 3}}})
+	if !errors.Is(err, ErrSchemaVersion) {
+		t.Fatal("expect read only open refuse to migrate, got", err)
+	}
+
+	migrated := false
+	user.Version = 3
+	user.Migrate = func(tx *Tx, bucket []byte, from, to uint32) error {
+		migrated = from == 2 && to == 3
+		return nil
+	}
+	d, err = OpenWithOptions(path, &Options{Schemas: []Schema{user}})
+	if err != nil || !migrated {
+		t.Fatal("expect migration on open", err)
+	}
+	defer d.Close()
+	if err := d.View(func(tx *Tx) error {
+		s, err := tx.Schema(user.Bucket)
+		if err == nil && s.Version != 3 {
+			t.Fatal("unexpected stored schema", s)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
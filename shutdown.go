@@ -0,0 +1,169 @@
+package zbolt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// worker background worker of DB
+type worker struct {
+	name    string
+	stop    func(ctx context.Context) error
+	restart func() // start again worker stopped by a Shutdown which timed out, nil if it can't be
+}
+
+// workerSet background workers registered to DB
+type workerSet struct {
+	mu   sync.Mutex
+	list []*worker
+}
+
+// periodic background worker of DB running a function every interval until halted
+type periodic struct {
+	mu         sync.Mutex
+	stop       chan struct{}
+	done       chan struct{}
+	unregister func()
+}
+
+// start run fn every interval in background, registered as worker name so Shutdown and Close halt it.
+// Do nothing if already running
+func (p *periodic) start(db *DB, name string, interval time.Duration, fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	p.stop, p.done = stop, done
+	p.unregister = db.registerWorker(name, func(ctx context.Context) error {
+		p.halt()
+		return nil
+	}, func() { p.start(db, name, interval, fn) })
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+// halt stop worker and wait it exit
+func (p *periodic) halt() {
+	p.mu.Lock()
+	stop, done, unregister := p.stop, p.done, p.unregister
+	p.stop, p.done, p.unregister = nil, nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		unregister()
+		close(stop)
+		<-done
+	}
+}
+
+// RegisterWorker register background worker, stop is called by Shutdown and Close in reverse order of registration,
+// it should flush pending work and return before ctx is done. Call unregister when the worker exit by itself
+func (db *DB) RegisterWorker(name string, stop func(ctx context.Context) error) (unregister func()) {
+	return db.registerWorker(name, stop, nil)
+}
+
+// registerWorker register background worker like RegisterWorker, with restart to start it again
+func (db *DB) registerWorker(name string, stop func(ctx context.Context) error, restart func()) (unregister func()) {
+	w := &worker{name: name, stop: stop, restart: restart}
+	db.workers.mu.Lock()
+	db.workers.list = append(db.workers.list, w)
+	db.workers.mu.Unlock()
+	return func() {
+		db.workers.mu.Lock()
+		defer db.workers.mu.Unlock()
+		for i, v := range db.workers.list {
+			if v == w {
+				db.workers.list = append(db.workers.list[:i], db.workers.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// workerNames names of registered workers
+func (db *DB) workerNames() []string {
+	db.workers.mu.Lock()
+	defer db.workers.mu.Unlock()
+	names := make([]string, len(db.workers.list))
+	for i, w := range db.workers.list {
+		names[i] = w.name
+	}
+	return names
+}
+
+// openTxCount count of open transactions
+func (db *DB) openTxCount() int {
+	return int(atomic.LoadInt32(&db.openTxs))
+}
+
+// isClosing Shutdown has been called
+func (db *DB) isClosing() bool {
+	return atomic.LoadInt32(&db.closing) != 0
+}
+
+// stopWorkers stop all registered workers, return stopped workers and the first error
+func (db *DB) stopWorkers(ctx context.Context) ([]*worker, error) {
+	db.workers.mu.Lock()
+	list := db.workers.list
+	db.workers.list = nil
+	db.workers.mu.Unlock()
+	var first error
+	for i := len(list) - 1; i >= 0; i-- {
+		if err := list[i].stop(ctx); err != nil && first == nil {
+			first = fmt.Errorf("stop %s: %w", list[i].name, err)
+		}
+	}
+	return list, first
+}
+
+// restartWorkers start again stopped workers which can be, in order of registration
+func restartWorkers(list []*worker) {
+	for _, w := range list {
+		if w.restart != nil {
+			w.restart()
+		}
+	}
+}
+
+// Shutdown stop background workers, reject new transactions with ErrShuttingDown,
+// wait open transactions to finish then close DB.
+// If ctx is done before transactions finish, return its error and leave DB open and usable: new transactions are
+// accepted again and built in workers like GC and TTL sweeper restarted, workers of RegisterWorker stay stopped
+func (db *DB) Shutdown(ctx context.Context) error {
+	stopped, err := db.stopWorkers(ctx)
+	atomic.StoreInt32(&db.closing, 1)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		n := atomic.LoadInt32(&db.openTxs)
+		if n == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			atomic.StoreInt32(&db.closing, 0)
+			restartWorkers(stopped)
+			return fmt.Errorf("%w: %d transactions still open", ctx.Err(), n)
+		case <-ticker.C:
+		}
+	}
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package zbolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDB_Shutdown(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "shutdown.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.StartGC(time.Hour, 0)
+	var stopped []string
+	d.RegisterWorker("writer", func(ctx context.Context) error {
+		stopped = append(stopped, "writer")
+		return nil
+	})
+	unregister := d.RegisterWorker("gone", func(ctx context.Context) error {
+		stopped = append(stopped, "gone")
+		return nil
+	})
+	unregister()
+
+	tx := d.NewTx(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expect deadline exceeded with open tx, got", err)
+	}
+	if len(stopped) != 1 {
+		t.Fatal("workers not stopped", stopped)
+	}
+	// DB left open is usable, built in workers run again
+	if d.isClosing() || d.gc.worker.stop == nil {
+		t.Fatal("expect gc restarted after timed out shutdown")
+	}
+	if names := d.workerNames(); len(names) != 1 || names[0] != "gc" {
+		t.Fatal("unexpected workers", names)
+	}
+	tx2 := d.NewTx(false)
+	if err := tx2.Error(); err != nil {
+		t.Fatal("expect DB usable after timed out shutdown", err)
+	}
+	tx2.Rollback()
+	tx.Rollback()
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDB_ShutdownBeginningTx(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "shutdown.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx1 := d.NewTx(true)
+	began := make(chan *Tx)
+	go func() {
+		// blocked on the writer lock held by tx1
+		began <- d.NewTx(true)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if n := d.openTxCount(); n != 2 {
+		t.Fatal("expect beginning tx counted", n)
+	}
+	done := make(chan error)
+	go func() { done <- d.Shutdown(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	tx1.Rollback()
+	tx2 := <-began
+	if err := tx2.Put([]byte("shutdown"), []byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx2.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
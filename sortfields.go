@@ -0,0 +1,120 @@
+package zbolt
+
+import (
+	"fmt"
+	"time"
+)
+
+// FieldKind type of a field of multi-field sort key
+type FieldKind uint8
+
+// field kinds, encoded like the parts of Key
+const (
+	FieldUint64 FieldKind = iota // uint64, 8 bytes big endian
+	FieldInt64                   // int64, sign bit flipped
+	FieldTime                    // time.Time, TimeToBytes
+	FieldString                  // string, escaped
+	FieldBytes                   // []byte, escaped
+)
+
+// SortField field of multi-field sort key, Desc orders the field from high to low
+type SortField struct {
+	Kind FieldKind
+	Desc bool
+}
+
+// SortFields fields of multi-field sort key compared in order, later fields break ties of earlier ones,
+// like score descending then time ascending
+type SortFields []SortField
+
+// SetSortFields decode sort keys of bucket with sort by fields, returned as Fields of Entry
+func (db *DB) SetSortFields(name []byte, fields SortFields) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.sortFields = fields
+	})
+}
+
+// Key encode values of fields into sort key, values are uint64, int64, time.Time, string or []byte by kind of field.
+// Return ErrSortField if count or types of values do not match fields
+func (fs SortFields) Key(values ...interface{}) ([]byte, error) {
+	if len(values) != len(fs) {
+		return nil, fmt.Errorf("%w: %d values for %d fields", ErrSortField, len(values), len(fs))
+	}
+	k := NewKey()
+	for i, f := range fs {
+		n := len(k.b)
+		switch v := values[i].(type) {
+		case uint64:
+			if f.Kind != FieldUint64 {
+				return nil, fmt.Errorf("%w: field %d got uint64", ErrSortField, i)
+			}
+			k.Uint64(v)
+		case int64:
+			if f.Kind != FieldInt64 {
+				return nil, fmt.Errorf("%w: field %d got int64", ErrSortField, i)
+			}
+			k.Int64(v)
+		case time.Time:
+			if f.Kind != FieldTime {
+				return nil, fmt.Errorf("%w: field %d got time", ErrSortField, i)
+			}
+			k.Time(v)
+		case string:
+			if f.Kind != FieldString {
+				return nil, fmt.Errorf("%w: field %d got string", ErrSortField, i)
+			}
+			k.String(v)
+		case []byte:
+			if f.Kind != FieldBytes {
+				return nil, fmt.Errorf("%w: field %d got bytes", ErrSortField, i)
+			}
+			k.Bytes(v)
+		default:
+			return nil, fmt.Errorf("%w: field %d got %T", ErrSortField, i, v)
+		}
+		if f.Desc {
+			complement(k.b[n:])
+		}
+	}
+	return k.b, nil
+}
+
+// Decode decode sort key into values of fields, return ErrKeyDecode if it does not match fields
+func (fs SortFields) Decode(sortKey []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(fs))
+	for i, f := range fs {
+		b := sortKey
+		if f.Desc {
+			b = append([]byte{}, sortKey...)
+			complement(b)
+		}
+		d := DecodeKey(b)
+		switch f.Kind {
+		case FieldUint64:
+			values[i] = d.ReadUint64()
+		case FieldInt64:
+			values[i] = d.ReadInt64()
+		case FieldTime:
+			values[i] = d.ReadTime()
+		case FieldString:
+			values[i] = d.ReadString()
+		default:
+			values[i] = d.ReadBytes()
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		sortKey = sortKey[len(b)-len(d.b):]
+	}
+	if len(sortKey) != 0 {
+		return nil, ErrKeyDecode
+	}
+	return values, nil
+}
+
+// complement flip all bits of b
+func complement(b []byte) {
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
@@ -0,0 +1,57 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSortFields(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	board := []byte("board")
+	fields := SortFields{{Kind: FieldUint64, Desc: true}, {Kind: FieldTime}}
+	d.SetSortFields(board, fields)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	at := time.Unix(1000, 0)
+	for _, p := range []struct {
+		name  string
+		score uint64
+		at    time.Time
+	}{{"alice", 10, at.Add(time.Second)}, {"bob", 20, at}, {"carol", 10, at}} {
+		sortKey, err := fields.Key(p.score, p.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.SortPut(board, sortKey, []byte(p.name), nil)
+	}
+	entries := tx.SortNextEntries(board, nil, 0)
+	if len(entries) != 3 || string(entries[0].Key) != "bob" || string(entries[1].Key) != "carol" || string(entries[2].Key) != "alice" {
+		t.Fatal("unexpected order", entries)
+	}
+	if e := entries[2]; len(e.Fields) != 2 || e.Fields[0].(uint64) != 10 || !e.Fields[1].(time.Time).Equal(at.Add(time.Second)) {
+		t.Fatal("unexpected fields", e.Fields)
+	}
+	if prev := tx.SortPrevEntries(board, entries[2].SortKey, 1); len(prev) != 1 || string(prev[0].Key) != "carol" || prev[0].Fields == nil {
+		t.Fatal("unexpected prev", prev)
+	}
+
+	if _, err := fields.Key("10", at); !errors.Is(err, ErrSortField) {
+		t.Fatal("expect ErrSortField, got", err)
+	}
+	if _, err := fields.Key(uint64(10)); !errors.Is(err, ErrSortField) {
+		t.Fatal("expect ErrSortField, got", err)
+	}
+	names := SortFields{{Kind: FieldString, Desc: true}, {Kind: FieldInt64}}
+	k, _ := names.Key("a\x00b", int64(-3))
+	if values, err := names.Decode(k); err != nil || values[0].(string) != "a\x00b" || values[1].(int64) != -3 {
+		t.Fatal("unexpected decode", values, err)
+	}
+	if _, err := names.Decode(k[:len(k)-1]); !errors.Is(err, ErrKeyDecode) {
+		t.Fatal("expect ErrKeyDecode, got", err)
+	}
+}
@@ -0,0 +1,128 @@
+package zbolt
+
+import (
+	"bytes"
+)
+
+// sortOrder order of entries in sort key bucket by sort key, descending store escaped sort keys complemented.
+// Member keys of equal sort keys are ordered by collation of the bucket, like keys of the bucket
+type sortOrder struct {
+	desc      bool
+	collation Collation
+}
+
+// sortAscending order of sort key bucket without options
+var sortAscending = sortOrder{}
+
+// EnableSortDescending store entries of bucket with sort in descending order of sort key, so newest first paging
+// with SortNext walk forward. Sort keys taken and returned by every Sort API stay unchanged.
+// Must be set before the bucket is written
+func (db *DB) EnableSortDescending(name []byte, enabled bool) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.sortDesc = enabled
+	})
+}
+
+// sortOrder get order of sort key bucket
+func (tx *Tx) sortOrder(name []byte) sortOrder {
+	if tx.db == nil {
+		return sortAscending
+	}
+	return tx.db.config(name).sortOrder()
+}
+
+// sortOrder order of sort key bucket of bucket config
+func (c bucketConfig) sortOrder() sortOrder {
+	return sortOrder{desc: c.sortDesc, collation: c.collation}
+}
+
+// prefix encoded sort key, prefix of keys of all its entries
+func (o sortOrder) prefix(sortKey []byte) []byte {
+	k := escapeKey(sortKey)
+	if o.desc {
+		complement(k)
+	}
+	return k
+}
+
+// entryKey key of member in sort key bucket, like [encoded sort key, collated key], so sort keys of any length keep order
+func (o sortOrder) entryKey(sortKey, key []byte) []byte {
+	return BytesConcat(o.prefix(sortKey), o.collation.member(key))
+}
+
+// split split key of sort key bucket into sort key and member key
+func (o sortOrder) split(k []byte) (sortKey, key []byte, ok bool) {
+	if !o.desc {
+		sortKey, rest, ok := unescapeKey(k)
+		if !ok {
+			return nil, nil, false
+		}
+		return sortKey, o.collation.memberKey(rest), true
+	}
+	c := append([]byte{}, k...)
+	complement(c)
+	sortKey, rest, ok := unescapeKey(c)
+	if !ok {
+		return nil, nil, false
+	}
+	return sortKey, o.collation.memberKey(k[len(k)-len(rest):]), true
+}
+
+// after seek key following all entries with sort key
+func (o sortOrder) after(sortKey []byte) []byte {
+	k := o.prefix(sortKey)
+	k[len(k)-1]++
+	return k
+}
+
+// migrateSortEscaped rewrite sort buckets written with 8 bytes sort keys to escaped sort keys
+func migrateSortEscaped(tx *Tx, f *Format) error {
+	var names [][]byte
+	if err := tx.tx.ForEach(func(name []byte, _ backendBucket) error {
+		if bytes.HasPrefix(name, _keyPrefix) {
+			names = append(names, append([]byte{}, name[len(_keyPrefix):]...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		kb := tx.tx.Bucket(shadowName(_keyPrefix, name))
+		var kvs [][]byte
+		if err := kb.ForEach(func(k, v []byte) error {
+			if len(k) >= 8 {
+				kvs = append(kvs, sortAscending.entryKey(k[:8], k[8:]), append([]byte{}, v...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.tx.DeleteBucket(shadowName(_keyPrefix, name)); err != nil {
+			return err
+		}
+		if tx.tx.Bucket(shadowName(_valuePrefix, name)) != nil {
+			if err := tx.tx.DeleteBucket(shadowName(_valuePrefix, name)); err != nil {
+				return err
+			}
+		}
+		kb, err := tx.tx.CreateBucketIfNotExists(shadowName(_keyPrefix, name))
+		if err != nil {
+			return err
+		}
+		vb, err := tx.tx.CreateBucketIfNotExists(shadowName(_valuePrefix, name))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			_, member, _ := sortAscending.split(kvs[i])
+			if err := kb.Put(kvs[i], kvs[i+1]); err != nil {
+				return err
+			}
+			if err := vb.Put(member, kvs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	f.Features = f.Features&^FeatureSortFixed | FeatureSortEscaped
+	return nil
+}
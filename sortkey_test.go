@@ -0,0 +1,226 @@
+package zbolt
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestTx_SortVariableKeys(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tags := []byte("tags")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.SortPut(tags, []byte("b"), []byte("k1"), []byte("v1"))
+	tx.SortPut(tags, []byte("ab"), []byte("k2"), []byte("v2"))
+	tx.SortPut(tags, []byte("a"), []byte("k3"), []byte("v3"), []byte("k4"), []byte("v4"))
+	tx.SortPut(tags, []byte("a\x00"), []byte("k5"), []byte("v5"))
+	if next := tx.SortNext(tags, nil, 0); len(next) != 10 || string(next[0]) != "k3" || string(next[4]) != "k5" || string(next[8]) != "k1" {
+		t.Fatalf("unexpected order %q", next)
+	}
+	if next := tx.SortNext(tags, []byte("a"), 0); len(next) != 6 || string(next[0]) != "k5" {
+		t.Fatalf("unexpected next %q", next)
+	}
+	if prev := tx.SortPrev(tags, []byte("ab"), 0); len(prev) != 6 || string(prev[0]) != "k5" || string(prev[4]) != "k3" {
+		t.Fatalf("unexpected prev %q", prev)
+	}
+	if prev := tx.SortPrev(tags, []byte("z"), 1); len(prev) != 2 || string(prev[0]) != "k1" {
+		t.Fatalf("unexpected prev %q", prev)
+	}
+	// move member to a sort key of another length
+	tx.SortPut(tags, []byte("abc"), []byte("k1"), []byte("v1"))
+	entries, _ := tx.SortPage(tags, nil, 0)
+	if len(entries) != 5 || string(entries[4].SortKey) != "abc" || string(entries[4].Key) != "k1" {
+		t.Fatal("unexpected entries", entries)
+	}
+}
+
+func TestMigrateFormat_SortEscaped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sort.db")
+	bdb, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sort bucket written by format version 1
+	tx := NewDB(bdb).NewTx(true)
+	tx.setFormat(Format{Version: 1, Features: FeatureSortFixed | FeatureMetaKinds})
+	for i, key := range []string{"e1", "e2"} {
+		entry := BytesConcat(Uint64ToBytes(uint64(2-i)), []byte(key))
+		kb, _ := tx.tx.CreateBucketIfNotExists(BytesConcat(_keyPrefix, []byte("feed")))
+		kb.Put(entry, []byte("v"+key))
+		vb, _ := tx.tx.CreateBucketIfNotExists(BytesConcat(_valuePrefix, []byte("feed")))
+		vb.Put([]byte(key), entry)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	bdb.Close()
+
+	if from, err := MigrateFormat(path); err != nil || from != 1 {
+		t.Fatal("migrate fail", from, err)
+	}
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tx = d.NewTx(true)
+	defer tx.Rollback()
+	if f, _ := tx.Format(); f.Features != defaultFeatures {
+		t.Fatal("unexpected features", f)
+	}
+	if next := tx.SortNext([]byte("feed"), Uint64ToBytes(1), 0); len(next) != 2 || string(next[0]) != "e1" {
+		t.Fatalf("unexpected next %q", next)
+	}
+	tx.SortDelete([]byte("feed"), []byte("e2"))
+	if next := tx.SortNext([]byte("feed"), nil, 0); len(next) != 2 || string(next[1]) != "ve1" {
+		t.Fatalf("unexpected next %q", next)
+	}
+}
+
+func TestTx_SortGet(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	feed := []byte("feed")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.SortPut(feed, Uint64ToBytes(1), []byte("a"), []byte("va"), []byte("b"), []byte("vb"))
+	tx.SortPut(feed, Uint64ToBytes(5), []byte("a"), []byte("va2"))
+	gets := tx.SortGet(feed, []byte("a"), []byte("x"), []byte("b"))
+	if len(gets) != 6 || string(gets[0]) != "a" || BytesToUint64(gets[1]) != 5 || string(gets[2]) != "va2" || string(gets[3]) != "b" || BytesToUint64(gets[4]) != 1 {
+		t.Fatalf("unexpected gets %q", gets)
+	}
+	if gets := tx.SortGet([]byte("missing"), []byte("a")); len(gets) != 0 {
+		t.Fatalf("unexpected gets %q", gets)
+	}
+}
+
+func TestTx_SortForEach(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	feed := []byte("feed")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.SortPut(feed, Uint64ToBytes(2), []byte("b"), []byte("vb"))
+	tx.SortPut(feed, Uint64ToBytes(1), []byte("a"), []byte("va"), []byte("c"), []byte("vc"))
+	var got string
+	if err := tx.SortForEach(feed, func(sortKey, key, value []byte) error {
+		got += fmt.Sprintf("%d%s%s,", BytesToUint64(sortKey), key, value)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "1ava,1cvc,2bvb," {
+		t.Fatal("unexpected iteration", got)
+	}
+	stop := errors.New("stop")
+	if err := tx.SortForEach(feed, func(sortKey, key, value []byte) error {
+		return stop
+	}); err != stop {
+		t.Fatal("expect stop, got", err)
+	}
+}
+
+func TestDB_EnableSortDescending(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	asc, desc := []byte("asc"), []byte("desc")
+	d.EnableSortDescending(desc, true)
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for _, name := range [][]byte{asc, desc} {
+		for i, sortKey := range []string{"a", "ab", "b", "a\x00", "\xff"} {
+			tx.SortPut(name, []byte(sortKey), []byte{byte('1' + i)}, []byte(sortKey))
+		}
+	}
+	next := tx.SortNext(desc, nil, 0)
+	if len(next) != 10 || string(next[0]) != "5" || string(next[2]) != "3" || string(next[8]) != "1" {
+		t.Fatalf("unexpected descending order %q", next)
+	}
+	if next := tx.SortNext(desc, []byte("ab"), 2); len(next) != 4 || string(next[0]) != "4" || string(next[2]) != "1" {
+		t.Fatalf("unexpected next %q", next)
+	}
+	if prev := tx.SortPrev(desc, []byte("ab"), 0); len(prev) != 4 || string(prev[0]) != "3" || string(prev[2]) != "5" {
+		t.Fatalf("unexpected prev %q", prev)
+	}
+	if gets := tx.SortGet(desc, []byte("2")); len(gets) != 3 || string(gets[1]) != "ab" {
+		t.Fatalf("unexpected gets %q", gets)
+	}
+	keys := func(entries []Entry) (s string) {
+		for _, e := range entries {
+			s += string(e.Key)
+		}
+		return s
+	}
+	for _, r := range [][2][]byte{{nil, nil}, {[]byte("a"), []byte("b")}, {[]byte("b"), []byte("a")}, {[]byte("ab"), nil}, {nil, []byte("ab")}} {
+		if a, d := keys(tx.SortRange(asc, r[0], r[1], 0)), keys(tx.SortRange(desc, r[0], r[1], 0)); a != d {
+			t.Fatalf("range %q differ %q %q", r, a, d)
+		}
+		if a, d := tx.SortCountRange(asc, r[0], r[1]), tx.SortCountRange(desc, r[0], r[1]); a != d {
+			t.Fatalf("count of range %q differ %d %d", r, a, d)
+		}
+	}
+	entries, _ := tx.SortPage(desc, nil, 1)
+	if len(entries) != 1 || string(entries[0].SortKey) != "\xff" {
+		t.Fatal("unexpected page", entries)
+	}
+	tx.SortDelete(desc, []byte("5"))
+	if n := tx.SortCount(desc); n != 4 {
+		t.Fatal("unexpected count", n)
+	}
+}
+
+func TestTx_SortReindex(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	feed := []byte("feed")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	tx.SortPut(feed, Uint64ToBytes(1), []byte("a"), []byte("va"), []byte("b"), []byte("vb"), []byte("c"), []byte("vc"))
+	kb := tx.tx.Bucket(BytesConcat(_keyPrefix, feed))
+	vb := tx.tx.Bucket(BytesConcat(_valuePrefix, feed))
+	vb.Delete([]byte("a"))                                           // entry without member
+	kb.Delete(sortAscending.entryKey(Uint64ToBytes(1), []byte("b"))) // member without entry
+	kb.Put([]byte("garbage"), []byte("x"))                           // not decodable
+	report, err := tx.SortReindex(feed)
+	if err != nil || report.Missing != 1 || report.Extra != 2 {
+		t.Fatal("unexpected report", report, err)
+	}
+	if gets := tx.SortGet(feed, []byte("a"), []byte("b"), []byte("c")); len(gets) != 6 || string(gets[0]) != "a" || string(gets[3]) != "c" {
+		t.Fatalf("unexpected gets %q", gets)
+	}
+	if n := tx.SortCount(feed); n != 2 {
+		t.Fatal("unexpected count", n)
+	}
+	if report, _ := tx.SortReindex(feed); len(report.Issues) != 0 {
+		t.Fatal("issues left", report.Issues)
+	}
+
+	// key bucket without member index
+	tx.tx.DeleteBucket(BytesConcat(_valuePrefix, feed))
+	if report, err := tx.SortReindex(feed); err != nil || report.Missing != 2 {
+		t.Fatal("unexpected report", report, err)
+	}
+	if gets := tx.SortGet(feed, []byte("a"), []byte("c")); len(gets) != 6 {
+		t.Fatalf("unexpected gets %q", gets)
+	}
+}
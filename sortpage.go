@@ -0,0 +1,260 @@
+package zbolt
+
+import (
+	"bytes"
+)
+
+// Entry member of bucket with sort
+type Entry struct {
+	SortKey []byte
+	Key     []byte
+	Value   []byte
+	Fields  []interface{} // sort key decoded by fields set with SetSortFields, nil if not set or not decodable
+}
+
+// SortCursor position in bucket with sort after a returned entry, encode its sort key and key
+// so paging resume correctly across equal sort keys and deleted entries. nil is the start of bucket
+type SortCursor []byte
+
+// entry split key of sort key bucket into entry, decoding sort key by fields
+func (o sortOrder) entry(k, v []byte, fields SortFields) (Entry, bool) {
+	sortKey, key, ok := o.split(k)
+	e := Entry{SortKey: sortKey, Key: key, Value: v}
+	if ok && fields != nil {
+		e.Fields, _ = fields.Decode(sortKey)
+	}
+	return e, ok
+}
+
+// sortLayout get order and fields of sort key bucket
+func (tx *Tx) sortLayout(name []byte) (sortOrder, SortFields) {
+	if tx.db == nil {
+		return sortAscending, nil
+	}
+	c := tx.db.config(name)
+	return c.sortOrder(), c.sortFields
+}
+
+// SortNextEntries get limit count entries after sort key like SortNext, with sort keys and their fields
+func (tx *Tx) SortNextEntries(name []byte, key []byte, limit int) []Entry {
+	entries := []Entry{}
+	if tx.err != nil {
+		return entries
+	}
+	b := tx.createShadowIfWritable(shadowName(_keyPrefix, name))
+	if b == nil {
+		return entries
+	}
+	o, fields := tx.sortLayout(name)
+	c := b.Cursor()
+	var k, v []byte
+	if len(key) == 0 { // if len key == 0, start with first one
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(o.after(key))
+	}
+	for ; k != nil && (limit <= 0 || len(entries) < limit); k, v = c.Next() {
+		if tx.canceled() {
+			return []Entry{}
+		}
+		if e, ok := o.entry(k, v, fields); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// SortPrevEntries get limit count entries front sort key like SortPrev, with sort keys and their fields
+func (tx *Tx) SortPrevEntries(name []byte, key []byte, limit int) []Entry {
+	entries := []Entry{}
+	if tx.err != nil {
+		return entries
+	}
+	b := tx.createShadowIfWritable(shadowName(_keyPrefix, name))
+	if b == nil {
+		return entries
+	}
+	o, fields := tx.sortLayout(name)
+	c := b.Cursor()
+	var k, v []byte
+	if len(key) == 0 { // if len key == 0, start with last one
+		k, v = c.Last()
+	} else if k, _ = c.Seek(o.prefix(key)); k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	for ; k != nil && (limit <= 0 || len(entries) < limit); k, v = c.Prev() {
+		if tx.canceled() {
+			return []Entry{}
+		}
+		if e, ok := o.entry(k, v, fields); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// entryKVs flatten entries like [key1,value1,key2,value2, ...]
+func entryKVs(entries []Entry) [][]byte {
+	bs := make([][]byte, 0, 2*len(entries))
+	for _, e := range entries {
+		bs = append(bs, e.Key, e.Value)
+	}
+	return bs
+}
+
+// SortPage get limit count entries of bucket with sort after cursor ordered by sort key then key, descending if enabled,
+// next is the cursor of the following page, nil when no entry is left. limit = 0 representative of all
+func (tx *Tx) SortPage(name []byte, cursor SortCursor, limit int) (entries []Entry, next SortCursor) {
+	entries = []Entry{}
+	if tx.err != nil {
+		return entries, nil
+	}
+	b := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	if b == nil {
+		return entries, nil
+	}
+	o, fields := tx.sortLayout(name)
+	c := b.Cursor()
+	var k, v []byte
+	if len(cursor) == 0 {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(cursor)
+		if bytes.Equal(k, cursor) {
+			k, v = c.Next()
+		}
+	}
+	for ; k != nil; k, v = c.Next() {
+		if tx.canceled() {
+			return []Entry{}, nil
+		}
+		e, ok := o.entry(k, v, fields)
+		if !ok {
+			continue
+		}
+		if limit > 0 && len(entries) >= limit {
+			return entries, SortCursor(append([]byte{}, cursor...))
+		}
+		entries = append(entries, e)
+		cursor = k
+	}
+	return entries, nil
+}
+
+// SortRange get limit count entries of bucket with sort whose sort key is in [from, to), ordered by sort key then key.
+// If from is greater than to, entries are in descending order with sort key in (to, from].
+// nil from is the start of bucket and nil to the end, limit = 0 representative of all
+func (tx *Tx) SortRange(name []byte, from, to []byte, limit int) []Entry {
+	return tx.sortRange(name, from, to, limit, false)
+}
+
+// SortRangeKeys get limit count entries like SortRange without reading values, Value and Fields of entries are nil
+func (tx *Tx) SortRangeKeys(name []byte, from, to []byte, limit int) []Entry {
+	return tx.sortRange(name, from, to, limit, true)
+}
+
+// sortRange get entries of SortRange, without values and fields if keysOnly
+func (tx *Tx) sortRange(name []byte, from, to []byte, limit int, keysOnly bool) []Entry {
+	entries := []Entry{}
+	if tx.err != nil {
+		return entries
+	}
+	b := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	if b == nil {
+		return entries
+	}
+	desc := from != nil && to != nil && bytes.Compare(from, to) > 0
+	o, fields := tx.sortLayout(name)
+	if keysOnly {
+		fields = nil
+	}
+	forward := desc == o.desc
+	c := b.Cursor()
+	var k, v []byte
+	switch {
+	case from == nil && forward:
+		k, v = c.First()
+	case from == nil:
+		k, v = c.Last()
+	case forward:
+		k, v = c.Seek(o.prefix(from))
+	default:
+		if k, _ = c.Seek(o.after(from)); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+	for k != nil && (limit <= 0 || len(entries) < limit) {
+		if tx.canceled() {
+			return []Entry{}
+		}
+		if keysOnly {
+			v = nil
+		}
+		e, ok := o.entry(k, v, fields)
+		if ok {
+			if to != nil && (!desc && bytes.Compare(e.SortKey, to) >= 0 || desc && bytes.Compare(e.SortKey, to) <= 0) {
+				break
+			}
+			entries = append(entries, e)
+		}
+		if forward {
+			k, v = c.Next()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+	return entries
+}
+
+// SortCount count entries of bucket with sort
+func (tx *Tx) SortCount(name []byte) int {
+	return tx.SortCountRange(name, nil, nil)
+}
+
+// SortCountRange count entries of bucket with sort whose sort key is in the range of SortRange, without reading values
+func (tx *Tx) SortCountRange(name []byte, from, to []byte) int {
+	if tx.err != nil {
+		return 0
+	}
+	b := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	if b == nil {
+		return 0
+	}
+	// bounds of encoded keys, a range against the order of bucket spans from after to to after from
+	var lo, hi []byte
+	o := tx.sortOrder(name)
+	if desc := from != nil && to != nil && bytes.Compare(from, to) > 0; desc == o.desc {
+		if from != nil {
+			lo = o.prefix(from)
+		}
+		if to != nil {
+			hi = o.prefix(to)
+		}
+	} else {
+		if to != nil {
+			lo = o.after(to)
+		}
+		if from != nil {
+			hi = o.after(from)
+		}
+	}
+	c := b.Cursor()
+	var k []byte
+	if lo == nil {
+		k, _ = c.First()
+	} else {
+		k, _ = c.Seek(lo)
+	}
+	n := 0
+	for ; k != nil && (hi == nil || bytes.Compare(k, hi) < 0); k, _ = c.Next() {
+		if tx.canceled() {
+			return 0
+		}
+		n++
+	}
+	return n
+}
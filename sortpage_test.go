@@ -0,0 +1,108 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestTx_SortPage(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	feed := []byte("feed")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	// five posts sharing two timestamps
+	tx.SortPut(feed, Uint64ToBytes(1), []byte("a"), []byte("va"), []byte("b"), []byte("vb"), []byte("c"), []byte("vc"))
+	tx.SortPut(feed, Uint64ToBytes(2), []byte("d"), []byte("vd"), []byte("e"), []byte("ve"))
+	var keys []string
+	entries, next := tx.SortPage(feed, nil, 2)
+	for _, e := range entries {
+		keys = append(keys, string(e.Key))
+	}
+	if len(entries) != 2 || next == nil || BytesToUint64(entries[0].SortKey) != 1 {
+		t.Fatal("unexpected first page", entries, next)
+	}
+	// entry after the cursor deleted between pages
+	tx.SortDelete(feed, []byte("c"))
+	for next != nil {
+		entries, next = tx.SortPage(feed, next, 2)
+		for _, e := range entries {
+			keys = append(keys, string(e.Key))
+		}
+	}
+	if len(keys) != 4 || keys[0] != "a" || keys[1] != "b" || keys[2] != "d" || keys[3] != "e" {
+		t.Fatal("unexpected pages", keys)
+	}
+	if entries, next := tx.SortPage(feed, nil, 0); len(entries) != 4 || next != nil || string(entries[3].Value) != "ve" {
+		t.Fatal("unexpected page of all", entries, next)
+	}
+}
+
+func TestTx_SortRange(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := []byte("events")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		tx.SortPut(events, Uint64ToBytes(uint64(i+1)), []byte(key), []byte("v"+key))
+	}
+	keys := func(entries []Entry) (s string) {
+		for _, e := range entries {
+			s += string(e.Key)
+		}
+		return s
+	}
+	if s := keys(tx.SortRange(events, Uint64ToBytes(2), Uint64ToBytes(4), 0)); s != "bc" {
+		t.Fatal("unexpected range", s)
+	}
+	if s := keys(tx.SortRange(events, Uint64ToBytes(4), Uint64ToBytes(1), 0)); s != "dcb" {
+		t.Fatal("unexpected descending range", s)
+	}
+	if s := keys(tx.SortRange(events, Uint64ToBytes(9), Uint64ToBytes(3), 1)); s != "e" {
+		t.Fatal("unexpected limited descending range", s)
+	}
+	if s := keys(tx.SortRange(events, nil, Uint64ToBytes(3), 0)); s != "ab" {
+		t.Fatal("unexpected open range", s)
+	}
+	if s := keys(tx.SortRange(events, Uint64ToBytes(4), nil, 0)); s != "de" {
+		t.Fatal("unexpected open range", s)
+	}
+	if es := tx.SortRangeKeys(events, Uint64ToBytes(4), Uint64ToBytes(1), 0); keys(es) != "dcb" || es[0].Value != nil {
+		t.Fatal("unexpected keys only range", keys(es))
+	}
+}
+
+func TestTx_SortCountRange(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	events := []byte("events")
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		tx.SortPut(events, Uint64ToBytes(uint64(i/2+1)), []byte(key), []byte("v"+key))
+	}
+	if n := tx.SortCount(events); n != 5 {
+		t.Fatal("unexpected count", n)
+	}
+	if n := tx.SortCountRange(events, Uint64ToBytes(1), Uint64ToBytes(3)); n != 4 {
+		t.Fatal("unexpected range count", n)
+	}
+	if n := tx.SortCountRange(events, Uint64ToBytes(3), Uint64ToBytes(1)); n != len(tx.SortRange(events, Uint64ToBytes(3), Uint64ToBytes(1), 0)) || n != 3 {
+		t.Fatal("unexpected descending range count", n)
+	}
+	if n := tx.SortCountRange(events, Uint64ToBytes(2), nil); n != 3 {
+		t.Fatal("unexpected open range count", n)
+	}
+	if n := tx.SortCount([]byte("missing")); n != 0 {
+		t.Fatal("unexpected count of missing bucket", n)
+	}
+}
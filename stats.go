@@ -0,0 +1,74 @@
+package zbolt
+
+import (
+	"time"
+)
+
+// Stats page and transaction stats of storage engine, counters are totals since the file was opened.
+// Memory backend only reports Writes
+type Stats struct {
+	FreePageN     int // free pages on the freelist
+	PendingPageN  int // pages freed by transactions still read
+	FreeAlloc     int // bytes allocated in free pages
+	FreelistInuse int // bytes used by the freelist
+	TxN           int // started read transactions
+	OpenTxN       int // open read transactions
+
+	PageCount  int // page allocations
+	PageAlloc  int // bytes of page allocations
+	Rebalances int // node rebalances
+	Splits     int // node splits
+	Spills     int // node spills
+	Writes     int // count of page writes of committed transactions
+	WriteTime  time.Duration
+}
+
+// BucketStats page stats of a bucket and its nested buckets
+type BucketStats struct {
+	KeyN  int // keys
+	Depth int // levels of the B+tree
+
+	BranchPageN     int // logical branch pages
+	BranchOverflowN int // physical branch overflow pages
+	LeafPageN       int // logical leaf pages
+	LeafOverflowN   int // physical leaf overflow pages
+	BranchAlloc     int // bytes allocated for branch pages
+	BranchInuse     int // bytes used by branch pages
+	LeafAlloc       int // bytes allocated for leaf pages
+	LeafInuse       int // bytes used by leaf pages
+
+	BucketN           int // buckets including the bucket itself
+	InlineBucketN     int // inlined buckets
+	InlineBucketInuse int // bytes used by inlined buckets
+}
+
+// backendBucketStater bucket reporting its page stats
+type backendBucketStater interface {
+	BucketStats() BucketStats
+}
+
+// Stats get page and transaction stats of storage engine
+func (db *DB) Stats() Stats {
+	return db.engine().Stats()
+}
+
+// BucketStats get page stats of bucket, of its key bucket for a bucket with sort only.
+// Like Count, stats of writable tx miss changes not yet written to pages.
+// Return ErrRecordNotFound if bucket does not exist
+func (tx *Tx) BucketStats(name []byte) (BucketStats, error) {
+	if tx.err != nil {
+		return BucketStats{}, tx.err
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		b = tx.tx.Bucket(shadowName(_keyPrefix, name))
+	}
+	if b == nil {
+		return BucketStats{}, ErrRecordNotFound
+	}
+	s, ok := b.(backendBucketStater)
+	if !ok {
+		return BucketStats{}, nil
+	}
+	return s.BucketStats(), nil
+}
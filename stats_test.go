@@ -0,0 +1,40 @@
+package zbolt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTx_BucketStats(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	name := []byte("stats")
+	err = db.Update(func(tx *Tx) error {
+		for i := 0; i < 100; i++ {
+			tx.Put(name, Uint64ToBytes(uint64(i)), []byte("value"))
+		}
+		return tx.Error()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := db.Stats(); s.Writes == 0 || s.TxN == 0 {
+		t.Fatal("expect writes and read tx counted", s)
+	}
+	tx := db.NewTx(false)
+	defer tx.Rollback()
+	s, err := tx.BucketStats(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.KeyN != 100 || s.Depth == 0 || s.LeafInuse == 0 {
+		t.Fatal("unexpected bucket stats", s)
+	}
+	if _, err := tx.BucketStats([]byte("missing")); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatal("expect missing bucket not found", err)
+	}
+}
@@ -0,0 +1,56 @@
+package zbolt
+
+import (
+	"context"
+	"sort"
+)
+
+// tagsKey context key of tags
+type tagsKey struct{}
+
+// ContextWithTag attach tag k=v to ctx, transactions created by NewTxContext with ctx carry it
+func ContextWithTag(ctx context.Context, k, v string) context.Context {
+	old, _ := ctx.Value(tagsKey{}).(map[string]string)
+	tags := make(map[string]string, len(old)+1)
+	for tk, tv := range old {
+		tags[tk] = tv
+	}
+	tags[k] = v
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+// WithTag attach tag k=v to tx, like request id or user id, so hooks can correlate it with the application request
+func (tx *Tx) WithTag(k, v string) *Tx {
+	if tx.tags == nil {
+		tx.tags = make(map[string]string)
+	}
+	tx.tags[k] = v
+	return tx
+}
+
+// Tag get value of tag k of tx, empty if not set
+func (tx *Tx) Tag(k string) string {
+	return tx.tags[k]
+}
+
+// Tags get tags of tx as sorted [k1,v1,k2,v2, ...]
+func (tx *Tx) Tags() []string {
+	keys := make([]string, 0, len(tx.tags))
+	for k := range tx.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		tags = append(tags, k, tx.tags[k])
+	}
+	return tags
+}
+
+// contextTags copy tags attached to ctx into tx
+func (tx *Tx) contextTags(ctx context.Context) {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	for k, v := range tags {
+		tx.WithTag(k, v)
+	}
+}
@@ -0,0 +1,37 @@
+package zbolt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestTx_WithTag(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	var seen []string
+	d.OnPreCommit(func(tx *Tx) error {
+		seen = tx.Tags()
+		return nil
+	})
+	ctx := ContextWithTag(context.Background(), "request", "r1")
+	tx := d.NewTxContext(ContextWithTag(ctx, "user", "u1"), true)
+	defer tx.Rollback()
+	tx.WithTag("op", "signup").Put([]byte("users"), []byte("u1"), []byte("alice"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(seen) != "[op signup request r1 user u1]" {
+		t.Fatal("unexpected tags in hook", seen)
+	}
+	if tx.Tag("user") != "u1" || tx.Tag("none") != "" {
+		t.Fatal("unexpected tag")
+	}
+	// parent context keep its own tags
+	if tags := d.NewTxContext(ctx, false).WithTag("x", "y").Tags(); fmt.Sprint(tags) != "[request r1 x y]" {
+		t.Fatal("unexpected tags", tags)
+	}
+}
@@ -0,0 +1,129 @@
+package zbolt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op operation of template, params are the ones passed to Exec
+type Op func(tx *Tx, params map[string][]byte) error
+
+// Template named operations across buckets executed in order in one transaction by Exec
+type Template struct {
+	Name string
+	Ops  []Op
+}
+
+// ExecHook hook called before a template is executed, like audit
+type ExecHook func(tx *Tx, name string, params map[string][]byte) error
+
+// RegisterTemplate register template on db, replace the one with the same name
+func (db *DB) RegisterTemplate(t Template) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.templates == nil {
+		db.templates = make(map[string]Template)
+	}
+	db.templates[t.Name] = t
+}
+
+// OnExec register hook called before every template executed by Exec, error returned by hook abort Exec
+func (db *DB) OnExec(hook ExecHook) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.execHooks = append(db.execHooks, hook)
+}
+
+// Exec run ops of template name in tx, stop at the first error which is accumulated in tx.
+// Return ErrNoTemplate if name is not registered
+func (tx *Tx) Exec(name string, params map[string][]byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if tx.db == nil {
+		return tx.Error(fmt.Errorf("%w: %q", ErrNoTemplate, name))
+	}
+	tx.db.mu.RLock()
+	t, ok := tx.db.templates[name]
+	hooks := tx.db.execHooks
+	tx.db.mu.RUnlock()
+	if !ok {
+		return tx.Error(fmt.Errorf("%w: %q", ErrNoTemplate, name))
+	}
+	for _, hook := range hooks {
+		if tx.Error(hook(tx, name, params)) != nil {
+			return tx.err
+		}
+	}
+	for _, op := range t.Ops {
+		if tx.Error(op(tx, params)) != nil {
+			return tx.err
+		}
+	}
+	return nil
+}
+
+// expand replace "{param}" in pattern with value of param
+func expand(pattern string, params map[string][]byte) ([]byte, error) {
+	var b []byte
+	for {
+		i := strings.IndexByte(pattern, '{')
+		j := strings.IndexByte(pattern[i+1:], '}')
+		if i < 0 || j < 0 {
+			return append(b, pattern...), nil
+		}
+		name := pattern[i+1 : i+1+j]
+		v, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrTemplateParam, name)
+		}
+		b = append(append(b, pattern[:i]...), v...)
+		pattern = pattern[i+2+j:]
+	}
+}
+
+// expandAll expand patterns in order
+func expandAll(params map[string][]byte, patterns ...string) ([][]byte, error) {
+	bs := make([][]byte, len(patterns))
+	for i, p := range patterns {
+		b, err := expand(p, params)
+		if err != nil {
+			return nil, err
+		}
+		bs[i] = b
+	}
+	return bs, nil
+}
+
+// PutOp op put value to key of bucket, each of them a pattern with "{param}" replaced by params of Exec
+func PutOp(bucket, key, value string) Op {
+	return func(tx *Tx, params map[string][]byte) error {
+		bs, err := expandAll(params, bucket, key, value)
+		if err != nil {
+			return err
+		}
+		return tx.Put(bs[0], bs[1], bs[2])
+	}
+}
+
+// SortPutOp op sort put value to key of bucket with sortKey, patterns like PutOp
+func SortPutOp(bucket, sortKey, key, value string) Op {
+	return func(tx *Tx, params map[string][]byte) error {
+		bs, err := expandAll(params, bucket, sortKey, key, value)
+		if err != nil {
+			return err
+		}
+		return tx.SortPut(bs[0], bs[1], bs[2], bs[3])
+	}
+}
+
+// DeleteOp op delete key of bucket, patterns like PutOp
+func DeleteOp(bucket, key string) Op {
+	return func(tx *Tx, params map[string][]byte) error {
+		bs, err := expandAll(params, bucket, key)
+		if err != nil {
+			return err
+		}
+		return tx.Delete(bs[0], bs[1])
+	}
+}
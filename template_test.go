@@ -0,0 +1,50 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTx_Exec(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.RegisterTemplate(Template{Name: "createUser", Ops: []Op{
+		PutOp("users", "{id}", "{name}"),
+		PutOp("counters", "{id}:posts", "0"),
+		SortPutOp("users_by_time", "{at}", "{id}", "{name}"),
+	}})
+	var audit []string
+	d.OnExec(func(tx *Tx, name string, params map[string][]byte) error {
+		audit = append(audit, name+" "+string(params["id"]))
+		return nil
+	})
+
+	tx := d.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.Exec("createUser", map[string][]byte{"id": []byte("u1"), "name": []byte("alice"), "at": Uint64ToBytes(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if gets := tx.Get([]byte("users"), []byte("u1")); len(gets) != 2 || string(gets[1]) != "alice" {
+		t.Fatal("profile not put", gets)
+	}
+	if gets := tx.Get([]byte("counters"), []byte("u1:posts")); len(gets) != 2 || string(gets[1]) != "0" {
+		t.Fatal("counter not put", gets)
+	}
+	if next := tx.SortNext([]byte("users_by_time"), nil, 0); len(next) != 2 || string(next[0]) != "u1" {
+		t.Fatal("index not put", next)
+	}
+	if len(audit) != 1 || audit[0] != "createUser u1" {
+		t.Fatal("unexpected audit", audit)
+	}
+
+	if err := tx.Exec("createUser", map[string][]byte{"id": []byte("u2")}); !errors.Is(err, ErrTemplateParam) {
+		t.Fatal("expect ErrTemplateParam, got", err)
+	}
+	tx.Error(ErrNil)
+	if err := tx.Exec("deleteUser", nil); !errors.Is(err, ErrNoTemplate) {
+		t.Fatal("expect ErrNoTemplate, got", err)
+	}
+}
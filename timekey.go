@@ -0,0 +1,45 @@
+package zbolt
+
+import (
+	"bytes"
+	"time"
+)
+
+// TimeKV key value of bucket keyed by TimeToBytes, key may have a suffix after the 8 bytes time to keep it unique
+type TimeKV struct {
+	Time  time.Time
+	Key   []byte
+	Value []byte
+}
+
+// TimeRange get limit count key value with key time in [from, to) in order, limit = 0 representative of all.
+// Keys shorter than 8 bytes are skipped
+func (tx *Tx) TimeRange(name []byte, from, to time.Time, limit int) []TimeKV {
+	if tx.err != nil {
+		return []TimeKV{}
+	}
+	b := tx.createBucketIfWritable(name)
+	if b == nil {
+		return []TimeKV{}
+	}
+	end := TimeToBytes(to)
+	r := tx.reader(name)
+	var res []TimeKV
+	c := b.Cursor()
+	for k, v := c.Seek(TimeToBytes(from)); k != nil; k, v = c.Next() {
+		if len(k) < 8 {
+			continue
+		}
+		if bytes.Compare(k[:8], end) >= 0 {
+			break
+		}
+		if tx.canceled() {
+			return []TimeKV{}
+		}
+		res = append(res, TimeKV{Time: BytesToTime(k[:8]), Key: k, Value: r.value(k, v)})
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+	return res
+}
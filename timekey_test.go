@@ -0,0 +1,37 @@
+package zbolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTx_TimeRange(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "time.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("log")
+	base := time.Date(1969, 12, 31, 23, 59, 0, 0, time.UTC)
+	d.Update(func(tx *Tx) error {
+		for i := 0; i < 5; i++ {
+			at := base.Add(time.Duration(i) * 30 * time.Second)
+			tx.Put(name, BytesConcat(TimeToBytes(at), []byte("#1")), []byte{byte('a' + i)})
+		}
+		return tx.Put(name, []byte("x"), []byte("short key"))
+	})
+	d.View(func(tx *Tx) error {
+		kvs := tx.TimeRange(name, base.Add(30*time.Second), base.Add(2*time.Minute), 0)
+		if len(kvs) != 3 || !kvs[0].Time.Equal(base.Add(30*time.Second)) || string(kvs[2].Value) != "d" {
+			t.Fatal("unexpected range", kvs)
+		}
+		if kvs := tx.TimeRange(name, time.Time{}, base.Add(time.Hour), 2); len(kvs) != 2 || string(kvs[0].Value) != "a" {
+			t.Fatal("unexpected limited range", kvs)
+		}
+		return nil
+	})
+	if now := time.Now(); !BytesToTime(TimeToBytes(now)).Equal(now) || BytesToUint64(TimeToBytes(time.Time{})) != 0 {
+		t.Fatal("time roundtrip failed")
+	}
+}
@@ -0,0 +1,60 @@
+package zbolt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// tokenIDSize count of random bytes of token id
+const tokenIDSize = 16
+
+// Tokens store of one-time tokens expiring after ttl, like email verification or password reset tokens.
+// Expired tokens can not be redeemed and are removed by Sweep or StartSweeper
+type Tokens struct {
+	db   *DB
+	name []byte
+}
+
+// Tokens get store of one-time tokens kept in bucket name
+func (db *DB) Tokens(name []byte) *Tokens {
+	return &Tokens{db: db, name: name}
+}
+
+// Issue store payload under a new random token id expiring after ttl, return the id
+func (t *Tokens) Issue(ttl time.Duration, payload []byte) (string, error) {
+	b := make([]byte, tokenIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	// tagged so that empty payloads are stored as non empty values
+	err := t.db.Update(func(tx *Tx) error {
+		return tx.PutTTL(t.name, ttl, []byte(id), BytesConcat([]byte{0}, payload))
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Redeem validate token id, delete it and return its payload in one transaction, so a token is redeemed at most once.
+// Return ErrRecordNotFound if token is unknown, expired or already redeemed
+func (t *Tokens) Redeem(id string) ([]byte, error) {
+	var payload []byte
+	err := t.db.Update(func(tx *Tx) error {
+		gets := tx.Get(t.name, []byte(id))
+		if len(gets) == 0 {
+			if tx.err != nil {
+				return tx.err
+			}
+			return ErrRecordNotFound
+		}
+		payload = append([]byte{}, gets[1][1:]...)
+		return tx.Delete(t.name, []byte(id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,35 @@
+package zbolt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokens(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	tokens := d.Tokens([]byte("tokens"))
+	id, err := tokens.Issue(time.Hour, []byte("user:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := tokens.Redeem(id); err != nil || string(p) != "user:1" {
+		t.Fatal("redeem fail", string(p), err)
+	}
+	if _, err := tokens.Redeem(id); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatal("token redeemed twice", err)
+	}
+
+	empty, _ := tokens.Issue(time.Hour, nil)
+	if p, err := tokens.Redeem(empty); err != nil || len(p) != 0 {
+		t.Fatal("redeem empty payload fail", p, err)
+	}
+	expired, _ := tokens.Issue(-time.Second, []byte("late"))
+	if _, err := tokens.Redeem(expired); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatal("expired token redeemed", err)
+	}
+}
@@ -0,0 +1,289 @@
+package zbolt
+
+import (
+	"bytes"
+	"sync/atomic"
+	"time"
+)
+
+var _expiryPrefix = []byte{27}
+
+// tags of entries in expiry bucket
+const (
+	expiryKey  byte = 0 // [0, key] -> expire time
+	expiryTime byte = 1 // [1, expire time, key] -> nil, ordered by expire time
+)
+
+// expiryBucketName bucket of expire times of keys of bucket
+func expiryBucketName(name []byte) []byte {
+	return shadowName(_expiryPrefix, name)
+}
+
+// openTTL enable expiry of buckets having expiry bucket in file
+func (db *DB) openTTL() error {
+	return db.View(db.loadTTL)
+}
+
+// loadTTL enable expiry of buckets having expiry bucket in file read by tx
+func (db *DB) loadTTL(tx *Tx) error {
+	var names [][]byte
+	err := tx.tx.ForEach(func(name []byte, b backendBucket) error {
+		if bytes.HasPrefix(name, _expiryPrefix) {
+			names = append(names, append([]byte{}, name[len(_expiryPrefix):]...))
+		}
+		return nil
+	})
+	for _, name := range names {
+		db.enableTTL(name)
+	}
+	return err
+}
+
+// enableTTL hide expired keys of bucket
+func (db *DB) enableTTL(name []byte) {
+	if db.config(name).ttl {
+		return
+	}
+	db.setConfig(name, func(c *bucketConfig) {
+		c.ttl = true
+	})
+	atomic.StoreInt32(&db.wrapped, 1)
+}
+
+// PutTTL put keys values to bucket expiring after ttl, input like [key1,value1,key2,value2, ...].
+// Expired keys are missing for reads until swept, Put without ttl makes a key persistent again
+func (tx *Tx) PutTTL(name []byte, ttl time.Duration, kvs ...[]byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	tx.db.enableTTL(name)
+	e, err := tx.tx.CreateBucketIfNotExists(expiryBucketName(name))
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	if tx.Put(name, kvs...) != nil {
+		return tx.err
+	}
+	at := time.Now().Add(ttl)
+	for i := 0; i < len(kvs); i += 2 {
+		if tx.Error(setExpiry(e, kvs[i], at)) != nil {
+			return tx.err
+		}
+	}
+	return nil
+}
+
+// ExpireAt expire existing key of bucket at t, return ErrRecordNotFound if key not exist
+func (tx *Tx) ExpireAt(name, key []byte, t time.Time) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(tx.Get(name, key)) == 0 {
+		if tx.err != nil {
+			return tx.err
+		}
+		return ErrRecordNotFound
+	}
+	tx.db.enableTTL(name)
+	e, err := tx.tx.CreateBucketIfNotExists(expiryBucketName(name))
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	return tx.Error(setExpiry(e, key, t))
+}
+
+// ExpiresAt get expire time of key, ok is false if key never expire
+func (tx *Tx) ExpiresAt(name, key []byte) (t time.Time, ok bool) {
+	if tx.err != nil {
+		return
+	}
+	e := tx.tx.Bucket(expiryBucketName(name))
+	if e == nil {
+		return
+	}
+	v := e.Get(BytesConcat([]byte{expiryKey}, key))
+	if len(v) != 8 {
+		return
+	}
+	return BytesToTime(v), true
+}
+
+// setExpiry set expire time of key in expiry bucket
+func setExpiry(e backendBucket, key []byte, t time.Time) error {
+	if err := clearExpiry(e, key); err != nil {
+		return err
+	}
+	at := TimeToBytes(t)
+	if err := e.Put(BytesConcat([]byte{expiryKey}, key), at); err != nil {
+		return err
+	}
+	return e.Put(BytesConcat([]byte{expiryTime}, at, key), nil)
+}
+
+// clearExpiry remove expire time of key from expiry bucket
+func clearExpiry(e backendBucket, key []byte) error {
+	k := BytesConcat([]byte{expiryKey}, key)
+	at := e.Get(k)
+	if at == nil {
+		return nil
+	}
+	if err := e.Delete(BytesConcat([]byte{expiryTime}, at, key)); err != nil {
+		return err
+	}
+	return e.Delete(k)
+}
+
+// Sweep delete expired keys of all buckets, at most batch keys per transaction, return count of deleted keys
+func (db *DB) Sweep(batch int) (int, error) {
+	if batch <= 0 {
+		batch = 1000
+	}
+	db.mu.RLock()
+	var names [][]byte
+	for name, c := range db.buckets {
+		if c.ttl {
+			names = append(names, []byte(name))
+		}
+	}
+	db.mu.RUnlock()
+	total := 0
+	for _, name := range names {
+		for {
+			n := 0
+			err := db.Update(func(tx *Tx) error {
+				e := tx.tx.Bucket(expiryBucketName(name))
+				if e == nil {
+					return nil
+				}
+				now := TimeToBytes(time.Now())
+				var keys [][]byte
+				c := e.Cursor()
+				for k, _ := c.Seek([]byte{expiryTime}); len(keys) < batch && len(k) >= 9 && k[0] == expiryTime && bytes.Compare(k[1:9], now) <= 0; k, _ = c.Next() {
+					keys = append(keys, append([]byte{}, k[9:]...))
+				}
+				for _, key := range keys {
+					if err := clearExpiry(e, key); err != nil {
+						return err
+					}
+					if err := tx.Delete(name, key); err != nil {
+						return err
+					}
+				}
+				n = len(keys)
+				return nil
+			})
+			if err != nil {
+				return total, err
+			}
+			total += n
+			if n < batch {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// StartSweeper start background sweeper deleting expired keys every interval
+func (db *DB) StartSweeper(interval time.Duration, batch int) {
+	db.sweeper.start(db, "ttl", interval, func() { db.Sweep(batch) })
+}
+
+// StopSweeper stop background sweeper and wait it exit
+func (db *DB) StopSweeper() {
+	db.sweeper.halt()
+}
+
+// ttlBucket bucket with expiring keys, expired keys are missing
+type ttlBucket struct {
+	backendBucket
+	e   backendBucket
+	now time.Time
+}
+
+// withExpired bucket b showing expired keys, so entries derived from them can be removed
+func withExpired(b backendBucket) backendBucket {
+	if t, ok := b.(ttlBucket); ok {
+		return t.backendBucket
+	}
+	return b
+}
+
+// ttlCursor cursor of ttlBucket skipping expired keys
+type ttlCursor struct {
+	backendCursor
+	b ttlBucket
+}
+
+// expired check key has expired
+func (b ttlBucket) expired(key []byte) bool {
+	at := b.e.Get(BytesConcat([]byte{expiryKey}, key))
+	return len(at) == 8 && !BytesToTime(at).After(b.now)
+}
+
+func (b ttlBucket) Get(key []byte) []byte {
+	if b.expired(key) {
+		return nil
+	}
+	return b.backendBucket.Get(key)
+}
+
+func (b ttlBucket) Put(key, value []byte) error {
+	if err := b.backendBucket.Put(key, value); err != nil {
+		return err
+	}
+	return clearExpiry(b.e, key)
+}
+
+func (b ttlBucket) Delete(key []byte) error {
+	if err := b.backendBucket.Delete(key); err != nil {
+		return err
+	}
+	return clearExpiry(b.e, key)
+}
+
+func (b ttlBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.backendBucket.ForEach(func(k, v []byte) error {
+		if b.expired(k) {
+			return nil
+		}
+		return fn(k, v)
+	})
+}
+
+func (b ttlBucket) Cursor() backendCursor {
+	return ttlCursor{b.backendBucket.Cursor(), b}
+}
+
+// skip move cursor by step while key has expired
+func (c ttlCursor) skip(k, v []byte, step func() ([]byte, []byte)) ([]byte, []byte) {
+	for k != nil && c.b.expired(k) {
+		k, v = step()
+	}
+	return k, v
+}
+
+func (c ttlCursor) First() ([]byte, []byte) {
+	k, v := c.backendCursor.First()
+	return c.skip(k, v, c.backendCursor.Next)
+}
+
+func (c ttlCursor) Last() ([]byte, []byte) {
+	k, v := c.backendCursor.Last()
+	return c.skip(k, v, c.backendCursor.Prev)
+}
+
+func (c ttlCursor) Next() ([]byte, []byte) {
+	k, v := c.backendCursor.Next()
+	return c.skip(k, v, c.backendCursor.Next)
+}
+
+func (c ttlCursor) Prev() ([]byte, []byte) {
+	k, v := c.backendCursor.Prev()
+	return c.skip(k, v, c.backendCursor.Prev)
+}
+
+func (c ttlCursor) Seek(seek []byte) ([]byte, []byte) {
+	k, v := c.backendCursor.Seek(seek)
+	return c.skip(k, v, c.backendCursor.Next)
+}
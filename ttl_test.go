@@ -0,0 +1,77 @@
+package zbolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTx_PutTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ttl.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := []byte("sessions")
+	d.Update(func(tx *Tx) error {
+		tx.PutTTL(name, -time.Second, []byte("s1"), []byte("old"), []byte("s3"), []byte("old"))
+		tx.PutTTL(name, time.Hour, []byte("s2"), []byte("live"))
+		tx.Put(name, []byte("s4"), []byte("forever"))
+		return tx.ExpireAt(name, []byte("s4"), time.Now().Add(-time.Minute))
+	})
+	d.Close()
+
+	// expiry survives reopen
+	if d, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.View(func(tx *Tx) error {
+		if gets := tx.Get(name, []byte("s1"), []byte("s2")); len(gets) != 2 || string(gets[0]) != "s2" {
+			t.Errorf("unexpected get %q", gets)
+		}
+		if next := tx.Next(name, nil, 0); len(next) != 2 || string(next[0]) != "s2" {
+			t.Errorf("unexpected next %q", next)
+		}
+		if prev := tx.Prev(name, nil, 0); len(prev) != 2 {
+			t.Errorf("unexpected prev %q", prev)
+		}
+		if at, ok := tx.ExpiresAt(name, []byte("s2")); !ok || at.Before(time.Now()) {
+			t.Error("unexpected expire time", at, ok)
+		}
+		return nil
+	})
+	d.Update(func(tx *Tx) error {
+		if err := tx.ExpireAt(name, []byte("s9"), time.Now()); err != ErrRecordNotFound {
+			t.Error("expect ErrRecordNotFound, got", err)
+		}
+		// put without ttl makes key persistent
+		return tx.Put(name, []byte("s3"), []byte("new"))
+	})
+	d.StartSweeper(time.Hour, 1)
+	if names := d.workerNames(); len(names) != 1 || names[0] != "ttl" {
+		t.Fatal("sweeper not registered", names)
+	}
+	d.StopSweeper()
+	if n, err := d.Sweep(1); err != nil || n != 2 {
+		t.Fatal("unexpected sweep", n, err)
+	}
+	d.View(func(tx *Tx) error {
+		raw := tx.tx.(keyTx).backendTx
+		if v := raw.Bucket(name).Get([]byte("s1")); v != nil {
+			t.Error("expired key not swept")
+		}
+		if next := tx.Next(name, nil, 0); len(next) != 4 || string(next[3]) != "new" {
+			t.Errorf("unexpected next after sweep %q", next)
+		}
+		n := 0
+		raw.Bucket(expiryBucketName(name)).ForEach(func(k, v []byte) error {
+			n++
+			return nil
+		})
+		if n != 2 {
+			t.Error("unexpected expiry entries", n)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,192 @@
+package zbolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TypeCodec encode values of type T to bytes and decode them back, key codecs must keep order
+type TypeCodec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(b []byte) (T, error)
+}
+
+// BytesCodec raw bytes
+type BytesCodec struct{}
+
+func (BytesCodec) Encode(v []byte) ([]byte, error) { return v, nil }
+func (BytesCodec) Decode(b []byte) ([]byte, error) { return b, nil }
+
+// StringCodec string as its bytes
+type StringCodec struct{}
+
+func (StringCodec) Encode(v string) ([]byte, error) { return []byte(v), nil }
+func (StringCodec) Decode(b []byte) (string, error) { return string(b), nil }
+
+// Uint64Codec uint64 as 8 bytes big endian, like Uint64ToBytes
+type Uint64Codec struct{}
+
+func (Uint64Codec) Encode(v uint64) ([]byte, error) { return Uint64ToBytes(v), nil }
+func (Uint64Codec) Decode(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("zbolt: uint64 of %d bytes", len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// Int64Codec int64 as 8 bytes big endian with sign bit flipped, so negative numbers sort first
+type Int64Codec struct{}
+
+func (Int64Codec) Encode(v int64) ([]byte, error) { return Uint64ToBytes(uint64(v) ^ 1<<63), nil }
+func (Int64Codec) Decode(b []byte) (int64, error) {
+	u, err := Uint64Codec{}.Decode(b)
+	return int64(u ^ 1<<63), err
+}
+
+// TimeCodec time as TimeToBytes
+type TimeCodec struct{}
+
+func (TimeCodec) Encode(v time.Time) ([]byte, error) { return TimeToBytes(v), nil }
+func (TimeCodec) Decode(b []byte) (time.Time, error) {
+	if len(b) != 8 {
+		return time.Time{}, fmt.Errorf("zbolt: time of %d bytes", len(b))
+	}
+	return BytesToTime(b), nil
+}
+
+// JSONCodec value as encoding/json, not suitable for keys
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(v T) ([]byte, error) { return json.Marshal(v) }
+func (JSONCodec[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// KV typed key value
+type KV[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// Bucket typed view of bucket name, keys and values are converted with Key and Value codecs
+type Bucket[K, V any] struct {
+	Name  []byte
+	Key   TypeCodec[K]
+	Value TypeCodec[V]
+}
+
+// NewBucket typed view of bucket name
+func NewBucket[K, V any](name []byte, key TypeCodec[K], value TypeCodec[V]) *Bucket[K, V] {
+	return &Bucket[K, V]{Name: name, Key: key, Value: value}
+}
+
+// Get get value of key, ok is false if key not exist
+func (b *Bucket[K, V]) Get(tx *Tx, key K) (value V, ok bool, err error) {
+	k, err := b.Key.Encode(key)
+	if err != nil {
+		return value, false, tx.Error(err)
+	}
+	gets := tx.Get(b.Name, k)
+	if len(gets) != 2 {
+		return value, false, tx.err
+	}
+	if value, err = b.Value.Decode(gets[1]); err != nil {
+		return value, false, tx.Error(err)
+	}
+	return value, true, nil
+}
+
+// Put put key values
+func (b *Bucket[K, V]) Put(tx *Tx, kvs ...KV[K, V]) error {
+	bs := make([][]byte, 0, 2*len(kvs))
+	for _, kv := range kvs {
+		k, err := b.Key.Encode(kv.Key)
+		if err != nil {
+			return tx.Error(err)
+		}
+		v, err := b.Value.Encode(kv.Value)
+		if err != nil {
+			return tx.Error(err)
+		}
+		bs = append(bs, k, v)
+	}
+	return tx.Put(b.Name, bs...)
+}
+
+// Delete delete keys
+func (b *Bucket[K, V]) Delete(tx *Tx, keys ...K) error {
+	bs := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		k, err := b.Key.Encode(key)
+		if err != nil {
+			return tx.Error(err)
+		}
+		bs = append(bs, k)
+	}
+	return tx.Delete(b.Name, bs...)
+}
+
+// Next get limit count key value after key, nil key start with the first one, limit = 0 representative of all
+func (b *Bucket[K, V]) Next(tx *Tx, after *K, limit int) ([]KV[K, V], error) {
+	k, err := b.encodeKey(after)
+	if err != nil {
+		return nil, tx.Error(err)
+	}
+	return b.decode(tx, tx.Next(b.Name, k, limit))
+}
+
+// Prev get limit count key value before key, nil key start with the last one, limit = 0 representative of all
+func (b *Bucket[K, V]) Prev(tx *Tx, before *K, limit int) ([]KV[K, V], error) {
+	k, err := b.encodeKey(before)
+	if err != nil {
+		return nil, tx.Error(err)
+	}
+	return b.decode(tx, tx.Prev(b.Name, k, limit))
+}
+
+// ForEach traveral all key value
+func (b *Bucket[K, V]) ForEach(tx *Tx, fn func(key K, value V) error) error {
+	return tx.ForEach(b.Name, func(k, v []byte) error {
+		key, err := b.Key.Decode(k)
+		if err != nil {
+			return err
+		}
+		value, err := b.Value.Decode(v)
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+// encodeKey encode key, nil if key is nil
+func (b *Bucket[K, V]) encodeKey(key *K) ([]byte, error) {
+	if key == nil {
+		return nil, nil
+	}
+	return b.Key.Encode(*key)
+}
+
+// decode decode [key1,value1,key2,value2, ...]
+func (b *Bucket[K, V]) decode(tx *Tx, kvs [][]byte) ([]KV[K, V], error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	res := make([]KV[K, V], 0, len(kvs)/2)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, err := b.Key.Decode(kvs[i])
+		if err != nil {
+			return nil, tx.Error(err)
+		}
+		value, err := b.Value.Decode(kvs[i+1])
+		if err != nil {
+			return nil, tx.Error(err)
+		}
+		res = append(res, KV[K, V]{Key: key, Value: value})
+	}
+	return res, nil
+}
@@ -0,0 +1,52 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestBucket_Typed(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	users := NewBucket[int64, user]([]byte("typed_users"), Int64Codec{}, JSONCodec[user]{})
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	if err := users.Put(tx, KV[int64, user]{-5, user{"alice", 30}}, KV[int64, user]{3, user{"bob", 40}}, KV[int64, user]{-20, user{"carol", 50}}); err != nil {
+		t.Fatal(err)
+	}
+	if u, ok, err := users.Get(tx, 3); err != nil || !ok || u.Name != "bob" {
+		t.Fatal("get", u, ok, err)
+	}
+	if _, ok, err := users.Get(tx, 4); err != nil || ok {
+		t.Fatal("get missing", ok, err)
+	}
+	next, err := users.Next(tx, nil, 0)
+	if err != nil || len(next) != 3 || next[0].Key != -20 || next[1].Key != -5 || next[2].Value.Name != "bob" {
+		t.Fatal("negative keys not sorted first", next, err)
+	}
+	after := int64(-5)
+	if next, _ := users.Next(tx, &after, 1); len(next) != 1 || next[0].Key != 3 {
+		t.Fatal("next", next)
+	}
+	if prev, _ := users.Prev(tx, &after, 0); len(prev) != 1 || prev[0].Key != -20 {
+		t.Fatal("prev", prev)
+	}
+	users.Delete(tx, -20)
+	var names []string
+	users.ForEach(tx, func(k int64, u user) error {
+		names = append(names, u.Name)
+		return nil
+	})
+	if len(names) != 2 || names[0] != "alice" {
+		t.Fatal("foreach", names)
+	}
+
+	// values not decodable by codec are reported
+	tx.Put([]byte("typed_raw"), []byte("k"), []byte("not json"))
+	raw := NewBucket[string, user]([]byte("typed_raw"), StringCodec{}, JSONCodec[user]{})
+	if _, _, err := raw.Get(tx, "k"); err == nil {
+		t.Fatal("expect decode error")
+	}
+}
@@ -0,0 +1,64 @@
+package zbolt
+
+import "math"
+
+// U64KV key value of bucket keyed by big endian uint64, like sequence keyed buckets.
+// U64 scans skip keys not 8 bytes long, they still count in limit
+type U64KV struct {
+	Key   uint64
+	Value []byte
+}
+
+// u64KVs decode [key1,value1,key2,value2, ...] skipping keys not 8 bytes long
+func u64KVs(kvs [][]byte) []U64KV {
+	res := make([]U64KV, 0, len(kvs)/2)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if len(kvs[i]) == 8 {
+			res = append(res, U64KV{Key: BytesToUint64(kvs[i]), Value: kvs[i+1]})
+		}
+	}
+	return res
+}
+
+// U64Next get limit count key value with key greater than after, limit = 0 representative of all
+func (tx *Tx) U64Next(name []byte, after uint64, limit int) []U64KV {
+	if after == math.MaxUint64 {
+		return nil
+	}
+	return u64KVs(tx.Range(name, Uint64ToBytes(after+1), nil, limit))
+}
+
+// U64Prev get limit count key value with key less than before, before = 0 start from the last key
+func (tx *Tx) U64Prev(name []byte, before uint64, limit int) []U64KV {
+	var end []byte
+	if before != 0 {
+		end = Uint64ToBytes(before)
+	}
+	return u64KVs(tx.RangeReverse(name, nil, end, limit))
+}
+
+// U64Get get value of uint64 key, nil if not exist
+func (tx *Tx) U64Get(name []byte, key uint64) []byte {
+	if gets := tx.Get(name, Uint64ToBytes(key)); len(gets) == 2 {
+		return gets[1]
+	}
+	return nil
+}
+
+// U64Put put values of uint64 keys
+func (tx *Tx) U64Put(name []byte, kvs ...U64KV) error {
+	bs := make([][]byte, 0, 2*len(kvs))
+	for _, kv := range kvs {
+		bs = append(bs, Uint64ToBytes(kv.Key), kv.Value)
+	}
+	return tx.Put(name, bs...)
+}
+
+// U64Append put value with key of next sequence of bucket, return the key
+func (tx *Tx) U64Append(name []byte, value []byte) (uint64, error) {
+	seq, err := tx.NextSequence(name)
+	if err != nil {
+		return 0, err
+	}
+	return seq, tx.Put(name, Uint64ToBytes(seq), value)
+}
@@ -0,0 +1,48 @@
+package zbolt
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTx_U64Next(t *testing.T) {
+	d, err := Open(filepath.Join(t.TempDir(), "u64.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("events")
+	d.Update(func(tx *Tx) error {
+		for _, v := range []string{"a", "b", "c", "d"} {
+			if _, err := tx.U64Append(name, []byte(v)); err != nil {
+				return err
+			}
+		}
+		tx.Put(name, []byte("odd"), []byte("x"))
+		return tx.U64Put(name, U64KV{Key: 10, Value: []byte("j")})
+	})
+	d.View(func(tx *Tx) error {
+		if kvs := tx.U64Next(name, 1, 2); len(kvs) != 2 || kvs[0].Key != 2 || string(kvs[1].Value) != "c" {
+			t.Fatal("unexpected next", kvs)
+		}
+		if kvs := tx.U64Next(name, 0, 0); len(kvs) != 5 || kvs[4].Key != 10 {
+			t.Fatal("unexpected next", kvs)
+		}
+		if kvs := tx.U64Next(name, 5, 0); len(kvs) != 1 || kvs[0].Key != 10 {
+			t.Fatal("expect next from absent key", kvs)
+		}
+		if kvs := tx.U64Prev(name, 11, 1); len(kvs) != 1 || kvs[0].Key != 10 {
+			t.Fatal("expect prev from key after the last one", kvs)
+		}
+		if kvs := tx.U64Prev(name, 0, 2); len(kvs) != 1 || kvs[0].Key != 10 {
+			t.Fatal("expect non uint64 key skipped", kvs)
+		}
+		if kvs := tx.U64Prev(name, 3, 0); len(kvs) != 2 || kvs[0].Key != 2 {
+			t.Fatal("unexpected prev", kvs)
+		}
+		if v := tx.U64Get(name, 4); string(v) != "d" {
+			t.Fatal("unexpected value", v)
+		}
+		return nil
+	})
+}
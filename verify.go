@@ -0,0 +1,292 @@
+package zbolt
+
+import (
+	"bytes"
+	"sort"
+	"sync/atomic"
+)
+
+// IndexIssue inconsistent entry of an index
+type IndexIssue struct {
+	Bucket []byte
+	Index  string // index name, "sort" for the SortPut index
+	Key    []byte // key of record
+	Entry  []byte // key of index entry
+	Extra  bool   // entry exist without record, otherwise record has no entry
+}
+
+// IndexReport result of VerifyIndexes
+type IndexReport struct {
+	Checked  int // checked records and entries
+	Missing  int
+	Extra    int
+	Repaired bool
+	Issues   []IndexIssue
+}
+
+// VerifyOptions options of VerifyIndexes
+type VerifyOptions struct {
+	Repair bool // add missing entries and delete extra ones
+	// Progress called every 1000 checked records or entries of an index and when the index is done
+	Progress func(bucket []byte, index string, checked int)
+}
+
+// verifyProgressEvery checked count between progress callbacks
+const verifyProgressEvery = 1000
+
+// VerifyIndexes cross-check ready secondary indexes and SortPut indexes against their records,
+// every index is checked, and repaired if asked, in its own transaction
+func (db *DB) VerifyIndexes(opts *VerifyOptions) (*IndexReport, error) {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+	report := &IndexReport{Repaired: opts.Repair}
+	run := db.View
+	if opts.Repair {
+		run = db.Update
+	}
+	for _, idx := range db.readyIndexes() {
+		if err := run(func(tx *Tx) error {
+			return tx.verifyIndex(idx, opts, report)
+		}); err != nil {
+			return report, err
+		}
+	}
+	var sorted [][]byte
+	seen := make(map[string]bool)
+	if err := db.View(func(tx *Tx) error {
+		return tx.tx.ForEach(func(name []byte, b backendBucket) error {
+			for _, prefix := range [][]byte{_keyPrefix, _valuePrefix} {
+				if bytes.HasPrefix(name, prefix) && !seen[string(name[len(prefix):])] {
+					seen[string(name[len(prefix):])] = true
+					sorted = append(sorted, append([]byte{}, name[len(prefix):]...))
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return report, err
+	}
+	for _, name := range sorted {
+		if err := run(func(tx *Tx) error {
+			return tx.verifySort(name, opts, report)
+		}); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// readyIndexes registered secondary indexes which are backfilled, ordered by bucket and name
+func (db *DB) readyIndexes() []*index {
+	db.mu.RLock()
+	var indexes []*index
+	for _, c := range db.buckets {
+		for _, idx := range c.indexes {
+			if atomic.LoadInt32(&idx.ready) == 1 {
+				indexes = append(indexes, idx)
+			}
+		}
+	}
+	db.mu.RUnlock()
+	sort.Slice(indexes, func(i, j int) bool {
+		if c := bytes.Compare(indexes[i].Bucket, indexes[j].Bucket); c != 0 {
+			return c < 0
+		}
+		return indexes[i].Name < indexes[j].Name
+	})
+	return indexes
+}
+
+// verifier count checked items of an index and collect its issues
+type verifier struct {
+	opts    *VerifyOptions
+	report  *IndexReport
+	bucket  []byte
+	index   string
+	checked int
+}
+
+// check count a checked item, call progress callback
+func (v *verifier) check() {
+	v.checked++
+	v.report.Checked++
+	if v.opts.Progress != nil && v.checked%verifyProgressEvery == 0 {
+		v.opts.Progress(v.bucket, v.index, v.checked)
+	}
+}
+
+// issue record an inconsistent entry
+func (v *verifier) issue(key, entry []byte, extra bool) {
+	if extra {
+		v.report.Extra++
+	} else {
+		v.report.Missing++
+	}
+	v.report.Issues = append(v.report.Issues, IndexIssue{
+		Bucket: v.bucket, Index: v.index, Key: append([]byte{}, key...), Entry: append([]byte{}, entry...), Extra: extra,
+	})
+}
+
+// done call progress callback of the finished index
+func (v *verifier) done() {
+	if v.opts.Progress != nil {
+		v.opts.Progress(v.bucket, v.index, v.checked)
+	}
+}
+
+// verifyIndex check entries of secondary index equal the ones extracted from records
+func (tx *Tx) verifyIndex(idx *index, opts *VerifyOptions, report *IndexReport) error {
+	v := &verifier{opts: opts, report: report, bucket: idx.Bucket, index: idx.Name}
+	defer v.done()
+	want := make(map[string][]byte)
+	c := tx.collation(idx.Bucket)
+	if err := tx.ForEach(idx.Bucket, func(k, value []byte) error {
+		v.check()
+		for _, iv := range idx.Extract(k, value) {
+			want[string(indexEntry(c, iv, k))] = append([]byte{}, k...)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var extra [][]byte
+	if b := tx.tx.Bucket(indexBucketName(idx.Bucket, idx.Name)); b != nil {
+		if err := b.ForEach(func(k, _ []byte) error {
+			v.check()
+			if _, ok := want[string(k)]; ok {
+				delete(want, string(k))
+				return nil
+			}
+			_, key, _ := splitIndexEntry(c, k)
+			v.issue(key, k, true)
+			extra = append(extra, append([]byte{}, k...))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	missing := make([]string, 0, len(want))
+	for entry := range want {
+		missing = append(missing, entry)
+	}
+	sort.Strings(missing)
+	for _, entry := range missing {
+		v.issue(want[entry], []byte(entry), false)
+	}
+	if !opts.Repair || len(extra)+len(missing) == 0 {
+		return nil
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(indexBucketName(idx.Bucket, idx.Name))
+	if err != nil {
+		return err
+	}
+	for _, k := range extra {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	for _, k := range missing {
+		if err := b.Put([]byte(k), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySort check the member index of SortPut bucket point to its sorted entries,
+// sorted entries are the records, a member pointing to no entry is extra
+func (tx *Tx) verifySort(name []byte, opts *VerifyOptions, report *IndexReport) error {
+	v := &verifier{opts: opts, report: report, bucket: name, index: "sort"}
+	defer v.done()
+	kb := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	vb := tx.tx.Bucket(shadowName(_valuePrefix, name))
+	o := tx.sortOrder(name)
+	if kb == nil && vb == nil {
+		return nil
+	}
+	if vb == nil {
+		if !opts.Repair {
+			vb = emptyBucket{}
+		} else if b, err := tx.tx.CreateBucketIfNotExists(shadowName(_valuePrefix, name)); err != nil {
+			return err
+		} else {
+			vb = b
+		}
+	}
+	var dangling [][]byte // members without entry
+	if err := vb.ForEach(func(member, k []byte) error {
+		v.check()
+		if kb == nil || kb.Get(k) == nil {
+			v.issue(member, k, true)
+			dangling = append(dangling, append([]byte{}, member...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var missing [][2][]byte // member and entry without member
+	var duplicates [][]byte // entries of member pointing to another entry, or not decodable
+	if kb != nil {
+		pointed := make(map[string]bool)
+		if err := kb.ForEach(func(k, _ []byte) error {
+			v.check()
+			_, member, ok := o.split(k)
+			if !ok {
+				v.issue(nil, k, true)
+				duplicates = append(duplicates, append([]byte{}, k...))
+				return nil
+			}
+			switch cur := vb.Get(member); {
+			case bytes.Equal(cur, k):
+			case cur != nil && kb.Get(cur) != nil || pointed[string(member)]:
+				v.issue(member, k, true)
+				duplicates = append(duplicates, append([]byte{}, k...))
+			default:
+				v.issue(member, k, false)
+				missing = append(missing, [2][]byte{append([]byte{}, member...), append([]byte{}, k...)})
+				pointed[string(member)] = true
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if !opts.Repair {
+		return nil
+	}
+	for _, member := range dangling {
+		if err := vb.Delete(member); err != nil {
+			return err
+		}
+	}
+	for _, k := range duplicates {
+		if err := kb.Delete(k); err != nil {
+			return err
+		}
+	}
+	for _, mk := range missing {
+		if err := vb.Put(mk[0], mk[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SortReindex rebuild the member index of bucket with sort from its sorted entries, deleting members without entry
+// and entries which are not decodable or shadowed by another entry of their member. Return the issues repaired
+func (tx *Tx) SortReindex(name []byte) (*IndexReport, error) {
+	report := &IndexReport{Repaired: true}
+	if tx.err != nil {
+		return report, tx.err
+	}
+	return report, tx.Error(tx.verifySort(name, &VerifyOptions{Repair: true}, report))
+}
+
+// emptyBucket read only bucket without keys
+type emptyBucket struct {
+	backendBucket
+}
+
+func (emptyBucket) Get(key []byte) []byte                    { return nil }
+func (emptyBucket) ForEach(fn func(k, v []byte) error) error { return nil }
@@ -0,0 +1,54 @@
+package zbolt
+
+import (
+	"testing"
+)
+
+func TestDB_VerifyIndexes(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	users, timeline := []byte("users"), []byte("timeline")
+	if err := d.BuildIndexOnline(IndexSpec{Bucket: users, Name: "email", Extract: emailIndex}); err != nil {
+		t.Fatal(err)
+	}
+	d.Update(func(tx *Tx) error {
+		tx.Put(users, []byte("u1"), []byte("alice:a@x"), []byte("u2"), []byte("bob:b@x"))
+		tx.SortPut(timeline, Uint64ToBytes(1), []byte("e1"), []byte("v1"))
+		tx.SortPut(timeline, Uint64ToBytes(2), []byte("e2"), []byte("v2"))
+		return nil
+	})
+	if report, err := d.VerifyIndexes(nil); err != nil || report.Missing+report.Extra != 0 || report.Checked != 8 {
+		t.Fatal("unexpected report of consistent indexes", report, err)
+	}
+	// break indexes behind their backs
+	d.Update(func(tx *Tx) error {
+		idx := tx.tx.Bucket(indexBucketName(users, "email"))
+		idx.Delete(indexEntry(0, []byte("a@x"), []byte("u1")))
+		idx.Put(indexEntry(0, []byte("c@x"), []byte("u3")), nil)
+		tx.tx.Bucket(BytesConcat(_valuePrefix, timeline)).Delete([]byte("e1"))
+		tx.tx.Bucket(BytesConcat(_keyPrefix, timeline)).Delete(sortAscending.entryKey(Uint64ToBytes(2), []byte("e2")))
+		return nil
+	})
+	var progress int
+	report, err := d.VerifyIndexes(&VerifyOptions{Repair: true, Progress: func(bucket []byte, index string, checked int) {
+		progress++
+	}})
+	if err != nil || report.Missing != 2 || report.Extra != 2 || len(report.Issues) != 4 || progress != 2 {
+		t.Fatal("unexpected report", report, err, progress)
+	}
+	if report, err := d.VerifyIndexes(nil); err != nil || report.Missing+report.Extra != 0 {
+		t.Fatal("not repaired", report.Issues, err)
+	}
+	d.View(func(tx *Tx) error {
+		if keys := tx.indexKeys(users, "email", []byte("a@x")); len(keys) != 1 {
+			t.Error("missing entry not added", keys)
+		}
+		if next := tx.SortNext(timeline, nil, 0); len(next) != 2 || string(next[0]) != "e1" {
+			t.Errorf("unexpected sort after repair %q", next)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+var _versionPrefix = []byte{23}
+
+const (
+	versionPut    byte = 0
+	versionDelete byte = 1
+)
+
+// EnableVersioning keep every value written to bucket in a history bucket, so it can be read with GetAsOf and ScanAsOf
+func (db *DB) EnableVersioning(name []byte, enabled bool) {
+	db.setConfig(name, func(c *bucketConfig) {
+		c.versioned = enabled
+	})
+}
+
+// escapeKey encode key keeping order and appending terminator, so it can be followed by other bytes
+func escapeKey(key []byte) []byte {
+	b := make([]byte, 0, len(key)+2)
+	for _, c := range key {
+		if c == 0 {
+			b = append(b, 0, 0xff)
+		} else {
+			b = append(b, c)
+		}
+	}
+	return append(b, 0, 1)
+}
+
+// unescapeKey decode escaped key, return key and the remaining bytes
+func unescapeKey(b []byte) (key []byte, rest []byte, ok bool) {
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0 {
+			key = append(key, b[i])
+			continue
+		}
+		if i+1 >= len(b) {
+			return nil, nil, false
+		}
+		switch b[i+1] {
+		case 0xff:
+			key = append(key, 0)
+			i++
+		case 1:
+			if key == nil {
+				key = []byte{}
+			}
+			return key, b[i+2:], true
+		default:
+			return nil, nil, false
+		}
+	}
+	return nil, nil, false
+}
+
+// versionKey key of version in history bucket, like [escaped key, unix nano]
+func versionKey(key []byte, t time.Time) []byte {
+	return BytesConcat(escapeKey(key), Uint64ToBytes(uint64(t.UnixNano())))
+}
+
+// putVersion record a version of key in history bucket
+func (tx *Tx) putVersion(name, key, value []byte, op byte) error {
+	h, err := tx.tx.CreateBucketIfNotExists(shadowName(_versionPrefix, name))
+	if err != nil {
+		return err
+	}
+	return h.Put(versionKey(key, time.Now()), BytesConcat([]byte{op}, value))
+}
+
+// GetAsOf get value of key in versioned bucket at time t, return nil if key not exist at that time
+func (tx *Tx) GetAsOf(name, key []byte, t time.Time) []byte {
+	if tx.err != nil {
+		return nil
+	}
+	h := tx.tx.Bucket(shadowName(_versionPrefix, name))
+	if h == nil {
+		return nil
+	}
+	prefix := escapeKey(key)
+	c := h.Cursor()
+	k, v := c.Seek(versionKey(key, t.Add(time.Nanosecond)))
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	if k == nil || len(k) != len(prefix)+8 || !bytes.HasPrefix(k, prefix) || len(v) == 0 || v[0] == versionDelete {
+		return nil
+	}
+	return v[1:]
+}
+
+// ScanAsOf get limit count key value in versioned bucket at time t, like [key1,value1,key2,value2, ...]
+func (tx *Tx) ScanAsOf(name []byte, t time.Time, limit int) [][]byte {
+	if tx.err != nil {
+		return [][]byte{}
+	}
+	h := tx.tx.Bucket(shadowName(_versionPrefix, name))
+	if h == nil {
+		return [][]byte{}
+	}
+	at := uint64(t.UnixNano())
+	var bs [][]byte
+	var key, value []byte
+	flush := func() bool {
+		if value != nil && value[0] == versionPut {
+			bs = append(bs, key, value[1:])
+		}
+		value = nil
+		return limit > 0 && len(bs)/2 >= limit //limit = 0 representative of all
+	}
+	c := h.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		kk, rest, ok := unescapeKey(k)
+		if !ok || len(rest) != 8 || len(v) == 0 {
+			continue
+		}
+		if !bytes.Equal(kk, key) {
+			if flush() {
+				return bs
+			}
+			key = kk
+		}
+		if binary.BigEndian.Uint64(rest) <= at {
+			value = v
+		}
+	}
+	flush()
+	return bs
+}
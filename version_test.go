@@ -0,0 +1,44 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTx_GetAsOf(t *testing.T) {
+	name := []byte("versioned")
+	db.EnableVersioning(name, true)
+	defer db.EnableVersioning(name, false)
+
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b\x00"), []byte("x"))
+	t1 := time.Now()
+	time.Sleep(time.Millisecond)
+	tx.Put(name, []byte("a"), []byte("2"))
+	t2 := time.Now()
+	time.Sleep(time.Millisecond)
+	tx.Delete(name, []byte("a"))
+	t3 := time.Now()
+
+	if v := tx.GetAsOf(name, []byte("a"), t1); string(v) != "1" {
+		t.Fatal("unexpected value at t1", string(v))
+	}
+	if v := tx.GetAsOf(name, []byte("a"), t2); string(v) != "2" {
+		t.Fatal("unexpected value at t2", string(v))
+	}
+	if v := tx.GetAsOf(name, []byte("a"), t3); v != nil {
+		t.Fatal("deleted key should be nil", string(v))
+	}
+	if v := tx.GetAsOf(name, []byte("a"), t1.Add(-time.Hour)); v != nil {
+		t.Fatal("key not exist before first put", string(v))
+	}
+
+	scan := tx.ScanAsOf(name, t2, 0)
+	if len(scan) != 4 || string(scan[1]) != "2" || string(scan[2]) != "b\x00" {
+		t.Fatal("unexpected scan at t2", scan)
+	}
+	if scan = tx.ScanAsOf(name, t3, 0); len(scan) != 2 || string(scan[0]) != "b\x00" {
+		t.Fatal("unexpected scan at t3", scan)
+	}
+}
@@ -0,0 +1,414 @@
+package zbolt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"hash/fnv"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+var _warmMetaKey = []byte("warm")
+
+// BucketCount fast count of keys and bytes of keys and values in a bucket
+type BucketCount struct {
+	Keys  int64
+	Bytes int64
+}
+
+// fastCount count of bucket maintained on commit
+type fastCount struct {
+	mu sync.Mutex
+	BucketCount
+}
+
+// bloom filter of keys put in bucket, never has false negatives
+type bloom struct {
+	mu   sync.RWMutex
+	Bits []uint64
+	K    uint32
+}
+
+// sketch count-min sketch of key reads of bucket
+type sketch struct {
+	Rows [sketchDepth][sketchWidth]uint32
+}
+
+const (
+	sketchDepth = 4
+	sketchWidth = 1024
+)
+
+// warmState auxiliary structures saved to meta bucket on Close and loaded on open, so they are not rebuilt by scans
+type warmState struct {
+	Counts  map[string]BucketCount
+	Blooms  map[string]*bloom
+	HotKeys map[string]*sketch
+}
+
+// openWarm take saved warm state from file, it is removed so a crash before the next Close forces rebuilds
+func (db *DB) openWarm() error {
+	var raw []byte
+	if err := db.View(func(tx *Tx) error {
+		if b := tx.tx.Bucket(_metaBucket); b != nil {
+			raw = append([]byte{}, b.Get(_warmMetaKey)...)
+		}
+		return nil
+	}); err != nil || len(raw) == 0 {
+		return err
+	}
+	var w warmState
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&w); err != nil {
+		return nil // rebuild
+	}
+	db.warm = &w
+	if db.engine().IsReadOnly() {
+		return nil
+	}
+	return db.Update(func(tx *Tx) error {
+		return tx.tx.Bucket(_metaBucket).Delete(_warmMetaKey)
+	})
+}
+
+// saveWarm save auxiliary structures of buckets to meta bucket
+func (db *DB) saveWarm() error {
+	if db.engine().IsReadOnly() {
+		return nil
+	}
+	w := warmState{Counts: map[string]BucketCount{}, Blooms: map[string]*bloom{}, HotKeys: map[string]*sketch{}}
+	db.mu.RLock()
+	for name, c := range db.buckets {
+		if c.count != nil {
+			w.Counts[name] = c.count.get()
+		}
+		if c.bloom != nil {
+			w.Blooms[name] = c.bloom.copy()
+		}
+		if c.hot != nil {
+			w.HotKeys[name] = c.hot.copy()
+		}
+	}
+	db.mu.RUnlock()
+	if len(w.Counts)+len(w.Blooms)+len(w.HotKeys) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&w); err != nil {
+		return err
+	}
+	// not through NewTx, which is refused once Shutdown started
+	tx, err := db.engine().Begin(true)
+	if err != nil {
+		return err
+	}
+	b, err := tx.CreateBucketIfNotExists(_metaBucket)
+	if err == nil {
+		err = b.Put(_warmMetaKey, buf.Bytes())
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnableFastCount maintain count of keys and bytes of bucket, including members put by SortPut, loaded from the state saved on Close or counted by a scan
+func (db *DB) EnableFastCount(name []byte) error {
+	fc := &fastCount{}
+	if c, ok := db.takeWarm(func(w *warmState) (interface{}, bool) {
+		c, ok := w.Counts[string(name)]
+		delete(w.Counts, string(name))
+		return c, ok
+	}); ok {
+		fc.BucketCount = c.(BucketCount)
+		db.setConfig(name, func(c *bucketConfig) { c.count = fc })
+		return nil
+	}
+	// hold writes while counting so none is missed
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	var err error
+	if fc.BucketCount, err = tx.countBucket(name); err != nil {
+		return err
+	}
+	db.setConfig(name, func(c *bucketConfig) { c.count = fc })
+	return nil
+}
+
+// countBucket count keys and bytes of bucket by a scan, including members put by SortPut
+func (tx *Tx) countBucket(name []byte) (BucketCount, error) {
+	var bc BucketCount
+	if err := tx.ForEach(name, func(k, v []byte) error {
+		bc.Keys++
+		bc.Bytes += int64(len(k) + len(v))
+		return nil
+	}); err != nil {
+		return bc, err
+	}
+	if b := tx.tx.Bucket(shadowName(_keyPrefix, name)); b != nil {
+		o := tx.sortOrder(name)
+		b.ForEach(func(k, v []byte) error {
+			if _, member, ok := o.split(k); ok {
+				bc.Keys++
+				bc.Bytes += int64(len(member) + len(v))
+			}
+			return nil
+		})
+	}
+	return bc, nil
+}
+
+// FastCount get count of bucket enabled by EnableFastCount, ok is false if not enabled
+func (db *DB) FastCount(name []byte) (c BucketCount, ok bool) {
+	fc := db.config(name).count
+	if fc == nil {
+		return c, false
+	}
+	return fc.get(), true
+}
+
+// EnableBloom keep a bloom filter of keys of bucket sized for expected keys at false positive rate fp,
+// Get skip lookups of keys the filter rules out. Loaded from the state saved on Close or built by a scan
+func (db *DB) EnableBloom(name []byte, expected int, fp float64) error {
+	if f, ok := db.takeWarm(func(w *warmState) (interface{}, bool) {
+		f, ok := w.Blooms[string(name)]
+		delete(w.Blooms, string(name))
+		return f, ok
+	}); ok {
+		db.setConfig(name, func(c *bucketConfig) { c.bloom = f.(*bloom) })
+		return nil
+	}
+	f := newBloom(expected, fp)
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	if err := tx.ForEach(name, func(k, v []byte) error {
+		f.add(k)
+		return nil
+	}); err != nil {
+		return err
+	}
+	db.setConfig(name, func(c *bucketConfig) { c.bloom = f })
+	return nil
+}
+
+// rebuildWarm recount fast counts and rebuild bloom filters of buckets by scans of tx, once the file was replaced
+func (db *DB) rebuildWarm(tx *Tx) error {
+	db.mu.Lock()
+	db.warm = nil
+	counts, blooms := map[string]bool{}, map[string]*bloom{}
+	for name, c := range db.buckets {
+		if c.count != nil {
+			counts[name] = true
+		}
+		if c.bloom != nil {
+			blooms[name] = c.bloom
+		}
+	}
+	db.mu.Unlock()
+	for name := range counts {
+		bc, err := tx.countBucket([]byte(name))
+		if err != nil {
+			return err
+		}
+		db.setConfig([]byte(name), func(c *bucketConfig) { c.count = &fastCount{BucketCount: bc} })
+	}
+	for name, old := range blooms {
+		f := &bloom{Bits: make([]uint64, len(old.Bits)), K: old.K}
+		if err := tx.ForEach([]byte(name), func(k, v []byte) error {
+			f.add(k)
+			return nil
+		}); err != nil {
+			return err
+		}
+		db.setConfig([]byte(name), func(c *bucketConfig) { c.bloom = f })
+	}
+	return nil
+}
+
+// MayContain report whether key may exist in bucket with bloom filter, true if bucket has no filter
+func (db *DB) MayContain(name, key []byte) bool {
+	f := db.config(name).bloom
+	return f == nil || f.has(key)
+}
+
+// EnableHotKeys estimate read count of keys of bucket with a count-min sketch, loaded from the state saved on Close
+func (db *DB) EnableHotKeys(name []byte) {
+	s := &sketch{}
+	if v, ok := db.takeWarm(func(w *warmState) (interface{}, bool) {
+		s, ok := w.HotKeys[string(name)]
+		delete(w.HotKeys, string(name))
+		return s, ok
+	}); ok {
+		s = v.(*sketch)
+	}
+	db.setConfig(name, func(c *bucketConfig) { c.hot = s })
+}
+
+// HotKeyReads estimated count of Get of key in bucket with EnableHotKeys, never less than the real count
+func (db *DB) HotKeyReads(name, key []byte) uint64 {
+	s := db.config(name).hot
+	if s == nil {
+		return 0
+	}
+	return s.estimate(key)
+}
+
+// takeWarm take an entry of warm state loaded on open
+func (db *DB) takeWarm(fn func(w *warmState) (interface{}, bool)) (interface{}, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.warm == nil {
+		return nil, false
+	}
+	return fn(db.warm)
+}
+
+// countWrite change fast count of bucket by a write of key from old to new value, nil value means not exist,
+// applied when the transaction commits. Return ErrBucketFull if the write grows bucket over its quota
+func (tx *Tx) countWrite(c bucketConfig, key, old, new []byte) error {
+	var d BucketCount
+	if old != nil {
+		d.Keys--
+		d.Bytes -= int64(len(key) + len(old))
+	}
+	if new != nil {
+		d.Keys++
+		d.Bytes += int64(len(key) + len(new))
+	}
+	if c.quota != nil && c.quota.exceeded(c.count.get(), tx.pending[c.count], d) {
+		return ErrBucketFull
+	}
+	tx.countAdd(c.count, d)
+	return nil
+}
+
+// countReset reset fast count of bucket to zero when the transaction commits
+func (tx *Tx) countReset(c bucketConfig) {
+	if c.count == nil {
+		return
+	}
+	cur, p := c.count.get(), tx.pending[c.count]
+	tx.countAdd(c.count, BucketCount{-cur.Keys - p.Keys, -cur.Bytes - p.Bytes})
+}
+
+// countAdd add d to fast count when the transaction commits
+func (tx *Tx) countAdd(fc *fastCount, d BucketCount) {
+	if tx.pending == nil {
+		tx.pending = make(map[*fastCount]BucketCount)
+	}
+	p, counted := tx.pending[fc]
+	tx.pending[fc] = BucketCount{p.Keys + d.Keys, p.Bytes + d.Bytes}
+	if !counted {
+		tx.afterCommit(func() {
+			p := tx.pending[fc]
+			fc.mu.Lock()
+			fc.Keys += p.Keys
+			fc.Bytes += p.Bytes
+			fc.mu.Unlock()
+		})
+	}
+}
+
+// get current count
+func (fc *fastCount) get() BucketCount {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.BucketCount
+}
+
+// afterCommit run fn once tx is committed
+func (tx *Tx) afterCommit(fn func()) {
+	tx.commits = append(tx.commits, fn)
+}
+
+// committed run functions registered by afterCommit
+func (tx *Tx) committed() {
+	for _, fn := range tx.commits {
+		fn()
+	}
+	tx.commits = nil
+}
+
+// newBloom bloom filter for n keys at false positive rate fp
+func newBloom(n int, fp float64) *bloom {
+	if n <= 0 {
+		n = 1
+	}
+	if fp <= 0 || fp >= 1 {
+		fp = 0.01
+	}
+	m := math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2))
+	k := uint32(math.Max(1, math.Round(m/float64(n)*math.Ln2)))
+	return &bloom{Bits: make([]uint64, (int(m)+63)/64), K: k}
+}
+
+// hash2 two independent hashes of key for double hashing
+func hash2(key []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write(key)
+	a := h.Sum64()
+	h.Write([]byte{0})
+	return a, h.Sum64() | 1
+}
+
+func (f *bloom) add(key []byte) {
+	a, b := hash2(key)
+	m := uint64(len(f.Bits) * 64)
+	f.mu.Lock()
+	for i := uint64(0); i < uint64(f.K); i++ {
+		bit := (a + i*b) % m
+		f.Bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.mu.Unlock()
+}
+
+func (f *bloom) has(key []byte) bool {
+	a, b := hash2(key)
+	m := uint64(len(f.Bits) * 64)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < uint64(f.K); i++ {
+		bit := (a + i*b) % m
+		if f.Bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// copy of filter safe to encode while keys are added
+func (f *bloom) copy() *bloom {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return &bloom{Bits: append([]uint64{}, f.Bits...), K: f.K}
+}
+
+// copy of sketch safe to encode while reads are counted
+func (s *sketch) copy() *sketch {
+	c := &sketch{}
+	for i := range s.Rows {
+		for j := range s.Rows[i] {
+			c.Rows[i][j] = atomic.LoadUint32(&s.Rows[i][j])
+		}
+	}
+	return c
+}
+
+func (s *sketch) add(key []byte) {
+	a, b := hash2(key)
+	for i := uint64(0); i < sketchDepth; i++ {
+		atomic.AddUint32(&s.Rows[i][(a+i*b)%sketchWidth], 1)
+	}
+}
+
+func (s *sketch) estimate(key []byte) uint64 {
+	a, b := hash2(key)
+	min := uint32(math.MaxUint32)
+	for i := uint64(0); i < sketchDepth; i++ {
+		if n := atomic.LoadUint32(&s.Rows[i][(a+i*b)%sketchWidth]); n < min {
+			min = n
+		}
+	}
+	return uint64(min)
+}
@@ -0,0 +1,94 @@
+package zbolt
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestDB_WarmCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "warm.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := []byte("items")
+	d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k1"), []byte("v1"), []byte("k2"), []byte("v2"))
+	})
+	if err := d.EnableFastCount(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.EnableBloom(name, 100, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	d.EnableHotKeys(name)
+	d.Update(func(tx *Tx) error {
+		tx.Put(name, []byte("k3"), []byte("v3"), []byte("k1"), []byte("value1"))
+		tx.Delete(name, []byte("k2"), []byte("k9"))
+		for i := 0; i < 5; i++ {
+			tx.Get(name, []byte("k1"))
+		}
+		return nil
+	})
+	// rolled back writes are not counted
+	tx := d.NewTx(true)
+	tx.Put(name, []byte("k4"), []byte("v4"))
+	tx.Rollback()
+	if c, ok := d.FastCount(name); !ok || c.Keys != 2 || c.Bytes != int64(len("k1value1k3v3")) {
+		t.Fatal("unexpected count", c, ok)
+	}
+	if !d.MayContain(name, []byte("k3")) || d.MayContain(name, []byte("absent")) {
+		t.Fatal("unexpected bloom filter")
+	}
+	if n := d.HotKeyReads(name, []byte("k1")); n < 5 {
+		t.Fatal("unexpected reads", n)
+	}
+	d.Close()
+
+	// change the file behind zbolt, loaded state is not rebuilt from it
+	bdb, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(name).Put([]byte("k5"), []byte("v5"))
+	})
+	bdb.Close()
+
+	if d, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	d.View(func(tx *Tx) error {
+		if v := tx.tx.Bucket(_metaBucket).Get(_warmMetaKey); v != nil {
+			t.Error("warm state kept in file after open")
+		}
+		return nil
+	})
+	d.EnableFastCount(name)
+	d.EnableBloom(name, 100, 0.01)
+	d.EnableHotKeys(name)
+	if c, _ := d.FastCount(name); c.Keys != 2 {
+		t.Fatal("count rebuilt instead of loaded", c)
+	}
+	if n := d.HotKeyReads(name, []byte("k1")); n < 5 {
+		t.Fatal("reads not loaded", n)
+	}
+	d.Close()
+
+	// without saved state, structures are rebuilt by scans
+	if d, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.Update(func(tx *Tx) error {
+		return tx.tx.Bucket(_metaBucket).Delete(_warmMetaKey)
+	})
+	d.warm = nil
+	d.EnableFastCount(name)
+	if c, _ := d.FastCount(name); fmt.Sprint(c) != fmt.Sprint(BucketCount{3, int64(len("k1value1k3v3k5v5"))}) {
+		t.Fatal("unexpected rebuilt count", c)
+	}
+}
@@ -0,0 +1,248 @@
+package zbolt
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// watchBuffer count of events buffered for a watcher before it is closed with ErrWatchOverflow
+const watchBuffer = 1024
+
+// Event change of key in bucket, delivered to watchers after the transaction commits
+type Event struct {
+	Bucket []byte
+	Key    []byte
+	Value  []byte // nil when key is deleted
+}
+
+// Watcher subscription to changes of keys with prefix in bucket
+type Watcher struct {
+	C <-chan Event
+
+	c      chan Event
+	db     *DB
+	bucket []byte
+	prefix []byte
+	err    error
+	stop   chan struct{} // closed with c to stop replay of WatchFrom
+	once   sync.Once
+}
+
+// watchState watchers registered on DB
+type watchState struct {
+	mu       sync.Mutex
+	n        int32 // count of watchers, checked without lock on write
+	watchers map[*Watcher]struct{}
+	commit   sync.RWMutex // read locked from commit until published, locked by WatchFrom to take snapshot between commits
+}
+
+// Watch subscribe to changes of keys with prefix in bucket put or deleted by committed transactions, nil prefix watch all keys.
+// C is closed by Close, when db is closed or when the watcher fall behind, then Err return ErrWatchOverflow
+func (db *DB) Watch(bucket, prefix []byte) *Watcher {
+	c := make(chan Event, watchBuffer)
+	w := &Watcher{C: c, c: c, db: db, bucket: append([]byte{}, bucket...), prefix: append([]byte{}, prefix...), stop: make(chan struct{})}
+	db.watch.mu.Lock()
+	defer db.watch.mu.Unlock()
+	if db.watch.watchers == nil {
+		db.watch.watchers = make(map[*Watcher]struct{})
+	}
+	db.watch.watchers[w] = struct{}{}
+	atomic.StoreInt32(&db.watch.n, int32(len(db.watch.watchers)))
+	return w
+}
+
+// Close stop watcher and close C
+func (w *Watcher) Close() {
+	w.db.watch.mu.Lock()
+	defer w.db.watch.mu.Unlock()
+	w.db.unwatch(w, nil)
+}
+
+// Err get why watcher was closed, ErrWatchOverflow if it fell behind
+func (w *Watcher) Err() error {
+	w.db.watch.mu.Lock()
+	defer w.db.watch.mu.Unlock()
+	return w.err
+}
+
+// unwatch remove watcher and close its channel, must hold watch lock
+func (db *DB) unwatch(w *Watcher, err error) {
+	if _, ok := db.watch.watchers[w]; !ok {
+		return
+	}
+	delete(db.watch.watchers, w)
+	atomic.StoreInt32(&db.watch.n, int32(len(db.watch.watchers)))
+	w.err = err
+	close(w.c)
+	w.once.Do(func() { close(w.stop) })
+}
+
+// closeWatchers close all watchers when db is closed
+func (db *DB) closeWatchers() {
+	db.watch.mu.Lock()
+	defer db.watch.mu.Unlock()
+	for w := range db.watch.watchers {
+		db.unwatch(w, nil)
+	}
+}
+
+// publish deliver events to matching watchers without blocking
+func (db *DB) publish(events []Event) {
+	db.watch.mu.Lock()
+	defer db.watch.mu.Unlock()
+	for _, e := range events {
+		for w := range db.watch.watchers {
+			if !bytes.Equal(w.bucket, e.Bucket) || !bytes.HasPrefix(e.Key, w.prefix) {
+				continue
+			}
+			select {
+			case w.c <- e:
+			default:
+				db.unwatch(w, ErrWatchOverflow)
+			}
+		}
+	}
+}
+
+// WatchFrom subscribe to all changes of bucket like Watch, first deliver entries of a consistent snapshot with key >= startKey,
+// then changes committed after the snapshot, none is missed or repeated. Changes are buffered while the snapshot is delivered.
+// Must not be called while holding a writable transaction
+func (db *DB) WatchFrom(bucket, startKey []byte) (*Watcher, error) {
+	// no writer is in flight and every commit is published while both locks are held
+	wtx := db.NewTx(true)
+	if wtx.err != nil {
+		return nil, wtx.err
+	}
+	defer wtx.Rollback()
+	db.watch.commit.Lock()
+	w := db.Watch(bucket, nil)
+	tx := db.NewTx(false)
+	db.watch.commit.Unlock()
+	if tx.err != nil {
+		w.Close()
+		return nil, tx.err
+	}
+	out := make(chan Event)
+	w.C = out
+	go func() {
+		defer close(out)
+		send := func(e Event) bool {
+			select {
+			case out <- e:
+				return true
+			case <-w.stop:
+				return false
+			}
+		}
+		b := tx.tx.Bucket(bucket)
+		if b != nil {
+			r := tx.reader(bucket)
+			c := b.Cursor()
+			k, v := c.First()
+			if len(startKey) > 0 {
+				k, v = c.Seek(startKey)
+			}
+			for ; k != nil; k, v = c.Next() {
+				if !send(Event{Bucket: w.bucket, Key: append([]byte{}, k...), Value: append([]byte{}, r.value(k, v)...)}) {
+					break
+				}
+			}
+		}
+		tx.Rollback()
+		for e := range w.c {
+			if !send(e) {
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// commit commit backend transaction and run functions registered by afterCommit,
+// a writable one hold off WatchFrom until its changes are published
+func (tx *Tx) commit() error {
+	if tx.db != nil && tx.tx.Writable() {
+		tx.db.watch.commit.RLock()
+		defer tx.db.watch.commit.RUnlock()
+	}
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	tx.committed()
+	return nil
+}
+
+// watching check any watcher is registered
+func (tx *Tx) watching() bool {
+	return tx.db != nil && atomic.LoadInt32(&tx.db.watch.n) > 0
+}
+
+// notify record change of key to publish once tx is committed
+func (tx *Tx) notify(name, key, value []byte) {
+	if !tx.watching() {
+		return
+	}
+	if tx.events == nil {
+		tx.afterCommit(func() {
+			tx.db.publish(tx.events)
+			tx.events = nil
+		})
+	}
+	e := Event{Bucket: append([]byte{}, name...), Key: append([]byte{}, key...)}
+	if value != nil {
+		e.Value = append([]byte{}, value...)
+	}
+	tx.events = append(tx.events, e)
+}
+
+// TypedEvent event with value decoded by codec of bucket, Err is the decoding error
+type TypedEvent[T any] struct {
+	Bucket  []byte
+	Key     []byte
+	Value   T
+	Deleted bool
+	Err     error
+}
+
+// TypedWatcher subscription delivering events decoded by codec of bucket
+type TypedWatcher[T any] struct {
+	C <-chan TypedEvent[T]
+
+	w    *Watcher
+	done chan struct{}
+	once sync.Once
+}
+
+// WatchT subscribe like Watch, values are decoded by codec of bucket before delivery
+func WatchT[T any](db *DB, bucket, prefix []byte) *TypedWatcher[T] {
+	c := make(chan TypedEvent[T])
+	tw := &TypedWatcher[T]{C: c, w: db.Watch(bucket, prefix), done: make(chan struct{})}
+	codec := db.Codec(bucket)
+	go func() {
+		defer close(c)
+		for e := range tw.w.C {
+			te := TypedEvent[T]{Bucket: e.Bucket, Key: e.Key, Deleted: e.Value == nil}
+			if !te.Deleted {
+				te.Err = codec.Unmarshal(e.Value, &te.Value)
+			}
+			select {
+			case c <- te:
+			case <-tw.done:
+				return
+			}
+		}
+	}()
+	return tw
+}
+
+// Close stop watcher and close C
+func (tw *TypedWatcher[T]) Close() {
+	tw.once.Do(func() { close(tw.done) })
+	tw.w.Close()
+}
+
+// Err get why watcher was closed, ErrWatchOverflow if it fell behind
+func (tw *TypedWatcher[T]) Err() error {
+	return tw.w.Err()
+}
@@ -0,0 +1,143 @@
+package zbolt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDB_Watch(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	w := d.Watch([]byte("users"), []byte("u"))
+	defer w.Close()
+
+	tx := d.NewTx(true)
+	tx.Put([]byte("users"), []byte("x1"), []byte("skipped"))
+	tx.Put([]byte("users"), []byte("u1"), []byte("rolled back"))
+	tx.Rollback()
+	tx = d.NewTx(true)
+	tx.Put([]byte("users"), []byte("u1"), []byte("alice"), []byte("x1"), []byte("skipped"))
+	tx.Delete([]byte("users"), []byte("u1"), []byte("u9"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"alice", ""} {
+		select {
+		case e := <-w.C:
+			if string(e.Key) != "u1" || string(e.Value) != want || (want == "") != (e.Value == nil) {
+				t.Fatalf("unexpected event %q %q", e.Key, e.Value)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+	select {
+	case e := <-w.C:
+		t.Fatalf("unexpected event %q", e.Key)
+	default:
+	}
+	w.Close()
+	if _, ok := <-w.C; ok || w.Err() != nil {
+		t.Fatal("watcher not closed", w.Err())
+	}
+}
+
+func TestWatchT(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	type user struct{ Name string }
+	w := WatchT[user](d, []byte("users"), nil)
+	defer w.Close()
+
+	tx := d.NewTx(true)
+	tx.PutObject([]byte("users"), []byte("u1"), user{Name: "alice"})
+	tx.Put([]byte("users"), []byte("u2"), []byte("not json"))
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-w.C; e.Err != nil || e.Value.Name != "alice" {
+		t.Fatal("unexpected event", e)
+	}
+	if e := <-w.C; e.Err == nil || string(e.Key) != "u2" {
+		t.Fatal("expect decoding error", e)
+	}
+}
+
+func TestDB_WatchFrom(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	logs := []byte("logs")
+	tx := d.NewTx(true)
+	tx.Put(logs, []byte("a"), []byte("1"), []byte("b"), []byte("2"), []byte("c"), []byte("3"))
+	tx.Commit()
+
+	w, err := d.WatchFrom(logs, []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	tx = d.NewTx(true)
+	tx.Put(logs, []byte("d"), []byte("4"))
+	tx.Commit()
+	var got string
+	for len(got) < 3 {
+		select {
+		case e := <-w.C:
+			got += string(e.Key)
+		case <-time.After(time.Second):
+			t.Fatal("events not delivered", got)
+		}
+	}
+	if got != "bcd" {
+		t.Fatal("unexpected events", got)
+	}
+}
+
+func TestDB_WatchFromConcurrent(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	logs := []byte("logs")
+	const n = 200
+	started := make(chan struct{})
+	go func() {
+		for i := uint64(0); i < n; i++ {
+			if i == n/2 {
+				close(started)
+			}
+			tx := d.NewTx(true)
+			tx.Put(logs, Uint64ToBytes(i), []byte("v"))
+			tx.Commit()
+		}
+	}()
+	<-started
+	w, err := d.WatchFrom(logs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	seen := make(map[uint64]bool)
+	for len(seen) < n {
+		select {
+		case e := <-w.C:
+			k := BytesToUint64(e.Key)
+			if seen[k] {
+				t.Fatal("key delivered twice", k)
+			}
+			seen[k] = true
+		case <-time.After(time.Second):
+			t.Fatal("keys missed", len(seen))
+		}
+	}
+}
@@ -2,10 +2,17 @@ package zbolt
 
 import (
 	"bytes"
+	"context"
+	"crypto/cipher"
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -14,69 +21,207 @@ import (
 
 // DB database struct, contain boltdb DB struct
 type DB struct {
-	db *bolt.DB
+	db       backend // read by engine, swapped by ReplaceFrom
+	engineMu sync.RWMutex
+	opts     *Options // options of OpenWithOptions, used to reopen file by ReplaceFrom
+
+	mu        sync.RWMutex
+	relations []*Relation
+	dedup     bool
+	buckets   map[string]*bucketConfig
+	gc        gcState
+	sweeper   periodic // background sweeper of expired keys
+	watch     watchState
+	lease     *lease
+	workers   workerSet
+	openTxs   int32
+	closing   int32
+	wrapped   int32
+	warm      *warmState
+	codec     Codec
+
+	lastBackup time.Time
+	repanic    bool
+	preCommits []func(tx *Tx) error
+	templates  map[string]Template
+	execHooks  []ExecHook
+	features   Features
+	tokenKey   cipher.AEAD // seal page tokens, see SetPageTokenKey
 }
 
 // Tx transaction struct, contain boltdb Tx and error
 type Tx struct {
-	tx  *bolt.Tx
-	err error
+	tx    backendTx
+	db    *DB
+	err   error
+	done  bool
+	start time.Time
+	ctx   context.Context
+	seq   uint64 // commit sequence, see Seq
+	max   int    // max bytes of keys and values returned by a call, see SetMaxResultBytes
+	codec byte   // id of codec of value put by PutObject, recorded in envelope
+
+	commits []func()                   // run after commit
+	pending map[*fastCount]BucketCount // count changes applied after commit
+	tags    map[string]string
+	events  []Event         // changes published to watchers after commit
+	built   map[*index]bool // model indexes backfilled by tx, ready after commit
+	cascade map[string]bool // keys deleted by the cascade in progress, see deleteChildren
 }
 
 var (
 	_keyPrefix   = []byte{20}
 	_valuePrefix = []byte{21}
 
-	_keyMax = Uint64ToBytes(math.MaxUint64)
-	_keyMin = Uint64ToBytes(0)
+	_timeMin = time.Unix(0, math.MinInt64)
+	_timeMax = time.Unix(0, math.MaxInt64)
 )
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrNil            = errors.New("nil")
+	ErrHasChildren    = errors.New("record has children")
+	ErrForeignKey     = errors.New("referenced parent record not found")
+	ErrSchemaMismatch = errors.New("schema mismatch")
+	ErrSchemaVersion  = errors.New("schema version is newer than registered")
+	ErrChecksum       = errors.New("checksum mismatch")
+	ErrInvalidFile    = errors.New("invalid bolt file")
+	ErrFormatNewer    = errors.New("file format is newer than supported")
+	ErrFormatOlder    = errors.New("file format is older than supported")
+	ErrLeaseHeld      = errors.New("database is held by another process")
+	ErrShuttingDown   = errors.New("database is shutting down")
+	ErrTimeout        = bolt.ErrTimeout // timeout waiting for file lock on open
+	ErrTxDeadline     = errors.New("transaction exceeded commit deadline")
+	ErrIndexNotReady  = errors.New("index is not registered or still building")
+	ErrDuplicate      = errors.New("index value already used by another key")
+	ErrKeyDecode      = errors.New("composite key does not match the parts read")
+	ErrBucketFull     = errors.New("bucket quota exceeded")
+	ErrNoTemplate     = errors.New("template is not registered")
+	ErrTemplateParam  = errors.New("template param is missing")
+	ErrWatchOverflow  = errors.New("watcher fell behind and was closed")
+	ErrSortField      = errors.New("value does not match sort key field")
+	ErrStaleEpoch     = errors.New("write epoch was fenced")
+	ErrBucketExists   = errors.New("bucket already exists")
+	ErrCASMismatch    = errors.New("current value does not match")
+	ErrReservedName   = errors.New("bucket name starts with byte reserved for internal buckets")
+	ErrNotCounter     = errors.New("value is not an 8 bytes counter")
+	ErrNoMerge        = errors.New("no merge function set for bucket")
+	ErrResultTooLarge = errors.New("result exceeds max result bytes, page with limit or stream with ForEach or ScanRange")
+	ErrInvalidToken   = errors.New("invalid page token")
+	ErrNoFile         = errors.New("database is not backed by a file")
+	ErrInvalidOp      = errors.New("invalid operation")
 )
 
-// Open create DB struct, open file to save db
+// Open create DB struct, open file to save db.
+// Return ErrFormatNewer or ErrFormatOlder if zbolt format stamped in file is not supported
 func Open(path string) (*DB, error) {
-	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
-	if err != nil {
-		return nil, err
-	}
-	return &DB{db: db}, nil
+	return OpenWithOptions(path, nil)
 }
 
 // NewDB assemble DB struct, input boltdb DB struct
 func NewDB(db *bolt.DB) *DB {
-	return &DB{db: db}
+	return &DB{db: boltDB{db}}
 }
 
 // NewTx create transaction struct
 func (db *DB) NewTx(writable bool) *Tx {
-	tx := &Tx{}
-	tx.tx, tx.err = db.db.Begin(writable)
+	tx := &Tx{db: db, start: time.Now()}
+	// counted before closing is checked, so Shutdown never closes DB under a beginning tx
+	atomic.AddInt32(&db.openTxs, 1)
+	if atomic.LoadInt32(&db.closing) != 0 {
+		atomic.AddInt32(&db.openTxs, -1)
+		tx.err = ErrShuttingDown
+		return tx
+	}
+	tx.tx, tx.err = db.engine().Begin(writable)
+	if tx.err != nil {
+		atomic.AddInt32(&db.openTxs, -1)
+		return tx
+	}
+	tx.seq = uint64(tx.tx.ID())
+	tx.tx = db.wrapTx(tx.tx)
 	return tx
 }
 
+// engine get storage engine of DB
+func (db *DB) engine() backend {
+	db.engineMu.RLock()
+	defer db.engineMu.RUnlock()
+	return db.db
+}
+
+// finish count transaction as closed
+func (tx *Tx) finish() {
+	if !tx.done && tx.db != nil {
+		tx.done = true
+		atomic.AddInt32(&tx.db.openTxs, -1)
+	}
+}
+
 //Close close DB
 func (db *DB) Close() error {
-	return db.db.Close()
+	db.stopWorkers(context.Background())
+	db.closeWatchers()
+	werr := db.saveWarm()
+	err := db.engine().Close()
+	if err == nil {
+		err = werr
+	}
+	if db.lease != nil {
+		db.lease.release()
+		db.lease = nil
+	}
+	return err
 }
 
 //Rollback rollback data when some error happened
 func (tx *Tx) Rollback() error {
 	if tx.tx != nil {
+		tx.finish()
 		return tx.tx.Rollback()
 	}
 	return errors.New("tx nil")
 }
 
-//Commit commit data at the end
+//Commit commit data at the end, pre-commit hooks may veto it
 func (tx *Tx) Commit() error {
+	if tx.err == nil && tx.tx.Writable() {
+		tx.Error(tx.db.preCommit(tx))
+	}
 	if tx.err == nil {
-		return tx.tx.Commit()
+		tx.finish()
+		return tx.commit()
 	}
 	return tx.err
 }
 
+// View run fn in a read only transaction, return error of fn or error accumulated in tx.
+// Panic of fn is returned as PanicError unless SetRepanic is enabled
+func (db *DB) View(fn func(tx *Tx) error) error {
+	tx := db.NewTx(false)
+	if tx.err != nil {
+		return tx.err
+	}
+	defer tx.Rollback()
+	if err := db.call(tx, fn); err != nil {
+		return err
+	}
+	return tx.err
+}
+
+// Update run fn in a writable transaction, commit if fn return nil and no error accumulated in tx, otherwise rollback.
+// Panic of fn is returned as PanicError unless SetRepanic is enabled
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	tx := db.NewTx(true)
+	if tx.err != nil {
+		return tx.err
+	}
+	defer tx.Rollback()
+	if err := db.call(tx, fn); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 //Error set Tx error or return Tx error
 func (tx *Tx) Error(errs ...error) error {
 	for _, err := range errs {
@@ -92,9 +237,180 @@ func (tx *Tx) Error(errs ...error) error {
 	return tx.err
 }
 
-//createBucketIfWritable create bucket if tx writable and return
-func (tx *Tx) createBucketIfWritable(name []byte) *bolt.Bucket {
-	var b *bolt.Bucket
+// bucketConfig options of a bucket registered on DB
+type bucketConfig struct {
+	maxDeltas  int
+	versioned  bool
+	gc         *GCPolicy
+	envelope   *EnvelopeOptions
+	collation  Collation
+	prefix     keyPrefix
+	codec      Codec
+	indexes    []*index
+	ttl        bool
+	count      *fastCount
+	bloom      *bloom
+	hot        *sketch
+	quota      *Quota
+	sortDesc   bool
+	sortFields SortFields
+	appendOnly bool
+	transform  *KeyTransform
+	merge      MergeFunc
+}
+
+// config get options of bucket, zero value if not registered
+func (db *DB) config(name []byte) bucketConfig {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if c := db.buckets[string(name)]; c != nil {
+		return *c
+	}
+	return bucketConfig{}
+}
+
+// setConfig change options of bucket
+func (db *DB) setConfig(name []byte, fn func(c *bucketConfig)) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.buckets == nil {
+		db.buckets = make(map[string]*bucketConfig)
+	}
+	c := db.buckets[string(name)]
+	if c == nil {
+		c = &bucketConfig{}
+		db.buckets[string(name)] = c
+	}
+	fn(c)
+}
+
+// SetDedup enable or disable write deduplication, when enabled Put skip keys which stored value is byte-identical
+func (db *DB) SetDedup(enabled bool) {
+	db.mu.Lock()
+	db.dedup = enabled
+	db.mu.Unlock()
+}
+
+// put put key value to bucket, skip unchanged value when dedup enabled.
+// Old value is not read for keys after tail of append-only bucket, tail is nil for other buckets
+func (tx *Tx) put(name []byte, b backendBucket, key, value []byte, tail *appendTail) error {
+	if tx.db == nil {
+		return b.Put(key, value)
+	}
+	tx.db.mu.RLock()
+	dedup := tx.db.dedup
+	tx.db.mu.RUnlock()
+	c := tx.db.config(name)
+	fresh := (dedup || len(c.indexes) > 0 || c.count != nil) && tail.after(key)
+	defer tail.advance(key)
+	if dedup && !fresh {
+		if old := tx.get(name, b, key); old != nil && bytes.Equal(old, value) {
+			return nil
+		}
+	}
+	if len(c.indexes) > 0 || c.count != nil {
+		var old []byte
+		if !fresh {
+			old = tx.get(name, withExpired(b), key)
+		}
+		if c.count != nil {
+			if err := tx.countWrite(c, key, old, value); err != nil {
+				return err
+			}
+		}
+		if err := tx.updateIndexes(name, c.indexes, key, old, value); err != nil {
+			return err
+		}
+	}
+	if c.bloom != nil {
+		c.bloom.add(key)
+	}
+	if c.versioned {
+		if err := tx.putVersion(name, key, value, versionPut); err != nil {
+			return err
+		}
+	}
+	tx.notify(name, key, value)
+	if c.maxDeltas > 0 {
+		return tx.putDelta(name, b, key, value, c.maxDeltas)
+	}
+	if err := tx.deleteDeltas(name, key); err != nil {
+		return err
+	}
+	return tx.store(name, b, key, value)
+}
+
+// get get value of key in bucket decoded by reader
+func (tx *Tx) get(name []byte, b backendBucket, key []byte) []byte {
+	return tx.reader(name).value(key, b.Get(key))
+}
+
+// store write encoded value to bucket
+func (tx *Tx) store(name []byte, b backendBucket, key, value []byte) error {
+	if tx.db != nil {
+		if e := tx.db.config(name).envelope; e != nil {
+			codec := e.Codec
+			if tx.codec != 0 {
+				codec = tx.codec
+			}
+			v, err := EncodeEnvelope(Envelope{Codec: codec, Flags: e.Flags, Payload: value})
+			if err != nil {
+				return err
+			}
+			value = v
+			if err := tx.setFeature(FeatureEnvelope); err != nil {
+				return err
+			}
+			if err := tx.markEnvelope(name, b); err != nil {
+				return err
+			}
+		} else if bytes.HasPrefix(value, _envelopeMagic) && tx.hasEnvelope(name) {
+			// raw value would be taken for an envelope in a bucket holding envelopes
+			value, _ = EncodeEnvelope(Envelope{Payload: value})
+		}
+	}
+	return b.Put(key, value)
+}
+
+// reader decode values read from a bucket
+type reader struct {
+	tx       *Tx
+	deltas   backendBucket
+	envelope bool // values may be in envelope
+}
+
+// reader get value reader of bucket
+func (tx *Tx) reader(name []byte) *reader {
+	return &reader{tx: tx, deltas: tx.deltaBucket(name), envelope: tx.hasEnvelope(name)}
+}
+
+// value decode stored value v of key, open envelope and apply deltas
+func (r *reader) value(key, v []byte) []byte {
+	if v == nil {
+		return nil
+	}
+	if !r.envelope {
+		return r.tx.resolve(r.deltas, key, v)
+	}
+	e, err := DecodeEnvelope(v)
+	if err != nil {
+		r.tx.Error(err)
+		return nil
+	}
+	return r.tx.resolve(r.deltas, key, e.Payload)
+}
+
+//createBucketIfWritable create bucket if tx writable and return, name must not be in the namespace of internal buckets
+func (tx *Tx) createBucketIfWritable(name []byte) backendBucket {
+	if tx.Error(checkBucketName(name)) != nil {
+		return nil
+	}
+	return tx.createShadowIfWritable(name)
+}
+
+// createShadowIfWritable create internal or user bucket if tx writable and return
+func (tx *Tx) createShadowIfWritable(name []byte) backendBucket {
+	var b backendBucket
 	var err error
 	if tx.tx.Writable() {
 		b, err = tx.tx.CreateBucketIfNotExists(name)
@@ -116,15 +432,183 @@ func (tx *Tx) Get(name []byte, keys ...[]byte) [][]byte {
 		return [][]byte{}
 	}
 	var bs [][]byte
+	var size int
+	r := tx.reader(name)
+	var c bucketConfig
+	if tx.db != nil {
+		c = tx.db.config(name)
+	}
 	for i := 0; i < len(keys); i++ {
-		v := b.Get(keys[i])
+		if tx.canceled() {
+			return [][]byte{}
+		}
+		if c.hot != nil {
+			c.hot.add(keys[i])
+		}
+		if c.bloom != nil && !c.bloom.has(keys[i]) {
+			continue
+		}
+		v := r.value(keys[i], b.Get(keys[i]))
 		if len(v) != 0 {
+			if tx.overBudget(&size, keys[i], v) {
+				return [][]byte{}
+			}
 			bs = append(bs, keys[i], v)
 		}
 	}
 	return bs
 }
 
+// lookup get value of key in bucket, ok is false if key not exist. Unlike Get an empty value is found
+func (tx *Tx) lookup(name, key []byte) (v []byte, ok bool) {
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return nil, false
+	}
+	raw := b.Get(key)
+	if raw == nil {
+		return nil, false
+	}
+	if v = tx.reader(name).value(key, raw); v == nil {
+		v = []byte{}
+	}
+	return v, true
+}
+
+// GetOrPut get value of key in bucket, put and return defaultValue if key not exist. tx must be writable
+func (tx *Tx) GetOrPut(name, key, defaultValue []byte) ([]byte, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	if v, ok := tx.lookup(name, key); ok || tx.err != nil {
+		return v, tx.err
+	}
+	if err := tx.Put(name, key, defaultValue); err != nil {
+		return nil, err
+	}
+	return defaultValue, nil
+}
+
+// PutIfAbsent put value of key in bucket only if key not exist, return whether value was put. tx must be writable
+func (tx *Tx) PutIfAbsent(name, key, value []byte) (bool, error) {
+	if tx.err != nil {
+		return false, tx.err
+	}
+	if tx.Exists(name, key)[0] {
+		return false, tx.err
+	}
+	if err := tx.Put(name, key, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompareAndSwap put new value of key in bucket only if current value equals old, nil old means key not exist,
+// empty old means an empty value, and nil new delete key. Return ErrCASMismatch without failing tx otherwise, so caller may retry
+func (tx *Tx) CompareAndSwap(name, key, old, new []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	cur, _ := tx.lookup(name, key)
+	if tx.err != nil {
+		return tx.err
+	}
+	if (old == nil) != (cur == nil) || !bytes.Equal(cur, old) {
+		return ErrCASMismatch
+	}
+	if new == nil {
+		return tx.Delete(name, key)
+	}
+	return tx.Put(name, key, new)
+}
+
+// DeleteRange delete keys in [start, end) in bucket like Range, return count of deleted keys
+func (tx *Tx) DeleteRange(name []byte, start, end []byte) (int, error) {
+	return tx.deleteKeys(name, tx.RangeKeys(name, start, end, 0))
+}
+
+// DeletePrefix delete keys with prefix in bucket found by walking a cursor, empty prefix delete all keys.
+// Return count of deleted keys
+func (tx *Tx) DeletePrefix(name []byte, prefix []byte) (int, error) {
+	return tx.deleteKeys(name, tx.PrefixKeys(name, prefix, 0))
+}
+
+// deleteKeys delete keys read from bucket by Delete, return their count
+func (tx *Tx) deleteKeys(name []byte, keys [][]byte) (int, error) {
+	if tx.err != nil || len(keys) == 0 {
+		return 0, tx.err
+	}
+	// keys may point into pages changed by deleting
+	for i, key := range keys {
+		keys[i] = append([]byte{}, key...)
+	}
+	if err := tx.Delete(name, keys...); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// Move move keys with their values from bucket src to bucket dst, reading, putting and deleting in tx.
+// Return ErrRecordNotFound naming the missing keys without moving any key or failing tx if some key not exist
+func (tx *Tx) Move(src, dst []byte, keys ...[]byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	var kvs, missing [][]byte
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		v, ok := tx.lookup(src, key)
+		if tx.err != nil {
+			return tx.err
+		}
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		kvs = append(kvs, append([]byte{}, key...), append([]byte{}, v...))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %q", ErrRecordNotFound, missing)
+	}
+	if len(kvs) == 0 {
+		return nil
+	}
+	if tx.Put(dst, kvs...) != nil {
+		return tx.err
+	}
+	return tx.Delete(src, keys...)
+}
+
+// Exists check keys exist in bucket without reading values, return one result for each key in order
+func (tx *Tx) Exists(name []byte, keys ...[]byte) []bool {
+	found := make([]bool, len(keys))
+	if tx.err != nil {
+		return found
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return found
+	}
+	var c bucketConfig
+	if tx.db != nil {
+		c = tx.db.config(name)
+	}
+	for i, key := range keys {
+		if tx.canceled() {
+			return make([]bool, len(keys))
+		}
+		if c.bloom != nil && !c.bloom.has(key) {
+			continue
+		}
+		found[i] = b.Get(key) != nil
+	}
+	return found
+}
+
 // Put put keys values to bucket, input multiple key value, like [key1,value1,key2,value2, ...]
 func (tx *Tx) Put(name []byte, kvs ...[]byte) error {
 	if tx.err != nil {
@@ -133,12 +617,51 @@ func (tx *Tx) Put(name []byte, kvs ...[]byte) error {
 	if len(kvs) == 0 || len(kvs)%2 != 0 {
 		return tx.Error(errors.New("key value length must is an even number"))
 	}
+	if tx.Error(checkBucketName(name)) != nil || tx.Error(tx.checkParents(name, kvs)) != nil {
+		return tx.err
+	}
 	b, err := tx.tx.CreateBucketIfNotExists(name)
 	if tx.Error(err) != nil {
 		return tx.err
 	}
+	var tail *appendTail
+	if tx.db != nil && tx.db.config(name).appendOnly {
+		fillFull(b)
+		tail = newAppendTail(b, tx.db.config(name))
+	}
 	for i := 0; i < len(kvs); i += 2 {
-		if tx.Error(b.Put(kvs[i], kvs[i+1])) != nil {
+		if tx.canceled() || tx.Error(tx.put(name, b, kvs[i], kvs[i+1], tail)) != nil {
+			return tx.err
+		}
+	}
+	return nil
+}
+
+// Upsert put keys values to bucket, input like [key1,value1,key2,value2, ...], when key exist onConflict decide the stored value,
+// return old to keep, new to replace or a merged value, return nil skip the key
+func (tx *Tx) Upsert(name []byte, kvs [][]byte, onConflict func(key, old, new []byte) []byte) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(kvs) == 0 || len(kvs)%2 != 0 {
+		return tx.Error(errors.New("key value length must is an even number"))
+	}
+	if tx.Error(checkBucketName(name)) != nil || tx.Error(tx.checkParents(name, kvs)) != nil {
+		return tx.err
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	for i := 0; i < len(kvs); i += 2 {
+		key, value := kvs[i], kvs[i+1]
+		if old := tx.get(name, b, key); old != nil && onConflict != nil {
+			value = onConflict(key, old, value)
+			if value == nil {
+				continue
+			}
+		}
+		if tx.Error(tx.put(name, b, key, value, nil)) != nil {
 			return tx.err
 		}
 	}
@@ -154,10 +677,42 @@ func (tx *Tx) Delete(name []byte, keys ...[]byte) error {
 	if b == nil {
 		return nil
 	}
-	for i := 0; i < len(keys); i += 2 {
+	if tx.Error(tx.deleteChildren(name, keys)) != nil {
+		return tx.err
+	}
+	var c bucketConfig
+	if tx.db != nil {
+		c = tx.db.config(name)
+	}
+	for i := 0; i < len(keys); i++ {
+		if len(c.indexes) > 0 || c.count != nil {
+			old := tx.get(name, withExpired(b), keys[i])
+			if tx.Error(tx.updateIndexes(name, c.indexes, keys[i], old, nil)) != nil {
+				return tx.err
+			}
+			if c.count != nil && old != nil {
+				if tx.Error(tx.countWrite(c, keys[i], old, nil)) != nil {
+					return tx.err
+				}
+			}
+		}
+		if c.versioned && b.Get(keys[i]) != nil {
+			if tx.Error(tx.putVersion(name, keys[i], nil, versionDelete)) != nil {
+				return tx.err
+			}
+		}
+		if tx.Error(tx.deleteReverse(name, b, keys[i])) != nil {
+			return tx.err
+		}
+		if tx.watching() && b.Get(keys[i]) != nil {
+			tx.notify(name, keys[i], nil)
+		}
 		if tx.Error(b.Delete(keys[i])) != nil {
 			return tx.err
 		}
+		if tx.Error(tx.deleteDeltas(name, keys[i])) != nil {
+			return tx.err
+		}
 	}
 	return nil
 }
@@ -171,7 +726,13 @@ func (tx *Tx) ForEach(name []byte, fn func(k, v []byte) error) error {
 	if b == nil {
 		return nil
 	}
-	return tx.Error(b.ForEach(fn))
+	r := tx.reader(name)
+	return tx.Error(b.ForEach(func(k, v []byte) error {
+		if tx.canceled() {
+			return tx.err
+		}
+		return fn(k, r.value(k, v))
+	}))
 }
 
 //Next get limit count value after key in bucket
@@ -193,10 +754,18 @@ func (tx *Tx) Next(name []byte, key []byte, limit int) [][]byte {
 			k, v = c.Next()
 		}
 	}
-	n := 0
+	n, size := 0, 0
 	var bs [][]byte
+	r := tx.reader(name)
 	for k != nil {
-		bs = append(bs, k, v)
+		if tx.canceled() {
+			return [][]byte{}
+		}
+		value := r.value(k, v)
+		if tx.overBudget(&size, k, value) {
+			return [][]byte{}
+		}
+		bs = append(bs, k, value)
 		n++
 		if limit > 0 && n >= limit { //limit = 0 representative of all
 			break
@@ -225,10 +794,18 @@ func (tx *Tx) Prev(name []byte, key []byte, limit int) [][]byte {
 			k, v = c.Prev()
 		}
 	}
-	n := 0
+	n, size := 0, 0
 	var bs [][]byte
+	r := tx.reader(name)
 	for k != nil {
-		bs = append(bs, k, v)
+		if tx.canceled() {
+			return [][]byte{}
+		}
+		value := r.value(k, v)
+		if tx.overBudget(&size, k, value) {
+			return [][]byte{}
+		}
+		bs = append(bs, k, value)
 		n++
 		if limit > 0 && n >= limit { //limit = 0 representative of all
 			break
@@ -238,6 +815,149 @@ func (tx *Tx) Prev(name []byte, key []byte, limit int) [][]byte {
 	return bs
 }
 
+// Range get limit count key value with key in [start, end) in bucket, like [key1, value1, key2, value2, ...].
+// Empty start begin with first one, empty end stop after last one, limit = 0 representative of all
+func (tx *Tx) Range(name []byte, start, end []byte, limit int) [][]byte {
+	return tx.scan(name, start, end, limit, false, false)
+}
+
+// RangeReverse get limit count key value with key in [start, end) in bucket like Range, from the last one backward
+func (tx *Tx) RangeReverse(name []byte, start, end []byte, limit int) [][]byte {
+	return tx.scan(name, start, end, limit, true, false)
+}
+
+// Keys get limit count keys after key in bucket without reading values, empty key start with first one
+func (tx *Tx) Keys(name []byte, key []byte, limit int) [][]byte {
+	var start []byte
+	if len(key) > 0 {
+		start = BytesConcat(key, []byte{0})
+	}
+	return tx.scan(name, start, nil, limit, false, true)
+}
+
+// PrefixKeys get limit count keys with prefix in bucket without reading values
+func (tx *Tx) PrefixKeys(name []byte, prefix []byte, limit int) [][]byte {
+	return tx.scan(name, prefix, prefixEnd(prefix), limit, false, true)
+}
+
+// RangeKeys get limit count keys in [start, end) in bucket like Range without reading values
+func (tx *Tx) RangeKeys(name []byte, start, end []byte, limit int) [][]byte {
+	return tx.scan(name, start, end, limit, false, true)
+}
+
+// RangeReverseKeys get limit count keys in [start, end) in bucket like RangeReverse without reading values
+func (tx *Tx) RangeReverseKeys(name []byte, start, end []byte, limit int) [][]byte {
+	return tx.scan(name, start, end, limit, true, true)
+}
+
+// Count count keys in bucket, in read only tx from page stats of storage engine unless keys are translated or expire
+func (tx *Tx) Count(name []byte) int {
+	if tx.err != nil {
+		return 0
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return 0
+	}
+	// page stats miss changes not yet written by writable tx
+	if kc, ok := b.(backendKeyCounter); ok && !tx.tx.Writable() {
+		return kc.KeyN()
+	}
+	return tx.CountPrefix(name, nil)
+}
+
+// CountPrefix count keys with prefix in bucket by walking them without reading values
+func (tx *Tx) CountPrefix(name []byte, prefix []byte) int {
+	if tx.err != nil {
+		return 0
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return 0
+	}
+	c := b.Cursor()
+	n := 0
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if tx.canceled() {
+			return 0
+		}
+		n++
+	}
+	return n
+}
+
+// prefixEnd least key greater than all keys with prefix, nil if there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] != 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// scan get limit count keys in [start, end) in bucket, followed by their values unless keysOnly
+func (tx *Tx) scan(name []byte, start, end []byte, limit int, reverse, keysOnly bool) [][]byte {
+	if tx.err != nil {
+		return [][]byte{}
+	}
+	b := tx.createBucketIfWritable(name)
+	if b == nil {
+		return [][]byte{}
+	}
+	c := b.Cursor()
+	var k, v []byte
+	switch {
+	case !reverse && len(start) > 0:
+		k, v = c.Seek(start)
+	case !reverse:
+		k, v = c.First()
+	case len(end) > 0:
+		if k, v = c.Seek(end); k != nil {
+			k, v = c.Prev()
+		} else {
+			k, v = c.Last()
+		}
+	default:
+		k, v = c.Last()
+	}
+	n, size := 0, 0
+	var bs [][]byte
+	r := tx.reader(name)
+	for k != nil {
+		if !reverse && len(end) > 0 && bytes.Compare(k, end) >= 0 || reverse && len(start) > 0 && bytes.Compare(k, start) < 0 {
+			break
+		}
+		if tx.canceled() {
+			return [][]byte{}
+		}
+		if keysOnly {
+			if tx.overBudget(&size, k) {
+				return [][]byte{}
+			}
+			bs = append(bs, k)
+		} else {
+			value := r.value(k, v)
+			if tx.overBudget(&size, k, value) {
+				return [][]byte{}
+			}
+			bs = append(bs, k, value)
+		}
+		n++
+		if limit > 0 && n >= limit {
+			break
+		}
+		if reverse {
+			k, v = c.Prev()
+		} else {
+			k, v = c.Next()
+		}
+	}
+	return bs
+}
+
 // Sequence get current sequence in bucket, if bucket not exist, create it, begin with 0
 func (tx *Tx) Sequence(name []byte) uint64 {
 	if tx.err != nil {
@@ -255,6 +975,9 @@ func (tx *Tx) NextSequence(name []byte) (uint64, error) {
 	if tx.err != nil {
 		return 0, tx.err
 	}
+	if tx.Error(checkBucketName(name)) != nil {
+		return 0, tx.err
+	}
 	b, err := tx.tx.CreateBucketIfNotExists(name)
 	if tx.Error(err) != nil {
 		return 0, tx.err
@@ -271,10 +994,112 @@ func (tx *Tx) DeleteBucket(name []byte) error {
 	if tx.err != nil {
 		return tx.err
 	}
+	for _, prefix := range [][]byte{_deltaPrefix, _versionPrefix, _reversePrefix} {
+		if tx.tx.Bucket(shadowName(prefix, name)) != nil {
+			if tx.Error(tx.tx.DeleteBucket(shadowName(prefix, name))) != nil {
+				return tx.err
+			}
+		}
+	}
+	if tx.Error(tx.deleteIndexes(name)) != nil {
+		return tx.err
+	}
 	return tx.Error(tx.tx.DeleteBucket(name))
 }
 
-// SortPut sort put key value to bucket, like timeline as sortKey
+// Truncate delete all keys of bucket with its delta, version, reverse, expiry and index entries and recreate it empty,
+// keeping its sequence if keepSequence. Sort entries of bucket are kept
+func (tx *Tx) Truncate(name []byte, keepSequence bool) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	b := tx.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	seq := b.Sequence()
+	if tx.DeleteBucket(name) != nil {
+		return tx.err
+	}
+	if tx.tx.Bucket(expiryBucketName(name)) != nil {
+		if tx.Error(tx.tx.DeleteBucket(expiryBucketName(name))) != nil {
+			return tx.err
+		}
+	}
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	if tx.Error(err) != nil {
+		return tx.err
+	}
+	if keepSequence {
+		if tx.Error(b.SetSequence(seq)) != nil {
+			return tx.err
+		}
+	}
+	if tx.db != nil {
+		tx.countReset(tx.db.config(name))
+	}
+	return nil
+}
+
+// WriteTo write a consistent snapshot of the whole db file to w
+func (tx *Tx) WriteTo(w io.Writer) (int64, error) {
+	if tx.err != nil {
+		return 0, tx.err
+	}
+	n, err := tx.tx.WriteTo(w)
+	if err == nil && tx.db != nil {
+		tx.db.mu.Lock()
+		tx.db.lastBackup = time.Now()
+		tx.db.mu.Unlock()
+	}
+	return n, tx.Error(err)
+}
+
+// ForEachBucket traveral all bucket names in db, include internal buckets
+func (tx *Tx) ForEachBucket(fn func(name []byte) error) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	return tx.Error(tx.tx.ForEach(func(name []byte, _ backendBucket) error {
+		return fn(name)
+	}))
+}
+
+// Buckets get names of all buckets in db ordered by name, see BucketsWithPrefix
+func (tx *Tx) Buckets(internal bool) [][]byte {
+	return tx.BucketsWithPrefix(nil, internal)
+}
+
+// BucketsWithPrefix get names of buckets starting with prefix ordered by name. If internal is false, buckets with sort
+// are listed by their name instead of their key and value buckets and other internal buckets are hidden,
+// otherwise the raw name of every bucket is listed
+func (tx *Tx) BucketsWithPrefix(prefix []byte, internal bool) [][]byte {
+	names := [][]byte{}
+	if tx.err != nil {
+		return names
+	}
+	seen := map[string]bool{}
+	err := tx.tx.ForEach(func(name []byte, _ backendBucket) error {
+		if !internal && isShadowName(name) {
+			if !bytes.HasPrefix(name, _keyPrefix) && !bytes.HasPrefix(name, _valuePrefix) {
+				return nil
+			}
+			name = name[1:]
+		}
+		if bytes.HasPrefix(name, prefix) && !seen[string(name)] {
+			seen[string(name)] = true
+			names = append(names, append([]byte{}, name...))
+		}
+		return nil
+	})
+	if tx.Error(err) != nil {
+		return [][]byte{}
+	}
+	sort.Slice(names, func(i, j int) bool { return bytes.Compare(names[i], names[j]) < 0 })
+	return names
+}
+
+// SortPut sort put key value to bucket, like timeline as sortKey, sortKey may have any length
 func (tx *Tx) SortPut(name []byte, sortKey []byte, kvs ...[]byte) error {
 	if tx.err != nil {
 		return tx.err
@@ -282,22 +1107,39 @@ func (tx *Tx) SortPut(name []byte, sortKey []byte, kvs ...[]byte) error {
 	if len(kvs) == 0 || len(kvs)%2 != 0 {
 		return tx.Error(errors.New("key value length must is an even number"))
 	}
-	keyBucket, err := tx.tx.CreateBucketIfNotExists(BytesConcat(_keyPrefix, name))
+	if tx.Error(checkBucketName(name)) != nil || tx.Error(tx.checkParents(name, kvs)) != nil {
+		return tx.err
+	}
+	keyBucket, err := tx.tx.CreateBucketIfNotExists(shadowName(_keyPrefix, name))
 	if tx.Error(err) != nil {
 		return tx.err
 	}
-	valueBucket, err := tx.tx.CreateBucketIfNotExists(BytesConcat(_valuePrefix, name))
+	valueBucket, err := tx.tx.CreateBucketIfNotExists(shadowName(_valuePrefix, name))
 	if tx.Error(err) != nil {
 		return tx.err
 	}
+	var c bucketConfig
+	if tx.db != nil {
+		c = tx.db.config(name)
+	}
 	for i := 0; i < len(kvs); i += 2 {
 		key, value := kvs[i], kvs[i+1]
 		old := valueBucket.Get(key)
-		if !bytes.Equal(sortKey, old) {
-			if tx.Error(keyBucket.Put(BytesConcat(sortKey, key), value)) != nil {
+		if c.count != nil {
+			var oldValue []byte
+			if old != nil {
+				oldValue = keyBucket.Get(old)
+			}
+			if tx.Error(tx.countWrite(c, key, oldValue, value)) != nil {
 				return tx.err
 			}
-			if tx.Error(valueBucket.Put(key, BytesConcat(sortKey, key))) != nil {
+		}
+		entry := c.sortOrder().entryKey(sortKey, key)
+		if tx.Error(keyBucket.Put(entry, value)) != nil {
+			return tx.err
+		}
+		if !bytes.Equal(entry, old) {
+			if tx.Error(valueBucket.Put(key, entry)) != nil {
 				return tx.err
 			}
 			if old != nil {
@@ -315,19 +1157,31 @@ func (tx *Tx) SortDelete(name []byte, keys ...[]byte) error {
 	if tx.err != nil {
 		return tx.err
 	}
-	keyBucket, err := tx.tx.CreateBucketIfNotExists(BytesConcat(_keyPrefix, name))
+	keyBucket, err := tx.tx.CreateBucketIfNotExists(shadowName(_keyPrefix, name))
 	if tx.Error(err) != nil {
 		return tx.err
 	}
-	valueBucket, err := tx.tx.CreateBucketIfNotExists(BytesConcat(_valuePrefix, name))
+	valueBucket, err := tx.tx.CreateBucketIfNotExists(shadowName(_valuePrefix, name))
 	if tx.Error(err) != nil {
 		return tx.err
 	}
+	if tx.Error(tx.deleteChildren(name, keys)) != nil {
+		return tx.err
+	}
+	var c bucketConfig
+	if tx.db != nil {
+		c = tx.db.config(name)
+	}
 	for i := 0; i < len(keys); i++ {
 		value := valueBucket.Get(keys[i])
 		if value == nil {
 			continue
 		}
+		if c.count != nil {
+			if tx.Error(tx.countWrite(c, keys[i], keyBucket.Get(value), nil)) != nil {
+				return tx.err
+			}
+		}
 		if tx.Error(keyBucket.Delete(value)) != nil {
 			return tx.err
 		}
@@ -338,82 +1192,94 @@ func (tx *Tx) SortDelete(name []byte, keys ...[]byte) error {
 	return nil
 }
 
+// SortDeleteRange delete entries of bucket with sort whose sort key is in [from, to) like SortRange,
+// return count of deleted entries
+func (tx *Tx) SortDeleteRange(name []byte, from, to []byte) (int, error) {
+	entries := tx.SortRangeKeys(name, from, to, 0)
+	if tx.err != nil || len(entries) == 0 {
+		return 0, tx.err
+	}
+	keys := make([][]byte, len(entries))
+	for i, e := range entries {
+		keys[i] = append([]byte{}, e.Key...)
+	}
+	if err := tx.SortDelete(name, keys...); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
 // SortDeleteBucket sort delete bucket
 func (tx *Tx) SortDeleteBucket(name []byte) error {
 	if tx.err != nil {
 		return tx.err
 	}
-	if tx.Error(tx.tx.DeleteBucket(BytesConcat(_keyPrefix, name))) != nil {
+	if tx.Error(tx.tx.DeleteBucket(shadowName(_keyPrefix, name))) != nil {
 		return tx.err
 	}
-	if tx.Error(tx.tx.DeleteBucket(BytesConcat(_valuePrefix, name))) != nil {
+	if tx.Error(tx.tx.DeleteBucket(shadowName(_valuePrefix, name))) != nil {
 		return tx.err
 	}
 	return nil
 }
 
-// SortNext get limit count key value after key in bucket with sort
-func (tx *Tx) SortNext(name []byte, key []byte, limit int) [][]byte {
+// SortGet get key, sort key and value of keys in bucket with sort, like [key1,sortKey1,value1, ...], missing keys are skipped
+func (tx *Tx) SortGet(name []byte, keys ...[]byte) [][]byte {
 	if tx.err != nil {
 		return [][]byte{}
 	}
-	b := tx.createBucketIfWritable(BytesConcat(_keyPrefix, name))
-	if b == nil {
+	keyBucket := tx.tx.Bucket(shadowName(_keyPrefix, name))
+	valueBucket := tx.tx.Bucket(shadowName(_valuePrefix, name))
+	if keyBucket == nil || valueBucket == nil {
 		return [][]byte{}
 	}
-	c := b.Cursor()
-	var k, v []byte
-	if len(key) == 0 { // if len key == 0, start with first one
-		k, v = c.First()
-	} else {
-		k, v = c.Seek(key)
-		if k != nil && bytes.Compare(k[:8], key) == 0 {
-			k, v = c.Next()
-		}
-	}
-	n := 0
+	o := tx.sortOrder(name)
 	var bs [][]byte
-	for k != nil && bytes.Compare(k[:8], _keyMax) <= 0 {
-		bs = append(bs, k[8:], v)
-		n++
-		if limit > 0 && n >= limit { //limit = 0 representative of all
-			break
+	for _, key := range keys {
+		entry := valueBucket.Get(key)
+		if entry == nil {
+			continue
 		}
-		k, v = c.Next()
+		sortKey, _, ok := o.split(entry)
+		v := keyBucket.Get(entry)
+		if !ok || v == nil {
+			continue
+		}
+		bs = append(bs, key, sortKey, v)
 	}
 	return bs
 }
 
+// SortNext get limit count key value after key in bucket with sort
+func (tx *Tx) SortNext(name []byte, key []byte, limit int) [][]byte {
+	return entryKVs(tx.SortNextEntries(name, key, limit))
+}
+
 // SortPrev get limit count key value front key in bucket with sort
 func (tx *Tx) SortPrev(name []byte, key []byte, limit int) [][]byte {
+	return entryKVs(tx.SortPrevEntries(name, key, limit))
+}
+
+// SortForEach traveral all key value in bucket with sort ordered by sort key, without building the result like SortNext
+func (tx *Tx) SortForEach(name []byte, fn func(sortKey, key, value []byte) error) error {
 	if tx.err != nil {
-		return [][]byte{}
+		return tx.err
 	}
-	b := tx.createBucketIfWritable(BytesConcat(_keyPrefix, name))
+	b := tx.tx.Bucket(shadowName(_keyPrefix, name))
 	if b == nil {
-		return [][]byte{}
+		return nil
 	}
-	c := b.Cursor()
-	var k, v []byte
-	if len(key) == 0 { // if len key == 0, start with last one
-		k, v = c.Last()
-	} else {
-		k, v = c.Seek(key)
-		if bytes.Compare(k[:8], key) >= 0 {
-			k, v = c.Prev()
+	o := tx.sortOrder(name)
+	return tx.Error(b.ForEach(func(k, v []byte) error {
+		if tx.canceled() {
+			return tx.err
 		}
-	}
-	n := 0
-	var bs [][]byte
-	for k != nil && bytes.Compare(k[:8], _keyMin) >= 0 {
-		bs = append(bs, k[8:], v)
-		n++
-		if limit > 0 && n >= limit { //limit = 0 representative of all
-			break
+		sortKey, key, ok := o.split(k)
+		if !ok {
+			return nil
 		}
-		k, v = c.Prev()
-	}
-	return bs
+		return fn(sortKey, key, v)
+	}))
 }
 
 // BytesConcat concat bytes
@@ -430,7 +1296,7 @@ func BytesConcat(slices ...[]byte) []byte {
 	return tmp
 }
 
-// BucketNameConcat concat bucket name
+// BucketNameConcat concat bucket name with "_", parts containing "_" may collide, prefer BucketName
 func BucketNameConcat(slices ...[]byte) []byte {
 	var totalLen int
 	for _, s := range slices {
@@ -463,18 +1329,36 @@ func BytesToUint64(v []byte) uint64 {
 	return binary.BigEndian.Uint64(v)
 }
 
+// TimeToBytes parse time to 8 bytes keeping order, nanoseconds since unix epoch with sign bit flipped,
+// times out of int64 nanoseconds range like zero time are clamped
+func TimeToBytes(t time.Time) []byte {
+	switch {
+	case t.Before(_timeMin):
+		t = _timeMin
+	case t.After(_timeMax):
+		t = _timeMax
+	}
+	return Uint64ToBytes(uint64(t.UnixNano()) ^ 1<<63)
+}
+
+// BytesToTime parse bytes of TimeToBytes to time
+func BytesToTime(b []byte) time.Time {
+	return time.Unix(0, int64(BytesToUint64(b)^1<<63))
+}
+
 // BytesToString parse bytes to string
 func BytesToString(b []byte) string {
 	return *(*string)(unsafe.Pointer(&b))
 }
 
-// StringToBytes parse string to bytes
+// StringToBytes parse string to bytes without copy, the bytes must not be modified
 func StringToBytes(s string) []byte {
+	// header of a real slice, a SliceHeader value does not keep s alive and fails go vet
+	var b []byte
 	sh := (*reflect.StringHeader)(unsafe.Pointer(&s))
-	bh := reflect.SliceHeader{
-		Data: sh.Data,
-		Len:  sh.Len,
-		Cap:  sh.Len,
-	}
-	return *(*[]byte)(unsafe.Pointer(&bh))
+	bh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	bh.Data = sh.Data
+	bh.Len = sh.Len
+	bh.Cap = sh.Len
+	return b
 }
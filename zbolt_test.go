@@ -1,7 +1,10 @@
 package zbolt
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -199,6 +202,418 @@ func TestTx_SortDelete(t *testing.T) {
 	for i := 0; i < len(prev3); i += 2 {
 		fmt.Println(BytesToUint64(prev3[i]))
 	}
-	tx1.Rollback()
+	// tx3 must be closed, a read tx left open block the remap of a later write growing z.db
+	tx3.Rollback()
 	fmt.Println("======================")
 }
+
+func TestTx_Upsert(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("upsert")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b"), []byte("2"))
+	kvs := [][]byte{[]byte("a"), []byte("x"), []byte("b"), []byte("y"), []byte("c"), []byte("z")}
+	err := tx.Upsert(name, kvs, func(key, old, new []byte) []byte {
+		if string(key) == "a" {
+			return nil
+		}
+		return BytesConcat(old, new)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gets := tx.Get(name, []byte("a"), []byte("b"), []byte("c"))
+	if string(gets[1]) != "1" || string(gets[3]) != "2y" || string(gets[5]) != "z" {
+		t.Fatal("unexpected upsert result", gets)
+	}
+}
+
+func TestDB_SetDedup(t *testing.T) {
+	name := []byte("dedup")
+	put := func() int {
+		before := db.db.Stats().Writes
+		tx := db.NewTx(true)
+		defer tx.Rollback()
+		tx.Put(name, []byte("key"), []byte("value"))
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return db.db.Stats().Writes - before
+	}
+	put()
+	changed := put()
+	db.SetDedup(true)
+	defer db.SetDedup(false)
+	if deduped := put(); deduped >= changed {
+		t.Fatal("dedup put should write less pages", deduped, changed)
+	}
+}
+
+func TestTx_Range(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("range")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b"), []byte("2"), []byte("c"), []byte("3"), []byte("d"), []byte("4"))
+	keys := func(kvs [][]byte) string {
+		var s string
+		for i := 0; i < len(kvs); i += 2 {
+			s += string(kvs[i])
+		}
+		return s
+	}
+	for _, c := range []struct {
+		start, end string
+		limit      int
+		want, rev  string
+	}{
+		{"b", "d", 0, "bc", "cb"},
+		{"", "c", 0, "ab", "ba"},
+		{"bb", "", 0, "cd", "dc"},
+		{"a", "z", 3, "abc", "dcb"},
+		{"c", "c", 0, "", ""},
+	} {
+		if got := keys(tx.Range(name, []byte(c.start), []byte(c.end), c.limit)); got != c.want {
+			t.Fatal("range", c.start, c.end, got)
+		}
+		if got := keys(tx.RangeReverse(name, []byte(c.start), []byte(c.end), c.limit)); got != c.rev {
+			t.Fatal("range reverse", c.start, c.end, got)
+		}
+	}
+}
+
+func TestTx_Keys(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("keys_only")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("ab"), []byte("2"), []byte("b"), []byte("3"), []byte{'b', 0xff}, []byte("4"))
+	join := func(ks [][]byte) string {
+		var s string
+		for _, k := range ks {
+			s += string(k) + ","
+		}
+		return s
+	}
+	for _, c := range []struct {
+		got  [][]byte
+		want string
+	}{
+		{tx.Keys(name, nil, 2), "a,ab,"},
+		{tx.Keys(name, []byte("a"), 0), "ab,b,b\xff,"},
+		{tx.PrefixKeys(name, []byte("a"), 0), "a,ab,"},
+		{tx.PrefixKeys(name, []byte{'b', 0xff}, 0), "b\xff,"},
+		{tx.RangeKeys(name, []byte("ab"), []byte("b"), 0), "ab,"},
+		{tx.RangeReverseKeys(name, []byte("ab"), nil, 2), "b\xff,b,"},
+	} {
+		if got := join(c.got); got != c.want {
+			t.Fatalf("unexpected keys %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestTx_Count(t *testing.T) {
+	name := []byte("count")
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put(name, []byte("a1"), []byte("1"), []byte("a2"), []byte("2"), []byte("b1"), []byte("3"))
+	if n := tx.Count(name); n != 3 {
+		t.Fatal("unexpected count in writable tx", n)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx = db.NewTx(false)
+	defer tx.Rollback()
+	if n := tx.Count(name); n != 3 {
+		t.Fatal("unexpected count", n)
+	}
+	if n := tx.CountPrefix(name, []byte("a")); n != 2 {
+		t.Fatal("unexpected prefix count", n)
+	}
+	if n := tx.Count([]byte("count_missing")); n != 0 {
+		t.Fatal("unexpected count of missing bucket", n)
+	}
+}
+
+func TestTx_Exists(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("exists")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("c"), []byte("3"))
+	if found := tx.Exists(name, []byte("a"), []byte("b"), []byte("c")); fmt.Sprint(found) != "[true false true]" {
+		t.Fatal("unexpected exists", found)
+	}
+	if found := tx.Exists([]byte("exists_missing"), []byte("a")); found[0] {
+		t.Fatal("key of missing bucket exists")
+	}
+}
+
+func TestTx_GetOrPut(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("get_or_put")
+	if v, err := tx.GetOrPut(name, []byte("k"), []byte("default")); err != nil || string(v) != "default" {
+		t.Fatal("default not put", string(v), err)
+	}
+	if v, err := tx.GetOrPut(name, []byte("k"), []byte("other")); err != nil || string(v) != "default" {
+		t.Fatal("existing value not returned", string(v), err)
+	}
+	tx.Put(name, []byte("empty"), []byte{})
+	if v, err := tx.GetOrPut(name, []byte("empty"), []byte("other")); err != nil || v == nil || len(v) != 0 {
+		t.Fatal("existing empty value not returned", string(v), err)
+	}
+}
+
+func TestTx_PutIfAbsent(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("put_if_absent")
+	if ok, err := tx.PutIfAbsent(name, []byte("leader"), []byte("a")); err != nil || !ok {
+		t.Fatal("first claim must win", ok, err)
+	}
+	if ok, err := tx.PutIfAbsent(name, []byte("leader"), []byte("b")); err != nil || ok {
+		t.Fatal("second claim must lose", ok, err)
+	}
+	if v := tx.Get(name, []byte("leader")); string(v[1]) != "a" {
+		t.Fatal("value overwritten", string(v[1]))
+	}
+}
+
+func TestTx_CompareAndSwap(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("cas")
+	k := []byte("version")
+	tx.Delete(name, k)
+	if err := tx.CompareAndSwap(name, k, nil, []byte("1")); err != nil {
+		t.Fatal("swap absent key fail", err)
+	}
+	if err := tx.CompareAndSwap(name, k, nil, []byte("2")); err != ErrCASMismatch {
+		t.Fatal("expect ErrCASMismatch", err)
+	}
+	if err := tx.CompareAndSwap(name, k, []byte("0"), []byte("2")); err != ErrCASMismatch {
+		t.Fatal("expect ErrCASMismatch", err)
+	}
+	if err := tx.CompareAndSwap(name, k, []byte("1"), []byte("2")); err != nil {
+		t.Fatal("swap fail", err)
+	}
+	if err := tx.CompareAndSwap(name, k, []byte("2"), nil); err != nil || len(tx.Get(name, k)) != 0 {
+		t.Fatal("swap to nil must delete", err)
+	}
+	if err := tx.CompareAndSwap(name, k, nil, []byte{}); err != nil {
+		t.Fatal("swap absent key to empty fail", err)
+	}
+	if err := tx.CompareAndSwap(name, k, nil, []byte("3")); err != ErrCASMismatch {
+		t.Fatal("empty value must not match absent key", err)
+	}
+	if err := tx.CompareAndSwap(name, k, []byte{}, []byte("3")); err != nil {
+		t.Fatal("swap empty value fail", err)
+	}
+}
+
+func TestTx_DeleteEveryKey(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("delete_every")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b"), []byte("2"), []byte("c"), []byte("3"))
+	if err := tx.Delete(name, []byte("a"), []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if gets := tx.Get(name, []byte("a"), []byte("b"), []byte("c")); len(gets) != 2 || string(gets[0]) != "c" {
+		t.Fatal("every given key must be deleted", gets)
+	}
+}
+
+func TestStringToBytes(t *testing.T) {
+	if b := StringToBytes("zbolt"); string(b) != "zbolt" || cap(b) != 5 {
+		t.Fatal("unexpected bytes", b)
+	}
+	if b := StringToBytes(""); len(b) != 0 {
+		t.Fatal("unexpected bytes of empty string", b)
+	}
+}
+
+func TestTx_Move(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	pending, done := []byte("move_pending"), []byte("move_done")
+	tx.Put(pending, []byte("a"), []byte("1"), []byte("b"), []byte("2"))
+	if err := tx.Move(pending, done, []byte("a"), []byte("x")); !errors.Is(err, ErrRecordNotFound) || !strings.Contains(err.Error(), `"x"`) {
+		t.Fatal("expect missing key reported", err)
+	}
+	if err := tx.Move(pending, done, []byte("a"), []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Get(pending, []byte("a"), []byte("b"))) != 0 {
+		t.Fatal("moved keys left in source")
+	}
+	if gets := tx.Get(done, []byte("a"), []byte("b")); len(gets) != 4 || string(gets[3]) != "2" {
+		t.Fatal("unexpected moved values", gets)
+	}
+	// duplicate keys and empty values
+	tx.Put(pending, []byte("c"), []byte("3"), []byte("e"), []byte{})
+	if err := tx.Move(pending, done, []byte("c"), []byte("c"), []byte("e")); err != nil {
+		t.Fatal("expect duplicate keys and empty value moved", err)
+	}
+	if found := tx.Exists(done, []byte("c"), []byte("e")); !found[0] || !found[1] {
+		t.Fatal("keys not moved", found)
+	}
+	if found := tx.Exists(pending, []byte("c"), []byte("e")); found[0] || found[1] {
+		t.Fatal("moved keys left in source", found)
+	}
+}
+
+func TestTx_DeleteRange(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("delete_range")
+	for _, k := range []string{"a", "b", "c", "d"} {
+		tx.Put(name, []byte(k), []byte(k))
+		tx.SortPut(name, []byte(k), []byte(k), []byte(k))
+	}
+	if n, err := tx.DeleteRange(name, []byte("b"), []byte("d")); err != nil || n != 2 {
+		t.Fatal("unexpected delete range", n, err)
+	}
+	if keys := tx.Keys(name, nil, 0); len(keys) != 2 || string(keys[0]) != "a" || string(keys[1]) != "d" {
+		t.Fatalf("unexpected keys left %q", keys)
+	}
+	tx.Put(name, []byte("tenant1/a"), []byte("1"), []byte("tenant1/b"), []byte("2"), []byte("tenant2/a"), []byte("3"))
+	if n, err := tx.DeletePrefix(name, []byte("tenant1/")); err != nil || n != 2 {
+		t.Fatal("unexpected delete prefix", n, err)
+	}
+	if keys := tx.PrefixKeys(name, []byte("tenant"), 0); len(keys) != 1 || string(keys[0]) != "tenant2/a" {
+		t.Fatalf("unexpected prefix keys left %q", keys)
+	}
+	if n, err := tx.SortDeleteRange(name, nil, []byte("c")); err != nil || n != 2 {
+		t.Fatal("unexpected sort delete range", n, err)
+	}
+	if kvs := tx.SortNext(name, nil, 0); len(kvs) != 4 || string(kvs[0]) != "c" {
+		t.Fatalf("unexpected sort entries left %q", kvs)
+	}
+}
+
+func TestTx_Truncate(t *testing.T) {
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	name := []byte("truncate")
+	tx.Put(name, []byte("a"), []byte("1"), []byte("b"), []byte("2"))
+	tx.NextSequence(name)
+	if err := tx.Truncate(name, true); err != nil {
+		t.Fatal(err)
+	}
+	if tx.tx.Bucket(name) == nil || tx.Count(name) != 0 {
+		t.Fatal("expect empty bucket kept")
+	}
+	if seq, _ := tx.NextSequence(name); seq != 2 {
+		t.Fatal("expect sequence kept", seq)
+	}
+	if err := tx.Truncate(name, false); err != nil {
+		t.Fatal(err)
+	}
+	if seq, _ := tx.NextSequence(name); seq != 1 {
+		t.Fatal("expect sequence reset", seq)
+	}
+}
+
+func TestTx_TruncateFastCount(t *testing.T) {
+	d, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	name := []byte("truncate")
+	if err := d.EnableFastCount(name); err != nil {
+		t.Fatal(err)
+	}
+	err = d.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("a"), []byte("1"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Update(func(tx *Tx) error {
+		tx.Put(name, []byte("b"), []byte("2"))
+		return tx.Truncate(name, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := d.FastCount(name); c.Keys != 0 || c.Bytes != 0 {
+		t.Fatal("expect fast count reset", c)
+	}
+}
+
+func TestTx_Buckets(t *testing.T) {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tx := db.NewTx(true)
+	defer tx.Rollback()
+	tx.Put([]byte("b_plain"), []byte("k"), []byte("v"))
+	tx.SortPut([]byte("a_sorted"), []byte("s"), []byte("k"), []byte("v"))
+	tx.Put([]byte("c"), []byte("k"), []byte("v"))
+	if got := fmt.Sprintf("%s", tx.Buckets(false)); got != "[a_sorted b_plain c]" {
+		t.Fatal("unexpected buckets", got)
+	}
+	if got := fmt.Sprintf("%s", tx.BucketsWithPrefix([]byte("a_"), false)); got != "[a_sorted]" {
+		t.Fatal("unexpected buckets with prefix", got)
+	}
+	var raw int
+	for _, name := range tx.Buckets(true) {
+		if bytes.Equal(name, BytesConcat(_keyPrefix, []byte("a_sorted"))) || bytes.Equal(name, BytesConcat(_valuePrefix, []byte("a_sorted"))) {
+			raw++
+		}
+	}
+	if raw != 2 {
+		t.Fatal("expect key and value buckets of sort listed", raw)
+	}
+}
+
+func TestDB_Update(t *testing.T) {
+	name := []byte("update_view")
+	if err := db.Update(func(tx *Tx) error {
+		return tx.Put(name, []byte("k1"), []byte("v1"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Update(func(tx *Tx) error {
+		tx.Put(name, []byte("k2"), []byte("v2"))
+		tx.Error(ErrRecordNotFound)
+		return nil
+	}); err != ErrRecordNotFound {
+		t.Fatal("expect accumulated error, got", err)
+	}
+	err := db.Update(func(tx *Tx) error {
+		tx.Put(name, []byte("k3"), []byte("v3"))
+		panic("boom")
+	})
+	if pe, ok := err.(*PanicError); !ok || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatal("expect PanicError, got", err)
+	}
+	if err := db.View(func(tx *Tx) error {
+		if gets := tx.Get(name, []byte("k1"), []byte("k2"), []byte("k3")); len(gets) != 2 {
+			t.Fatal("unexpected values", gets)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDB_SetRepanic(t *testing.T) {
+	db.SetRepanic(true)
+	defer db.SetRepanic(false)
+	open := db.openTxCount()
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatal("expect repanic, got", r)
+		}
+		if db.openTxCount() != open {
+			t.Fatal("transaction leaked")
+		}
+	}()
+	db.Update(func(tx *Tx) error {
+		panic("boom")
+	})
+}